@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterAttachmentsRoutes(router *mux.Router, service services.IAttachmentService) {
+	router.Path("").HandlerFunc(uploadAttachment(service)).Methods("POST")
+	router.Path("/{id}/url").HandlerFunc(getAttachmentURL(service)).Methods("GET")
+}
+
+// uploadAttachment takes a multipart form with the file in its "file" field,
+// the chat is set with ?chatId=. The file is streamed, not buffered in memory.
+func uploadAttachment(service services.IAttachmentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(r.URL.Query().Get("chatId"))
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, services.MAX_ATTACHMENT_SIZE+1<<20)
+		reader, err := r.MultipartReader()
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				err := errors.New(`no "file" field in the form`)
+				WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+				return
+			}
+			if part.FormName() != "file" {
+				continue
+			}
+
+			attachment, httpErr := service.Upload(payload.UserId, chatId, part.FileName(), part)
+			if httpErr != nil {
+				WriteError(w, httpErr)
+				return
+			}
+
+			writeResponce(w, attachment)
+			return
+		}
+	}
+}
+
+// getAttachmentURL signs the URL of the original or, with ?variant=, of a thumbnail.
+func getAttachmentURL(service services.IAttachmentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attachmentId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		url, httpErr := service.GetURL(payload.UserId, attachmentId, r.URL.Query().Get("variant"))
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, url)
+	}
+}
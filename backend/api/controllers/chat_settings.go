@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterChatSettingsRoutes(router *mux.Router, service services.IChatSettingsService) {
+	router.Path("/{id}/settings").HandlerFunc(getChatSettings(service)).Methods("GET")
+	router.Path("/{id}/settings").HandlerFunc(updateChatSettings(service)).Methods("PUT")
+}
+
+func getChatSettings(service services.IChatSettingsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		settings, httpErr := service.Get(payload.UserId, chatId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, settings)
+	}
+}
+
+func updateChatSettings(service services.IChatSettingsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		var settings models.ChatSettings
+		err = json.NewDecoder(r.Body).Decode(&settings)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+		settings.ChatId = chatId
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		updSettings, httpErr := service.Update(payload.UserId, settings)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, updSettings)
+	}
+}
@@ -18,9 +18,9 @@ import (
 func getChatService(db *sql.DB) services.ChatService {
 	messageStorer := models.NewMessageStorer(db)
 	participantStorer := models.NewParticipantStorer(db)
-	participantService := services.NewParticipantService(participantStorer)
+	participantService := services.NewParticipantService(participantStorer, messageStorer, nil)
 	chatStorer := models.NewChatStorer(db)
-	return services.NewChatService(chatStorer, participantStorer, messageStorer, participantService)
+	return services.NewChatService(chatStorer, participantStorer, messageStorer, participantService, nil)
 }
 
 func TestCreateChat1(t *testing.T) {
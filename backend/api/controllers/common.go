@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/BogPin/real-time-chat/backend/api/utils"
 	"github.com/gorilla/mux"
@@ -39,3 +41,28 @@ func writeResponce(w http.ResponseWriter, resp any) {
 		return
 	}
 }
+
+// optionalIntParam reads an integer query parameter, 0 when it isn't set.
+func optionalIntParam(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// optionalTimeParam reads a query parameter holding a date or an RFC 3339 time,
+// the zero time when it isn't set. With endOfDay dates point to the start of the next day.
+func optionalTimeParam(r *http.Request, name string, endOfDay bool) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
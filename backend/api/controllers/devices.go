@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterDevicesRoutes(router *mux.Router, service services.IDeviceService) {
+	router.Path("").HandlerFunc(registerDevice(service)).Methods("POST")
+	router.Path("").HandlerFunc(getDevices(service)).Methods("GET")
+	router.Path("/{id}").HandlerFunc(unregisterDevice(service)).Methods("DELETE")
+}
+
+func registerDevice(service services.IDeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var fromRequest models.DeviceFromRequest
+		err := json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		device, httpErr := service.Register(payload.UserId, fromRequest)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, device)
+	}
+}
+
+func getDevices(service services.IDeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		devices, httpErr := service.GetUserDevices(payload.UserId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, devices)
+	}
+}
+
+func unregisterDevice(service services.IDeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		device, httpErr := service.Unregister(payload.UserId, deviceId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, device)
+	}
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterExportsRoutes(router *mux.Router, service services.IExportService) {
+	router.Path("/{id}/export").HandlerFunc(streamExport(service)).Methods("GET")
+	router.Path("/{id}/exports").HandlerFunc(getExports(service)).Methods("GET")
+	router.Path("/{id}/exports").HandlerFunc(createExport(service)).Methods("POST")
+	router.Path("/{id}/exports/{exportId}").HandlerFunc(getExport(service)).Methods("GET")
+	router.Path("/{id}/exports/{exportId}/url").HandlerFunc(getExportURL(service)).Methods("GET")
+}
+
+// streamExport writes the history of a small chat in ?format= as a file download.
+func streamExport(service services.IExportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		write, httpErr := service.Stream(payload.UserId, chatId, format)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		fileName := fmt.Sprintf("chat-%d.%s", chatId, models.ExportFormats[format].Extension)
+		w.Header().Set("Content-Type", models.ExportFormats[format].ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		// the response is already under way, a failure can only cut it short
+		if err := write(w); err != nil {
+			log.Printf("export of chat %d was cut short: %v\n", chatId, err)
+		}
+	}
+}
+
+func getExports(service services.IExportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		exports, httpErr := service.GetAll(payload.UserId, chatId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, exports)
+	}
+}
+
+func createExport(service services.IExportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		var fromRequest models.ExportFromRequest
+		err = json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		export, httpErr := service.Create(payload.UserId, chatId, fromRequest.Format)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		writeResponce(w, export)
+	}
+}
+
+func getExport(service services.IExportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, exportId, httpErr := exportParams(r)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		export, httpErr := service.GetOne(payload.UserId, chatId, exportId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, export)
+	}
+}
+
+func getExportURL(service services.IExportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, exportId, httpErr := exportParams(r)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		url, httpErr := service.GetURL(payload.UserId, chatId, exportId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, url)
+	}
+}
+
+func exportParams(r *http.Request) (int, int, utils.HttpError) {
+	chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, 0, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	exportId, err := strconv.Atoi(mux.Vars(r)["exportId"])
+	if err != nil {
+		return 0, 0, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	return chatId, exportId, nil
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterImportsRoutes(router *mux.Router, service services.IImportService) {
+	router.Path("").HandlerFunc(createImport(service)).Methods("POST")
+	router.Path("").HandlerFunc(getImports(service)).Methods("GET")
+	router.Path("/{id}").HandlerFunc(getImport(service)).Methods("GET")
+}
+
+// createImport takes the export of ?source= as a multipart form, the "options"
+// field has to come before the "file" one as the file is read as it arrives.
+func createImport(service services.IImportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, services.MAX_IMPORT_SIZE+1<<20)
+		reader, err := r.MultipartReader()
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+		var options models.ImportOptions
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				err := errors.New(`no "file" field in the form`)
+				WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+				return
+			}
+			if part.FormName() == "options" {
+				err := json.NewDecoder(part).Decode(&options)
+				if err != nil {
+					WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+					return
+				}
+				continue
+			}
+			if part.FormName() != "file" {
+				continue
+			}
+
+			imp, httpErr := service.Create(payload.UserId, r.URL.Query().Get("source"), options, part)
+			if httpErr != nil {
+				WriteError(w, httpErr)
+				return
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+			writeResponce(w, imp)
+			return
+		}
+	}
+}
+
+func getImports(service services.IImportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		imports, httpErr := service.GetAll(payload.UserId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, imports)
+	}
+}
+
+func getImport(service services.IImportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		importId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		imp, httpErr := service.GetOne(payload.UserId, importId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, imp)
+	}
+}
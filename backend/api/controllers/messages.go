@@ -1,16 +1,25 @@
 package controllers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 
+	"github.com/BogPin/real-time-chat/backend/api/models"
 	"github.com/BogPin/real-time-chat/backend/api/services"
 	"github.com/BogPin/real-time-chat/backend/api/utils"
 	"github.com/gorilla/mux"
 )
 
 func RegisterMessagesRoutes(router *mux.Router, service services.IMessageService) {
+	router.Path("/mentions").HandlerFunc(getMentions(service)).Methods("GET")
+	router.Path("/search").HandlerFunc(searchMessages(service)).Methods("GET")
 	router.Path("/{id}").HandlerFunc(getMessage(service)).Methods("GET")
+	router.Path("/{id}").HandlerFunc(updateMessage(service)).Methods("PATCH")
+	router.Path("/{id}").HandlerFunc(deleteMessage(service)).Methods("DELETE")
+	router.Path("/{id}/thread").HandlerFunc(getThread(service)).Methods("GET")
+	router.Path("/{id}/revisions").HandlerFunc(getRevisions(service)).Methods("GET")
+	router.Path("/{id}/listened").HandlerFunc(markListened(service)).Methods("POST")
 	router.Path("").HandlerFunc(getMessages(service)).Methods("GET")
 }
 
@@ -45,6 +54,39 @@ func getMessages(service services.IMessageService) http.HandlerFunc {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		query := models.HistoryQuery{ChatId: chatId, Cursor: r.URL.Query().Get("cursor")}
+		params := map[string]*int{
+			"before": &query.Before,
+			"after":  &query.After,
+			"around": &query.Around,
+			"limit":  &query.Limit,
+		}
+		for name, value := range params {
+			*value, err = optionalIntParam(r, name)
+			if err != nil {
+				WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+				return
+			}
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		messages, httpErr := service.GetChatMessages(payload.UserId, query)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, messages)
+	}
+}
+
+func getMentions(service services.IMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		page, err := strconv.Atoi(r.URL.Query().Get("page"))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -57,12 +99,242 @@ func getMessages(service services.IMessageService) http.HandlerFunc {
 			return
 		}
 
-		messages, httpErr := service.GetChatMessages(payload.UserId, chatId, page)
+		messages, httpErr := service.GetUserMentions(payload.UserId, page)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, messages)
+	}
+}
+
+func getThread(service services.IMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		page := 0
+		if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+			page, err = strconv.Atoi(pageStr)
+			if err != nil {
+				WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+				return
+			}
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		thread, httpErr := service.GetThread(payload.UserId, messageId, page)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, thread)
+	}
+}
+
+func updateMessage(service services.IMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		var message models.Message
+		err = json.NewDecoder(r.Body).Decode(&message)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+		message.Id = messageId
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		updMessage, httpErr := service.Update(payload.UserId, message)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, updMessage)
+	}
+}
+
+func getRevisions(service services.IMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		revisions, httpErr := service.GetRevisions(payload.UserId, messageId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, revisions)
+	}
+}
+
+// deleteMessage deletes a message for the user, or for the whole chat with ?forEveryone=true.
+func deleteMessage(service services.IMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		forEveryone := false
+		if forEveryoneStr := r.URL.Query().Get("forEveryone"); forEveryoneStr != "" {
+			forEveryone, err = strconv.ParseBool(forEveryoneStr)
+			if err != nil {
+				WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+				return
+			}
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		dltMessage, httpErr := service.Delete(payload.UserId, messageId, forEveryone)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, dltMessage)
+	}
+}
+
+// searchMessages handles ?q= with optional chatId, senderId, type,
+// from and to (dates or RFC 3339 times, to is inclusive for dates), page and limit.
+func searchMessages(service services.IMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := models.SearchQuery{
+			Text: r.URL.Query().Get("q"),
+			Type: r.URL.Query().Get("type"),
+		}
+		params := map[string]*int{
+			"chatId":   &query.ChatId,
+			"senderId": &query.SenderId,
+			"page":     &query.Page,
+			"limit":    &query.Limit,
+		}
+		var err error
+		for name, value := range params {
+			*value, err = optionalIntParam(r, name)
+			if err != nil {
+				WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+				return
+			}
+		}
+		query.From, err = optionalTimeParam(r, "from", false)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+		query.To, err = optionalTimeParam(r, "to", true)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		results, httpErr := service.Search(payload.UserId, query)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, results)
+	}
+}
+
+func markListened(service services.IMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		event, httpErr := service.MarkListened(payload.UserId, messageId)
 		if httpErr != nil {
 			WriteError(w, httpErr)
 			return
 		}
 
+		writeResponce(w, event)
+	}
+}
+
+// RegisterForwardRoutes adds forwarding, deliver sends each forwarded message to its chat
+// the way messages sent over the socket are.
+func RegisterForwardRoutes(router *mux.Router, service services.IMessageService, deliver func(msg models.Message)) {
+	router.Path("/forward").HandlerFunc(forwardMessages(service, deliver)).Methods("POST")
+}
+
+func forwardMessages(service services.IMessageService, deliver func(msg models.Message)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var fromRequest models.ForwardFromRequest
+		err := json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		messages, httpErr := service.Forward(payload.UserId, fromRequest)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+		for _, msg := range messages {
+			deliver(msg)
+		}
+
 		writeResponce(w, messages)
 	}
 }
@@ -13,6 +13,7 @@ import (
 
 func RegisterParticipantRoutes(router *mux.Router, service services.IParticipantService) {
 	router.Path("").HandlerFunc(createParticipant(service)).Methods("POST")
+	router.Path("/mute").HandlerFunc(muteChat(service)).Methods("PUT")
 	router.Path("/{id}").HandlerFunc(updateParticipant(service)).Methods("PATCH")
 	router.Path("").HandlerFunc(deleteParticipant(service)).Methods("DELETE")
 }
@@ -69,6 +70,31 @@ func updateParticipant(service services.IParticipantService) http.HandlerFunc {
 	}
 }
 
+func muteChat(service services.IParticipantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var mute models.MuteFromRequest
+		err := json.NewDecoder(r.Body).Decode(&mute)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		part, httpErr := service.SetMuted(payload.UserId, mute.ChatId, mute.Muted)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, part)
+	}
+}
+
 func deleteParticipant(service services.IParticipantService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userId, err := strconv.Atoi(r.URL.Query().Get("userId"))
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterPinsRoutes(router *mux.Router, service services.IPinService) {
+	router.Path("/{id}/pins").HandlerFunc(getPins(service)).Methods("GET")
+	router.Path("/{id}/pins").HandlerFunc(pinMessage(service)).Methods("POST")
+	router.Path("/{id}/pins/{messageId}").HandlerFunc(unpinMessage(service)).Methods("DELETE")
+}
+
+func getPins(service services.IPinService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		pins, httpErr := service.List(payload.UserId, chatId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, pins)
+	}
+}
+
+func pinMessage(service services.IPinService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		var fromRequest models.PinFromRequest
+		err = json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		pin, httpErr := service.Pin(payload.UserId, chatId, fromRequest.MessageId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, pin)
+	}
+}
+
+func unpinMessage(service services.IPinService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		messageId, err := strconv.Atoi(mux.Vars(r)["messageId"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		pin, httpErr := service.Unpin(payload.UserId, chatId, messageId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, pin)
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterPollsRoutes(router *mux.Router, service services.IPollService) {
+	router.Path("/{id}/votes").HandlerFunc(vote(service)).Methods("POST")
+	router.Path("/{id}/votes").HandlerFunc(retractVotes(service)).Methods("DELETE")
+	router.Path("/{id}/votes/{optionId}").HandlerFunc(retractVotes(service)).Methods("DELETE")
+	router.Path("/{id}/close").HandlerFunc(closePoll(service)).Methods("POST")
+}
+
+func vote(service services.IPollService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		var fromRequest models.VoteFromRequest
+		err = json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		event, httpErr := service.Vote(payload.UserId, messageId, fromRequest.OptionIds)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, event)
+	}
+}
+
+// retractVotes takes back the vote for optionId, or all votes of the user when it isn't set.
+func retractVotes(service services.IPollService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		optionIds := make([]int, 0, 1)
+		if optionIdStr, ok := mux.Vars(r)["optionId"]; ok {
+			optionId, err := strconv.Atoi(optionIdStr)
+			if err != nil {
+				WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+				return
+			}
+			optionIds = append(optionIds, optionId)
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		event, httpErr := service.Retract(payload.UserId, messageId, optionIds)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, event)
+	}
+}
+
+func closePoll(service services.IPollService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		event, httpErr := service.Close(payload.UserId, messageId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, event)
+	}
+}
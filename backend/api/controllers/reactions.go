@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterReactionsRoutes(router *mux.Router, service services.IReactionService) {
+	router.Path("/{id}/reactions").HandlerFunc(addReaction(service)).Methods("POST")
+	router.Path("/{id}/reactions/{emoji}").HandlerFunc(removeReaction(service)).Methods("DELETE")
+}
+
+func addReaction(service services.IReactionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		var fromRequest models.ReactionFromRequest
+		err = json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		reaction, httpErr := service.Add(payload.UserId, messageId, fromRequest.Emoji)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, reaction)
+	}
+}
+
+func removeReaction(service services.IReactionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messageId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		reaction, httpErr := service.Remove(payload.UserId, messageId, mux.Vars(r)["emoji"])
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, reaction)
+	}
+}
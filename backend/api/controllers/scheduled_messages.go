@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+func RegisterScheduledMessagesRoutes(router *mux.Router, service services.IScheduledMessageService) {
+	router.Path("").HandlerFunc(scheduleMessage(service)).Methods("POST")
+	router.Path("").HandlerFunc(getScheduledMessages(service)).Methods("GET")
+	router.Path("/{id}").HandlerFunc(updateScheduledMessage(service)).Methods("PUT")
+	router.Path("/{id}").HandlerFunc(cancelScheduledMessage(service)).Methods("DELETE")
+}
+
+func scheduleMessage(service services.IScheduledMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var fromRequest models.ScheduledMessageFromRequest
+		err := json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		msg, httpErr := service.Create(payload.UserId, fromRequest)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, msg)
+	}
+}
+
+// getScheduledMessages lists the scheduled messages of the user, of one chat with ?chatId=.
+func getScheduledMessages(service services.IScheduledMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatId, err := optionalIntParam(r, "chatId")
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		messages, httpErr := service.GetAll(payload.UserId, chatId)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, messages)
+	}
+}
+
+func updateScheduledMessage(service services.IScheduledMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		var fromRequest models.ScheduledMessageFromRequest
+		err = json.NewDecoder(r.Body).Decode(&fromRequest)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		msg, httpErr := service.Update(payload.UserId, id, fromRequest)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, msg)
+	}
+}
+
+func cancelScheduledMessage(service services.IScheduledMessageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		msg, httpErr := service.Delete(payload.UserId, id)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, msg)
+	}
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/gorilla/mux"
+)
+
+const (
+	TUS_VERSION    = "1.0.0"
+	TUS_EXTENSIONS = "creation,expiration,termination"
+)
+
+// RegisterUploadsRoutes serves resumable uploads with the tus protocol
+// (https://tus.io/protocols/resumable-upload). A finished upload becomes an
+// attachment with POST /{id}/attachment.
+func RegisterUploadsRoutes(router *mux.Router, service services.IUploadService) {
+	router.Path("").HandlerFunc(tusOptions).Methods("OPTIONS")
+	router.Path("").HandlerFunc(tus(createUpload(service))).Methods("POST")
+	router.Path("/{id}").HandlerFunc(tus(getUploadOffset(service))).Methods("HEAD")
+	router.Path("/{id}").HandlerFunc(tus(appendUpload(service))).Methods("PATCH")
+	router.Path("/{id}").HandlerFunc(tus(terminateUpload(service))).Methods("DELETE")
+	router.Path("/{id}/attachment").HandlerFunc(completeUpload(service)).Methods("POST")
+}
+
+func tusOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", TUS_VERSION)
+	w.Header().Set("Tus-Version", TUS_VERSION)
+	w.Header().Set("Tus-Extension", TUS_EXTENSIONS)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(services.MAX_UPLOAD_SIZE, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tus rejects requests of other protocol versions and marks responses with the supported one.
+func tus(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", TUS_VERSION)
+		if r.Header.Get("Tus-Resumable") != TUS_VERSION {
+			w.Header().Set("Tus-Version", TUS_VERSION)
+			err := errors.New("unsupported tus version")
+			WriteError(w, utils.NewHttpError(err, http.StatusPreconditionFailed))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// parseUploadMetadata decodes the Upload-Metadata header, comma separated
+// pairs of a key and a base64 value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 {
+			continue
+		}
+		value := ""
+		if len(fields) > 1 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			value = string(decoded)
+		}
+		metadata[fields[0]] = value
+	}
+	return metadata, nil
+}
+
+// createUpload takes the length in Upload-Length and the file name and chat
+// in the "filename" and "chatId" keys of Upload-Metadata.
+func createUpload(service services.IUploadService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+		chatId, err := strconv.Atoi(metadata["chatId"])
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		upload, httpErr := service.Create(payload.UserId, chatId, metadata["filename"], length)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+upload.Id)
+		w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func getUploadOffset(service services.IUploadService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		upload, httpErr := service.GetOne(payload.UserId, mux.Vars(r)["id"])
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+		w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// appendUpload stores the body as the chunk at Upload-Offset.
+func appendUpload(service services.IUploadService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+			err := errors.New("chunks must be sent as application/offset+octet-stream")
+			WriteError(w, utils.NewHttpError(err, http.StatusUnsupportedMediaType))
+			return
+		}
+
+		offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+		if err != nil {
+			WriteError(w, utils.NewHttpError(err, http.StatusBadRequest))
+			return
+		}
+
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		upload, httpErr := service.Append(payload.UserId, mux.Vars(r)["id"], offset, r.Body)
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func terminateUpload(service services.IUploadService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		httpErr := service.Terminate(payload.UserId, mux.Vars(r)["id"])
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func completeUpload(service services.IUploadService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := r.Context().Value(TokenPayloadKey).(TokenPayload)
+		if !ok {
+			WriteError(w, ErrNoUserPayloadInContext)
+			return
+		}
+
+		attachment, httpErr := service.Complete(payload.UserId, mux.Vars(r)["id"])
+		if httpErr != nil {
+			WriteError(w, httpErr)
+			return
+		}
+
+		writeResponce(w, attachment)
+	}
+}
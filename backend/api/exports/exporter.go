@@ -0,0 +1,291 @@
+// Package exports writes the history of chats as JSON Lines, plain text or a
+// standalone HTML page. Small chats are streamed right away, exports of any
+// size can be made in the background and downloaded from blob storage.
+package exports
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+)
+
+const (
+	PAGE_SIZE       = 500
+	EXPORT_LEASE    = 10 * time.Minute
+	EXPORT_TTL      = 7 * 24 * time.Hour
+	EXPIRY_INTERVAL = time.Hour
+	KEY_PREFIX      = "exports"
+	FAILURE_REASON  = "the export couldn't be written"
+)
+
+type Exporter struct {
+	ExportStorer      models.IExportStorer
+	ChatStorer        models.IChatStorer
+	ParticipantStorer models.IParticipantStorer
+	MessageStorer     models.IMessageStorer
+	MentionStorer     models.IMentionStorer
+	RevisionStorer    models.IRevisionStorer
+	ReactionStorer    models.IReactionStorer
+	AttachmentStorer  models.IAttachmentStorer
+	PollStorer        models.IPollStorer
+	Storage           storage.Storage
+}
+
+func NewExporter(
+	exportStorer models.IExportStorer,
+	chatStorer models.IChatStorer,
+	participantStorer models.IParticipantStorer,
+	messageStorer models.IMessageStorer,
+	mentionStorer models.IMentionStorer,
+	revisionStorer models.IRevisionStorer,
+	reactionStorer models.IReactionStorer,
+	attachmentStorer models.IAttachmentStorer,
+	pollStorer models.IPollStorer,
+	blobs storage.Storage,
+) *Exporter {
+	return &Exporter{
+		ExportStorer:      exportStorer,
+		ChatStorer:        chatStorer,
+		ParticipantStorer: participantStorer,
+		MessageStorer:     messageStorer,
+		MentionStorer:     mentionStorer,
+		RevisionStorer:    revisionStorer,
+		ReactionStorer:    reactionStorer,
+		AttachmentStorer:  attachmentStorer,
+		PollStorer:        pollStorer,
+		Storage:           blobs,
+	}
+}
+
+// Write writes the history of a chat to w in format, progress is called with
+// the number of messages written so far after each page of them.
+func (e *Exporter) Write(w io.Writer, chatId int, format string, progress func(messages int)) error {
+	writer, err := NewWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	chat, err := e.ChatStorer.GetOne(chatId)
+	if err != nil {
+		return err
+	}
+	participants, err := e.ParticipantStorer.GetChatUsers(chatId)
+	if err != nil {
+		return err
+	}
+	senders, err := e.ExportStorer.GetSenders(chatId)
+	if err != nil {
+		return err
+	}
+	header := models.ExportHeader{
+		Chat:         *chat,
+		Participants: participants,
+		Senders:      senders,
+		ExportedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	written, afterId := 0, 0
+	for {
+		msgs, err := e.MessageStorer.GetChatHistory(chatId, afterId, PAGE_SIZE)
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			break
+		}
+
+		exported, err := e.details(msgs)
+		if err != nil {
+			return err
+		}
+		for _, msg := range exported {
+			if err := writer.WriteMessage(msg); err != nil {
+				return err
+			}
+		}
+
+		written += len(msgs)
+		afterId = msgs[len(msgs)-1].Id
+		progress(written)
+		if len(msgs) < PAGE_SIZE {
+			break
+		}
+	}
+
+	return writer.Close()
+}
+
+// details adds what is exported along with msgs, the content of deleted messages is gone
+// and so are their attachments, polls and revisions.
+func (e *Exporter) details(msgs []models.Message) ([]models.ExportedMessage, error) {
+	ids := make([]int, 0, len(msgs))
+	pollIds := make([]int, 0)
+	for _, msg := range msgs {
+		ids = append(ids, msg.Id)
+		if msg.Type == "poll" {
+			pollIds = append(pollIds, msg.Id)
+		}
+	}
+
+	mentions, err := e.MentionStorer.GetForMessages(ids)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := e.RevisionStorer.GetForMessages(ids)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := e.ReactionStorer.GetAllForMessages(ids)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := e.AttachmentStorer.GetForMessages(ids)
+	if err != nil {
+		return nil, err
+	}
+	polls := make(map[int]models.Poll)
+	if len(pollIds) > 0 {
+		// polls are exported as anyone sees them, voters of anonymous ones stay unknown
+		polls, err = e.PollStorer.GetForMessages(pollIds, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	exported := make([]models.ExportedMessage, len(msgs))
+	for i, msg := range msgs {
+		msg.Attachments = make([]models.Attachment, 0)
+		msg.Mentions = make([]models.Mention, 0)
+		if mentions[msg.Id] != nil {
+			msg.Mentions = mentions[msg.Id]
+		}
+		exported[i] = models.ExportedMessage{Message: msg, Reactions: reactions[msg.Id], Revisions: revisions[msg.Id]}
+		if exported[i].Reactions == nil {
+			exported[i].Reactions = make([]models.Reaction, 0)
+		}
+		if msg.DeletedAt != "" || exported[i].Revisions == nil {
+			exported[i].Revisions = make([]models.Revision, 0)
+		}
+		if msg.DeletedAt != "" {
+			continue
+		}
+		if attachments[msg.Id] != nil {
+			exported[i].Attachments = attachments[msg.Id]
+		}
+		if poll, ok := polls[msg.Id]; ok {
+			exported[i].Poll = &poll
+		}
+	}
+	return exported, nil
+}
+
+// Start makes the exports waiting to be written every interval, one at a time
+// per instance, and deletes expired exports along with their files every
+// EXPIRY_INTERVAL. Exports are leased to one instance at a time, so any number
+// of them can run.
+func (e *Exporter) Start(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := e.RunPending(); err != nil {
+				log.Printf("error while making exports: %v\n", err)
+			}
+		}
+	}()
+	go func() {
+		for range time.Tick(EXPIRY_INTERVAL) {
+			e.deleteExpired()
+		}
+	}()
+}
+
+// RunPending makes the exports waiting to be written until there are none left.
+func (e *Exporter) RunPending() error {
+	for {
+		export, err := e.ExportStorer.Claim(EXPORT_LEASE)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := e.run(*export); err != nil {
+			log.Printf("export %d of chat %d failed: %v\n", export.Id, export.ChatId, err)
+			if err := e.ExportStorer.Fail(export.Id, FAILURE_REASON); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// run writes export to a temporary file first, its size has to be known to store it.
+func (e *Exporter) run(export models.Export) error {
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	err = e.Write(tmp, export.ChatId, export.Format, func(messages int) {
+		if err := e.ExportStorer.SetProgress(export.Id, messages, EXPORT_LEASE); err != nil {
+			log.Println(err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key, err := storage.NewKey(fmt.Sprintf("%s/%d", KEY_PREFIX, export.ChatId))
+	if err != nil {
+		return err
+	}
+	format := models.ExportFormats[export.Format]
+	if err := e.Storage.Put(key, tmp, size, format.ContentType); err != nil {
+		return err
+	}
+
+	if err := e.ExportStorer.Finish(export.Id, key, size, EXPORT_TTL); err != nil {
+		if err := e.Storage.Delete(key); err != nil {
+			log.Println(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *Exporter) deleteExpired() {
+	exports, err := e.ExportStorer.DeleteExpired()
+	if err != nil {
+		log.Printf("error while deleting expired exports: %v\n", err)
+		return
+	}
+	for _, export := range exports {
+		if export.Key == "" {
+			continue
+		}
+		if err := e.Storage.Delete(export.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package exports_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/exports"
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var header = models.ExportHeader{
+	Chat: models.Chat{Id: 3, Title: "Project <X>"},
+	Participants: []models.ChatUser{
+		{Participant: models.Participant{UserId: 1, ChatId: 3, Role: "admin"}, Name: "Alice", Tag: "alice"},
+	},
+	Senders:    []models.ExportUser{{Id: 1, Name: "Alice", Tag: "alice"}, {Id: 2, Name: "Bob", Tag: "bob"}},
+	ExportedAt: "2023-05-01T12:00:00Z",
+}
+
+var message = models.ExportedMessage{
+	Message: models.Message{
+		Id:          7,
+		SenderId:    2,
+		ChatId:      3,
+		Type:        "text",
+		Content:     "ship it <b>now</b>\nplease",
+		CreatedAt:   "2023-05-01T10:00:00Z",
+		EditedAt:    "2023-05-01T10:05:00Z",
+		Attachments: []models.Attachment{{FileName: "plan.pdf", MimeType: "application/pdf", Size: 2048}},
+	},
+	Reactions: []models.Reaction{{MessageId: 7, UserId: 1, Emoji: "👍"}},
+	Revisions: []models.Revision{{MessageId: 7, Content: "ship it", EditedAt: "2023-05-01T10:05:00Z"}},
+}
+
+func write(t *testing.T, format string) string {
+	var b bytes.Buffer
+	writer, err := exports.NewWriter(&b, format)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.WriteHeader(header))
+	assert.Nil(t, writer.WriteMessage(message))
+	assert.Nil(t, writer.Close())
+	return b.String()
+}
+
+func TestWriteText(t *testing.T) {
+	//Arrange
+	expected := `Project <X>
+Exported 2023-05-01 12:00:00
+Participants: Alice (@alice, admin)
+
+[2023-05-01 10:00:00] Bob: ship it <b>now</b>
+    please
+    [before the edit at 2023-05-01 10:05:00] ship it
+    [attachment] plan.pdf, application/pdf, 2.0 KiB
+    [👍] Alice
+`
+
+	//Act
+	actual := write(t, "text")
+
+	//Assert
+	assert.Equal(t, expected, actual)
+}
+
+func TestWriteHTMLEscapes(t *testing.T) {
+	//Act
+	actual := write(t, "html")
+
+	//Assert
+	assert.True(t, strings.HasPrefix(actual, "<!DOCTYPE html>"))
+	assert.True(t, strings.HasSuffix(actual, "</html>\n"))
+	assert.Contains(t, actual, "<title>Project &lt;X&gt;</title>")
+	assert.Contains(t, actual, "ship it &lt;b&gt;now&lt;/b&gt;\nplease")
+	assert.Contains(t, actual, `<b>Bob</b>`)
+	assert.Contains(t, actual, `title="Alice">👍 1</span>`)
+	assert.NotContains(t, actual, "<b>now</b>")
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	//Act
+	actual := write(t, "jsonl")
+
+	//Assert
+	lines := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+	assert.Len(t, lines, 2)
+	var actualHeader models.ExportHeader
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &actualHeader))
+	assert.Equal(t, header, actualHeader)
+	var actualMessage models.ExportedMessage
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &actualMessage))
+	assert.Equal(t, message, actualMessage)
+}
+
+func TestWriteUnknownFormatError(t *testing.T) {
+	//Act
+	writer, err := exports.NewWriter(&bytes.Buffer{}, "pdf")
+
+	//Assert
+	assert.Nil(t, writer)
+	assert.EqualError(t, err, `chats can't be exported as "pdf"`)
+}
+
+func TestExporterWritesHistoryInPages(t *testing.T) {
+	//Arrange
+	chatId := 3
+	firstPage := make([]models.Message, exports.PAGE_SIZE)
+	firstIds := make([]int, exports.PAGE_SIZE)
+	for i := range firstPage {
+		firstPage[i] = models.Message{Id: i + 1, SenderId: 1, ChatId: chatId, Type: "text", Content: "hi"}
+		firstIds[i] = i + 1
+	}
+	deleted := models.Message{Id: 600, SenderId: 2, ChatId: chatId, Type: "text", CreatedAt: "2023-05-01T10:00:00Z", DeletedAt: "2023-05-01T11:00:00Z"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChatStorer := models_mocks.NewMockIChatStorer(ctrl)
+	mockChatStorer.EXPECT().GetOne(chatId).Return(&header.Chat, nil)
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.EXPECT().GetChatUsers(chatId).Return(header.Participants, nil)
+
+	mockExportStorer := models_mocks.NewMockIExportStorer(ctrl)
+	mockExportStorer.EXPECT().GetSenders(chatId).Return(header.Senders, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetChatHistory(chatId, 0, exports.PAGE_SIZE).Return(firstPage, nil)
+	mockMessageStorer.EXPECT().GetChatHistory(chatId, exports.PAGE_SIZE, exports.PAGE_SIZE).Return([]models.Message{deleted}, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages(firstIds).Return(map[int][]models.Mention{}, nil)
+	mockMentionStorer.EXPECT().GetForMessages([]int{deleted.Id}).Return(map[int][]models.Mention{}, nil)
+
+	mockRevisionStorer := models_mocks.NewMockIRevisionStorer(ctrl)
+	mockRevisionStorer.EXPECT().GetForMessages(firstIds).Return(map[int][]models.Revision{}, nil)
+	mockRevisionStorer.
+		EXPECT().
+		GetForMessages([]int{deleted.Id}).
+		Return(map[int][]models.Revision{deleted.Id: {{MessageId: deleted.Id, Content: "secret"}}}, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetAllForMessages(firstIds).Return(map[int][]models.Reaction{}, nil)
+	mockReactionStorer.EXPECT().GetAllForMessages([]int{deleted.Id}).Return(map[int][]models.Reaction{}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(firstIds).Return(map[int][]models.Attachment{}, nil)
+	mockAttachmentStorer.EXPECT().GetForMessages([]int{deleted.Id}).Return(map[int][]models.Attachment{}, nil)
+
+	exporter := exports.NewExporter(
+		mockExportStorer,
+		mockChatStorer,
+		mockParticipantStorer,
+		mockMessageStorer,
+		mockMentionStorer,
+		mockRevisionStorer,
+		mockReactionStorer,
+		mockAttachmentStorer,
+		nil,
+		nil,
+	)
+	var b bytes.Buffer
+	progress := make([]int, 0)
+
+	//Act
+	err := exporter.Write(&b, chatId, "text", func(messages int) { progress = append(progress, messages) })
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []int{exports.PAGE_SIZE, exports.PAGE_SIZE + 1}, progress)
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(&b)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.Len(t, lines, 4+exports.PAGE_SIZE+1)
+	assert.Equal(t, "[2023-05-01 10:00:00] Bob: (deleted)", lines[len(lines)-1])
+}
@@ -0,0 +1,305 @@
+package exports
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+// Writer writes an export in one of models.ExportFormats,
+// the header first and then the messages oldest first.
+type Writer interface {
+	WriteHeader(header models.ExportHeader) error
+	WriteMessage(msg models.ExportedMessage) error
+	// Close finishes the export, it doesn't close the underlying writer.
+	Close() error
+}
+
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return jsonlWriter{encoder: encoder}, nil
+	case "text":
+		return &textWriter{w: bufio.NewWriter(w)}, nil
+	case "html":
+		return newHTMLWriter(w), nil
+	}
+	return nil, fmt.Errorf("chats can't be exported as %q", format)
+}
+
+// jsonlWriter writes a JSON value per line, the header and then a line per message.
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (jw jsonlWriter) WriteHeader(header models.ExportHeader) error {
+	return jw.encoder.Encode(header)
+}
+
+func (jw jsonlWriter) WriteMessage(msg models.ExportedMessage) error {
+	return jw.encoder.Encode(msg)
+}
+
+func (jw jsonlWriter) Close() error {
+	return nil
+}
+
+// names tells users by their names, users that can't be told are named by their id.
+type names map[int]string
+
+func newNames(header models.ExportHeader) names {
+	n := make(names)
+	for _, sender := range header.Senders {
+		n[sender.Id] = sender.Name
+	}
+	for _, participant := range header.Participants {
+		n[participant.UserId] = participant.Name
+	}
+	return n
+}
+
+func (n names) of(userId int) string {
+	if name, ok := n[userId]; ok {
+		return name
+	}
+	return fmt.Sprintf("user %d", userId)
+}
+
+type reactionGroup struct {
+	Emoji string
+	Users []string
+}
+
+// groupReactions groups reactions with the same emoji in the order emojis were first used.
+func (n names) groupReactions(reactions []models.Reaction) []reactionGroup {
+	groups := make([]reactionGroup, 0)
+	index := make(map[string]int)
+	for _, reaction := range reactions {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(groups)
+			index[reaction.Emoji] = i
+			groups = append(groups, reactionGroup{Emoji: reaction.Emoji})
+		}
+		groups[i].Users = append(groups[i].Users, n.of(reaction.UserId))
+	}
+	return groups
+}
+
+// formatTime shows a time read from the database, times it can't read are shown as they are.
+func formatTime(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// messageText is the text a message is shown with, system messages are
+// shown by the event they tell about.
+func messageText(msg models.Message) string {
+	if msg.Type != "system" {
+		return msg.Content
+	}
+	var content models.SystemContent
+	if err := json.Unmarshal([]byte(msg.Content), &content); err != nil || content.Event == "" {
+		return msg.Content
+	}
+	return strings.ReplaceAll(content.Event, "_", " ")
+}
+
+func pollSummary(poll models.Poll) string {
+	options := make([]string, len(poll.Options))
+	for i, option := range poll.Options {
+		options[i] = fmt.Sprintf("%s: %d", option.Text, option.Votes)
+	}
+	summary := poll.Question + " (" + strings.Join(options, ", ") + ")"
+	if poll.Closed {
+		summary += ", closed"
+	}
+	return summary
+}
+
+// textWriter writes a line per message with its details indented below it.
+type textWriter struct {
+	w     *bufio.Writer
+	names names
+}
+
+func (tw *textWriter) WriteHeader(header models.ExportHeader) error {
+	tw.names = newNames(header)
+	fmt.Fprintf(tw.w, "%s\n", header.Chat.Title)
+	fmt.Fprintf(tw.w, "Exported %s\n", formatTime(header.ExportedAt))
+	participants := make([]string, len(header.Participants))
+	for i, participant := range header.Participants {
+		participants[i] = fmt.Sprintf("%s (@%s, %s)", participant.Name, participant.Tag, participant.Role)
+	}
+	_, err := fmt.Fprintf(tw.w, "Participants: %s\n\n", strings.Join(participants, ", "))
+	return err
+}
+
+func (tw *textWriter) WriteMessage(msg models.ExportedMessage) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] ", formatTime(msg.CreatedAt))
+	switch {
+	case msg.Type == "system":
+		b.WriteString("* " + tw.names.of(msg.SenderId) + " " + messageText(msg.Message))
+	case msg.DeletedAt != "":
+		b.WriteString(tw.names.of(msg.SenderId) + ": (deleted)")
+	default:
+		b.WriteString(tw.names.of(msg.SenderId))
+		if msg.ParentId != 0 {
+			fmt.Fprintf(&b, " (reply to #%d)", msg.ParentId)
+		}
+		if msg.Forwarded != nil {
+			b.WriteString(" (forwarded)")
+		}
+		// continuation lines are indented so each message starts a line of its own
+		b.WriteString(": " + strings.ReplaceAll(msg.Content, "\n", "\n    "))
+	}
+	b.WriteString("\n")
+
+	if msg.DeletedAt == "" {
+		for _, revision := range msg.Revisions {
+			fmt.Fprintf(&b, "    [before the edit at %s] %s\n", formatTime(revision.EditedAt), strings.ReplaceAll(revision.Content, "\n", " "))
+		}
+		for _, attachment := range msg.Attachments {
+			fmt.Fprintf(&b, "    [attachment] %s, %s, %s\n", attachment.FileName, attachment.MimeType, formatSize(attachment.Size))
+		}
+		if msg.Poll != nil {
+			fmt.Fprintf(&b, "    [poll] %s\n", pollSummary(*msg.Poll))
+		}
+	}
+	for _, group := range tw.names.groupReactions(msg.Reactions) {
+		fmt.Fprintf(&b, "    [%s] %s\n", group.Emoji, strings.Join(group.Users, ", "))
+	}
+	_, err := tw.w.WriteString(b.String())
+	return err
+}
+
+func (tw *textWriter) Close() error {
+	return tw.w.Flush()
+}
+
+// htmlWriter writes a standalone page that needs nothing but a browser to be read.
+type htmlWriter struct {
+	w        *bufio.Writer
+	names    names
+	template *template.Template
+}
+
+func newHTMLWriter(w io.Writer) *htmlWriter {
+	hw := &htmlWriter{w: bufio.NewWriter(w)}
+	hw.template = template.Must(htmlTemplate.Clone()).Funcs(template.FuncMap{
+		"name":      func(userId int) string { return hw.names.of(userId) },
+		"reactions": func(reactions []models.Reaction) []reactionGroup { return hw.names.groupReactions(reactions) },
+	})
+	return hw
+}
+
+func (hw *htmlWriter) WriteHeader(header models.ExportHeader) error {
+	hw.names = newNames(header)
+	return hw.template.ExecuteTemplate(hw.w, "header", header)
+}
+
+func (hw *htmlWriter) WriteMessage(msg models.ExportedMessage) error {
+	return hw.template.ExecuteTemplate(hw.w, "message", msg)
+}
+
+func (hw *htmlWriter) Close() error {
+	if err := hw.template.ExecuteTemplate(hw.w, "footer", nil); err != nil {
+		return err
+	}
+	return hw.w.Flush()
+}
+
+var htmlTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"name":      func(userId int) string { return "" },
+	"reactions": func(reactions []models.Reaction) []reactionGroup { return nil },
+	"time":      formatTime,
+	"size":      formatSize,
+	"text":      messageText,
+}).Parse(`
+{{- define "header" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Chat.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+header.chat { border-bottom: 1px solid #d0d7de; margin-bottom: 1rem; }
+.participants { color: #59636e; }
+article { margin: 0.75rem 0; }
+article.reply { margin-left: 2rem; }
+article.system { color: #59636e; font-style: italic; text-align: center; }
+.meta { color: #59636e; font-size: 0.85em; }
+.content { white-space: pre-wrap; margin: 0.25rem 0; }
+.deleted { color: #8c959f; font-style: italic; }
+.details { font-size: 0.9em; margin: 0.25rem 0; }
+.reactions span { border: 1px solid #d0d7de; border-radius: 1rem; padding: 0 0.5rem; margin-right: 0.25rem; }
+</style>
+</head>
+<body>
+<header class="chat">
+<h1>{{.Chat.Title}}</h1>
+<p class="meta">Exported {{time .ExportedAt}}</p>
+<p class="participants">{{range $i, $p := .Participants}}{{if $i}}, {{end}}{{$p.Name}} (@{{$p.Tag}}, {{$p.Role}}){{end}}</p>
+</header>
+<main>
+{{end}}
+
+{{- define "message" -}}
+{{if eq .Type "system" -}}
+<article id="m{{.Id}}" class="system">{{name .SenderId}} {{text .Message}} <span class="meta">{{time .CreatedAt}}</span></article>
+{{else -}}
+<article id="m{{.Id}}"{{if .ParentId}} class="reply"{{end}}>
+<div class="meta"><b>{{name .SenderId}}</b> {{time .CreatedAt}}
+{{- if .ParentId}} · <a href="#m{{.ParentId}}">in reply</a>{{end}}
+{{- if .Forwarded}} · forwarded{{end}}
+{{- if .EditedAt}} · edited {{time .EditedAt}}{{end}}</div>
+{{if .DeletedAt -}}
+<p class="deleted">This message was deleted.</p>
+{{else -}}
+<p class="content">{{.Content}}</p>
+{{range .Attachments}}<p class="details">📎 {{.FileName}} · {{.MimeType}} · {{size .Size}}</p>
+{{end}}
+{{- with .Poll}}<div class="details"><b>{{.Question}}</b>{{if .Closed}} (closed){{end}}<ul>{{range .Options}}<li>{{.Text}}: {{.Votes}}</li>{{end}}</ul></div>
+{{end}}
+{{- if .Revisions}}<details class="details"><summary>Earlier versions</summary>{{range .Revisions}}<p class="content"><span class="meta">until {{time .EditedAt}}</span> {{.Content}}</p>{{end}}</details>
+{{end}}
+{{- end}}
+{{- with reactions .Reactions}}<p class="reactions">{{range .}}<span title="{{range $i, $u := .Users}}{{if $i}}, {{end}}{{$u}}{{end}}">{{.Emoji}} {{len .Users}}</span>{{end}}</p>
+{{end -}}
+</article>
+{{end}}
+{{- end}}
+
+{{- define "footer" -}}
+</main>
+</body>
+</html>
+{{end}}
+`))
@@ -0,0 +1,334 @@
+// Package imports recreates chats from the exports of other messengers, Slack
+// workspace exports and WhatsApp chat exports. Exports are uploaded to blob
+// storage and imported in the background, users of the source are mapped to
+// local users or to placeholders made for them.
+package imports
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+)
+
+const (
+	IMPORT_LEASE   = 10 * time.Minute
+	BATCH_SIZE     = 1000
+	MAX_ERRORS     = 100
+	TAG_PREFIX     = "imported_"
+	FAILURE_REASON = "the export couldn't be imported"
+)
+
+// readError is an export that couldn't be read, what is wrong with it is told to its importer.
+type readError struct {
+	err error
+}
+
+func (re readError) Error() string {
+	return re.err.Error()
+}
+
+type Importer struct {
+	ImportStorer      models.IImportStorer
+	ChatStorer        models.IChatStorer
+	ParticipantStorer models.IParticipantStorer
+	Storage           storage.Storage
+}
+
+func NewImporter(
+	importStorer models.IImportStorer,
+	chatStorer models.IChatStorer,
+	participantStorer models.IParticipantStorer,
+	blobs storage.Storage,
+) *Importer {
+	return &Importer{
+		ImportStorer:      importStorer,
+		ChatStorer:        chatStorer,
+		ParticipantStorer: participantStorer,
+		Storage:           blobs,
+	}
+}
+
+// Start runs the imports waiting to be run every interval, one at a time per
+// instance. Imports are leased to one instance at a time, so any number of
+// them can run.
+func (im *Importer) Start(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := im.RunPending(); err != nil {
+				log.Printf("error while running imports: %v\n", err)
+			}
+		}
+	}()
+}
+
+// RunPending runs the imports waiting to be run until there are none left.
+func (im *Importer) RunPending() error {
+	for {
+		imp, err := im.ImportStorer.Claim(IMPORT_LEASE)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		status := "done"
+		progress, err := im.Run(*imp)
+		if err != nil {
+			reason := FAILURE_REASON
+			var readErr readError
+			if errors.As(err, &readErr) {
+				reason = readErr.Error()
+			} else {
+				log.Printf("import %d failed: %v\n", imp.Id, err)
+			}
+			addError(&progress, reason)
+		}
+		if err != nil || len(progress.ChatIds) == 0 {
+			status = "failed"
+		}
+		if err := im.ImportStorer.Finish(imp.Id, status, progress); err != nil {
+			log.Println(err)
+		}
+		// the export is of no use once imported, nor once it failed to be
+		if err := im.Storage.Delete(imp.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Println(err)
+		}
+	}
+}
+
+// Run imports the chats of an export in a transaction per chat, a chat that fails
+// to be imported is left out and the import goes on with the next one. It returns
+// how far the import got, along with an error when it couldn't import anything.
+func (im *Importer) Run(imp models.Import) (models.ImportProgress, error) {
+	progress := models.ImportProgress{ChatIds: make([]int, 0), Errors: make([]string, 0)}
+	export, err := im.read(imp)
+	if err != nil {
+		return progress, err
+	}
+	for _, chat := range export.Chats {
+		progress.MessagesTotal += len(chat.Messages)
+	}
+
+	users, err := im.mapUsers(imp, export, &progress)
+	if err != nil {
+		return progress, err
+	}
+	im.setProgress(imp.Id, progress)
+
+	for _, chat := range export.Chats {
+		imported := progress.MessagesImported
+		chatId, err := im.importChat(imp, chat, users, func(inserted int) {
+			progress.MessagesImported = imported + inserted
+			im.setProgress(imp.Id, progress)
+		})
+		if err != nil {
+			log.Printf("chat %q of import %d couldn't be imported: %v\n", chat.Title, imp.Id, err)
+			progress.MessagesImported = imported
+			addError(&progress, fmt.Sprintf("chat %q couldn't be imported", chat.Title))
+			continue
+		}
+		progress.ChatIds = append(progress.ChatIds, chatId)
+		im.setProgress(imp.Id, progress)
+	}
+	return progress, nil
+}
+
+// read fetches the export to a temporary file first, zips can only be read with random access.
+func (im *Importer) read(imp models.Import) (*Export, error) {
+	read, ok := Readers[imp.Source]
+	if !ok {
+		return nil, readError{fmt.Errorf("exports of %q can't be imported", imp.Source)}
+	}
+
+	blob, err := im.Storage.Get(imp.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	tmp, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	size, err := io.Copy(tmp, blob)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := read(tmp, size, imp)
+	if err != nil {
+		return nil, readError{err}
+	}
+	return export, nil
+}
+
+// mapUsers returns the local users the users of an export are mapped to, by their keys.
+// Users are mapped as imp asks, only ever to its importer, then as earlier imports of the
+// same importer mapped them, the others get placeholders nobody can log in as.
+func (im *Importer) mapUsers(imp models.Import, export *Export, progress *models.ImportProgress) (map[string]int, error) {
+	users := make(map[string]int)
+	keys := make([]string, 0, len(export.Users))
+	for _, user := range export.Users {
+		userId, ok := imp.Options.UserMap[user.Id]
+		if !ok {
+			keys = append(keys, user.Key)
+			continue
+		}
+		if userId != imp.UserId {
+			addError(progress, fmt.Sprintf("%s can't be mapped to user %d, only to you", user.Id, userId))
+			keys = append(keys, user.Key)
+			continue
+		}
+		if err := im.ImportStorer.MapUser(imp.UserId, imp.Source, user.Key, userId); err != nil {
+			return nil, err
+		}
+		users[user.Key] = userId
+	}
+
+	known, err := im.ImportStorer.GetUsers(imp.UserId, imp.Source, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range export.Users {
+		if _, ok := users[user.Key]; ok {
+			continue
+		}
+		if userId, ok := known[user.Key]; ok {
+			users[user.Key] = userId
+			continue
+		}
+
+		tag, err := randomHex(8)
+		if err != nil {
+			return nil, err
+		}
+		password, err := randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		userId, err := im.ImportStorer.CreatePlaceholder(imp.UserId, imp.Source, user.Key, TAG_PREFIX+tag, user.Name, password)
+		if err != nil {
+			return nil, err
+		}
+		users[user.Key] = userId
+		progress.UsersCreated++
+	}
+	return users, nil
+}
+
+// importChat creates a chat the importer is the admin of and inserts its messages in
+// batches, progress is called with the number of messages inserted after each one.
+func (im *Importer) importChat(imp models.Import, chat Chat, users map[string]int, progress func(inserted int)) (chatId int, err error) {
+	tx, err := im.ChatStorer.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
+	created, err := im.ChatStorer.CreateInTx(tx, models.ChatDTO{Title: chat.Title, CreatorId: imp.UserId})
+	if err != nil {
+		return 0, err
+	}
+	admin := models.Participant{UserId: imp.UserId, ChatId: created.Id, Role: "admin"}
+	if _, err = im.ParticipantStorer.CreateInTx(tx, admin); err != nil {
+		return 0, err
+	}
+	added := map[int]bool{imp.UserId: true}
+	for _, member := range chat.Members {
+		userId, ok := users[member]
+		if !ok || added[userId] {
+			continue
+		}
+		added[userId] = true
+		participant := models.Participant{UserId: userId, ChatId: created.Id, Role: "member"}
+		if _, err = im.ParticipantStorer.CreateInTx(tx, participant); err != nil {
+			return 0, err
+		}
+	}
+
+	msgs := importedMessages(chat, users)
+	inserted := 0
+	for start := 0; start < len(msgs); start += BATCH_SIZE {
+		end := start + BATCH_SIZE
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+		var n int64
+		n, err = im.ImportStorer.InsertMessagesInTx(tx, created.Id, msgs[start:end])
+		if err != nil {
+			return 0, err
+		}
+		inserted += int(n)
+		progress(inserted)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created.Id, nil
+}
+
+// importedMessages orders the messages of a chat as they are inserted, oldest first
+// with the messages replies are attached to before all replies. Messages too long
+// for the chat are split in parts sent at the same time.
+func importedMessages(chat Chat, users map[string]int) []models.ImportedMessage {
+	msgs := make([]models.ImportedMessage, 0, len(chat.Messages))
+	for _, msg := range chat.Messages {
+		senderId, ok := users[msg.UserKey]
+		if !ok {
+			continue
+		}
+		for _, part := range split(msg.Text, MAX_CONTENT_LENGTH) {
+			msgs = append(msgs, models.ImportedMessage{SenderId: senderId, Content: part, SentAt: msg.SentAt, ParentAt: msg.ThreadAt})
+		}
+	}
+	sort.SliceStable(msgs, func(i, j int) bool {
+		iReply, jReply := !msgs[i].ParentAt.IsZero(), !msgs[j].ParentAt.IsZero()
+		if iReply != jReply {
+			return jReply
+		}
+		return msgs[i].SentAt.Before(msgs[j].SentAt)
+	})
+	return msgs
+}
+
+func (im *Importer) setProgress(importId int, progress models.ImportProgress) {
+	if err := im.ImportStorer.SetProgress(importId, progress, IMPORT_LEASE); err != nil {
+		log.Println(err)
+	}
+}
+
+func addError(progress *models.ImportProgress, message string) {
+	if len(progress.Errors) < MAX_ERRORS {
+		progress.Errors = append(progress.Errors, message)
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,181 @@
+package imports_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/imports"
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func zipped(t *testing.T, files map[string]string) *bytes.Reader {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, content := range files {
+		f, err := w.Create(name)
+		assert.Nil(t, err)
+		_, err = f.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	return bytes.NewReader(b.Bytes())
+}
+
+var slackExport = map[string]string{
+	"users.json": `[
+		{"id": "U1", "team_id": "T1", "name": "alice", "profile": {"display_name": "Alice"}},
+		{"id": "U2", "team_id": "T1", "name": "bob", "real_name": "Bob Brown"}
+	]`,
+	"channels.json": `[{"id": "C1", "name": "general", "members": ["U1", "U2"]}]`,
+	"dms.json":      `[{"id": "D1", "members": ["U1", "U2"]}]`,
+	"general/2021-03-02.json": `[
+		{"type": "message", "user": "U2", "text": "agreed", "ts": "1614729600.000200", "thread_ts": "1614643200.000100"}
+	]`,
+	"general/2021-03-01.json": `[
+		{"type": "message", "subtype": "channel_join", "user": "U2", "text": "<@U2> has joined the channel", "ts": "1614643100.000000"},
+		{"type": "message", "user": "U1", "text": "hi <@U2>, see <https://example.com|the plan> &amp; <!here>", "ts": "1614643200.000100", "thread_ts": "1614643200.000100"},
+		{"type": "message", "subtype": "file_share", "user": "U3", "text": "", "ts": "1614643300.000000",
+			"user_profile": {"real_name": "Carol"}, "files": [{"name": "plan.pdf"}]}
+	]`,
+	"D1/2021-03-01.json": `[{"type": "message", "user": "U2", "text": "psst", "ts": "1614643400.000000"}]`,
+}
+
+func TestReadSlack(t *testing.T) {
+	//Arrange
+	r := zipped(t, slackExport)
+
+	//Act
+	export, err := imports.ReadSlack(r, r.Size(), models.Import{UserId: 1})
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []imports.User{
+		{Id: "U1", Key: "T1/U1", Name: "Alice"},
+		{Id: "U2", Key: "T1/U2", Name: "Bob Brown"},
+		{Id: "U3", Key: "T1/U3", Name: "Carol"},
+	}, export.Users)
+	assert.Len(t, export.Chats, 2)
+
+	general := export.Chats[0]
+	assert.Equal(t, "general", general.Title)
+	assert.Equal(t, []string{"T1/U1", "T1/U2"}, general.Members)
+	root := time.Unix(1614643200, 100000).UTC()
+	assert.Equal(t, []imports.Message{
+		{UserKey: "T1/U1", Text: "hi @Bob Brown, see the plan (https://example.com) & @all", SentAt: root},
+		{UserKey: "T1/U3", Text: "[file: plan.pdf]", SentAt: time.Unix(1614643300, 0).UTC()},
+		{UserKey: "T1/U2", Text: "agreed", SentAt: time.Unix(1614729600, 200000).UTC(), ThreadAt: root},
+	}, general.Messages)
+
+	dm := export.Chats[1]
+	assert.Equal(t, "Alice, Bob Brown", dm.Title)
+	assert.Equal(t, []imports.Message{{UserKey: "T1/U2", Text: "psst", SentAt: time.Unix(1614643400, 0).UTC()}}, dm.Messages)
+}
+
+func TestReadSlackWithoutUsersError(t *testing.T) {
+	//Arrange
+	r := zipped(t, map[string]string{"channels.json": `[]`})
+
+	//Act
+	_, err := imports.ReadSlack(r, r.Size(), models.Import{UserId: 1})
+
+	//Assert
+	assert.EqualError(t, err, "the Slack export has no users.json")
+}
+
+func TestReadWhatsAppAndroid(t *testing.T) {
+	//Arrange
+	chat := "12/31/21, 9:05 PM - Messages and calls are end-to-end encrypted.\n" +
+		"12/31/21, 9:05\u202fPM - Alice: happy new year\n" +
+		"see you soon\n" +
+		"12/31/21, 9:07 PM - Alice added Bob\n" +
+		"1/1/22, 12:01 AM - Bob: you too\n"
+	r := bytes.NewReader([]byte(chat))
+	imp := models.Import{UserId: 4, Options: models.ImportOptions{Title: "Friends", TimeZone: "Europe/Kyiv"}}
+	kyiv, _ := time.LoadLocation("Europe/Kyiv")
+
+	//Act
+	export, err := imports.ReadWhatsApp(r, r.Size(), imp)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []imports.User{{Id: "Alice", Key: "4/Alice", Name: "Alice"}, {Id: "Bob", Key: "4/Bob", Name: "Bob"}}, export.Users)
+	assert.Len(t, export.Chats, 1)
+	assert.Equal(t, "Friends", export.Chats[0].Title)
+	assert.Equal(t, []string{"4/Alice", "4/Bob"}, export.Chats[0].Members)
+	assert.Equal(t, []imports.Message{
+		{UserKey: "4/Alice", Text: "happy new year\nsee you soon", SentAt: time.Date(2021, 12, 31, 21, 5, 0, 0, kyiv)},
+		{UserKey: "4/Bob", Text: "you too", SentAt: time.Date(2022, 1, 1, 0, 1, 0, 0, kyiv)},
+	}, export.Chats[0].Messages)
+}
+
+func TestReadWhatsAppZippedIOS(t *testing.T) {
+	//Arrange
+	chat := "\u200e[03/04/2022, 10:15:30] Alice: \u200eMessages and calls are end-to-end encrypted.\n" +
+		"[03/04/2022, 10:16:02] Alice: morning\n" +
+		"[03/04/2022, 10:17:45] Bob: \u200e<attached: 00000012-PHOTO.jpg>\n"
+	r := zipped(t, map[string]string{"WhatsApp Chat with Bob.txt": chat, "00000012-PHOTO.jpg": "jpeg"})
+
+	//Act
+	export, err := imports.ReadWhatsApp(r, r.Size(), models.Import{UserId: 4})
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "Bob", export.Chats[0].Title)
+	assert.Equal(t, []imports.Message{
+		{UserKey: "4/Alice", Text: "morning", SentAt: time.Date(2022, 4, 3, 10, 16, 2, 0, time.UTC)},
+		{UserKey: "4/Bob", Text: "[file: 00000012-PHOTO.jpg]", SentAt: time.Date(2022, 4, 3, 10, 17, 45, 0, time.UTC)},
+	}, export.Chats[0].Messages)
+}
+
+func TestImporterMapsUsersAndGoesOnPastFailedChats(t *testing.T) {
+	//Arrange
+	blobs, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files", "secret")
+	assert.Nil(t, err)
+	r := zipped(t, slackExport)
+	key := "imports/1/export"
+	assert.Nil(t, blobs.Put(key, r, r.Size(), "application/zip"))
+	imp := models.Import{Id: 9, UserId: 1, Source: "slack", Key: key, Options: models.ImportOptions{UserMap: map[string]int{"U1": 1, "U2": 5}}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImportStorer := models_mocks.NewMockIImportStorer(ctrl)
+	mockImportStorer.EXPECT().MapUser(1, "slack", "T1/U1", 1).Return(nil)
+	mockImportStorer.EXPECT().GetUsers(1, "slack", []string{"T1/U2", "T1/U3"}).Return(map[string]int{"T1/U2": 2}, nil)
+	mockImportStorer.
+		EXPECT().
+		CreatePlaceholder(1, "slack", "T1/U3", gomock.Any(), "Carol", gomock.Any()).
+		DoAndReturn(func(importerId int, source, key, tag, name, password string) (int, error) {
+			assert.Regexp(t, "^"+imports.TAG_PREFIX+"[0-9a-f]{16}$", tag)
+			assert.Len(t, password, 64)
+			return 3, nil
+		})
+	mockImportStorer.EXPECT().SetProgress(imp.Id, gomock.Any(), imports.IMPORT_LEASE).Return(nil)
+
+	mockChatStorer := models_mocks.NewMockIChatStorer(ctrl)
+	mockChatStorer.EXPECT().Begin().Return(nil, errors.New("connection refused")).Times(2)
+
+	importer := imports.NewImporter(mockImportStorer, mockChatStorer, nil, blobs)
+
+	//Act
+	progress, err := importer.Run(imp)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, models.ImportProgress{
+		ChatIds:       []int{},
+		UsersCreated:  1,
+		MessagesTotal: 4,
+		Errors: []string{
+			"U2 can't be mapped to user 5, only to you",
+			`chat "general" couldn't be imported`, `chat "Alice, Bob Brown" couldn't be imported`,
+		},
+	}, progress)
+}
@@ -0,0 +1,280 @@
+package imports
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"golang.org/x/exp/slices"
+)
+
+// slackSubtypes are the subtypes of the messages people wrote, the others tell
+// about joins, topic changes, bots and the like and are left out.
+var slackSubtypes = []string{"", "thread_broadcast", "file_share", "me_message"}
+
+var slackMarkup = regexp.MustCompile(`<([^<>]*)>`)
+
+var slackEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+type slackProfile struct {
+	RealName    string `json:"real_name"`
+	DisplayName string `json:"display_name"`
+}
+
+type slackUser struct {
+	Id       string       `json:"id"`
+	TeamId   string       `json:"team_id"`
+	Name     string       `json:"name"`
+	RealName string       `json:"real_name"`
+	Profile  slackProfile `json:"profile"`
+}
+
+type slackChannel struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+type slackMessage struct {
+	Type        string       `json:"type"`
+	Subtype     string       `json:"subtype"`
+	User        string       `json:"user"`
+	Text        string       `json:"text"`
+	Ts          string       `json:"ts"`
+	ThreadTs    string       `json:"thread_ts"`
+	UserProfile slackProfile `json:"user_profile"`
+	Files       []struct {
+		Name string `json:"name"`
+	} `json:"files"`
+}
+
+// ReadSlack reads a zipped Slack workspace export: the users, channels, private
+// channels and direct messages it has, with a directory of daily message files
+// per chat. Users are told apart by their workspace and their id.
+func ReadSlack(r io.ReaderAt, size int64, imp models.Import) (*Export, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("a Slack export has to be a zip: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	days := make(map[string][]string)
+	for _, file := range archive.File {
+		files[file.Name] = file
+		dir, name := path.Split(file.Name)
+		if dir != "" && path.Ext(name) == ".json" {
+			dir = strings.TrimSuffix(dir, "/")
+			days[dir] = append(days[dir], file.Name)
+		}
+	}
+
+	users := make([]slackUser, 0)
+	if ok, err := readZipJSON(files, "users.json", &users); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("the Slack export has no users.json")
+	}
+	s := newSlackReader(users)
+
+	chats := make([]Chat, 0)
+	for _, list := range []string{"channels.json", "groups.json", "mpims.json", "dms.json"} {
+		channels := make([]slackChannel, 0)
+		if _, err := readZipJSON(files, list, &channels); err != nil {
+			return nil, err
+		}
+		for _, channel := range channels {
+			// channels are kept in directories named after them, direct messages after their ids
+			dir := channel.Name
+			if _, ok := days[dir]; !ok || dir == "" {
+				dir = channel.Id
+			}
+			dayFiles := days[dir]
+			sort.Strings(dayFiles)
+			chat, err := s.readChannel(files, channel, dayFiles)
+			if err != nil {
+				return nil, err
+			}
+			chats = append(chats, chat)
+		}
+	}
+	if len(chats) == 0 {
+		return nil, errors.New("the Slack export has no channels")
+	}
+
+	export := &Export{Users: make([]User, 0, len(s.users)), Chats: chats}
+	for _, key := range s.order {
+		export.Users = append(export.Users, s.users[key])
+	}
+	return export, nil
+}
+
+// slackReader keeps the users of a workspace, the ones chats refer to are
+// exported in the order they were first referred to.
+type slackReader struct {
+	team  string
+	known map[string]slackUser
+	users map[string]User
+	order []string
+}
+
+func newSlackReader(users []slackUser) *slackReader {
+	s := &slackReader{known: make(map[string]slackUser), users: make(map[string]User)}
+	for _, user := range users {
+		s.known[user.Id] = user
+		if s.team == "" {
+			s.team = user.TeamId
+		}
+	}
+	return s
+}
+
+// user returns the key of a user, users that aren't in users.json, like the
+// ones of other workspaces, are named after profile.
+func (s *slackReader) user(id string, profile slackProfile) string {
+	key := id
+	if s.team != "" {
+		key = s.team + "/" + id
+	}
+	if _, ok := s.users[key]; ok {
+		return key
+	}
+
+	name := id
+	if user, ok := s.known[id]; ok {
+		name = slackName(user.Name, user.RealName, user.Profile)
+	} else if named := slackName("", "", profile); named != "" {
+		name = named
+	}
+	s.users[key] = User{Id: id, Key: key, Name: truncate(name, MAX_NAME_LENGTH)}
+	s.order = append(s.order, key)
+	return key
+}
+
+func (s *slackReader) name(id string) string {
+	if user, ok := s.known[id]; ok {
+		return slackName(user.Name, user.RealName, user.Profile)
+	}
+	return id
+}
+
+func slackName(name, realName string, profile slackProfile) string {
+	for _, n := range []string{profile.DisplayName, profile.RealName, realName, name} {
+		if n = strings.TrimSpace(n); n != "" {
+			return n
+		}
+	}
+	return ""
+}
+
+func (s *slackReader) readChannel(files map[string]*zip.File, channel slackChannel, dayFiles []string) (Chat, error) {
+	chat := Chat{Members: make([]string, 0, len(channel.Members)), Messages: make([]Message, 0)}
+	for _, member := range channel.Members {
+		chat.Members = append(chat.Members, s.user(member, slackProfile{}))
+	}
+
+	for _, name := range dayFiles {
+		msgs := make([]slackMessage, 0)
+		if _, err := readZipJSON(files, name, &msgs); err != nil {
+			return Chat{}, err
+		}
+		for _, msg := range msgs {
+			if msg.Type != "message" || msg.User == "" || !slices.Contains(slackSubtypes, msg.Subtype) {
+				continue
+			}
+			sentAt, err := parseSlackTs(msg.Ts)
+			if err != nil {
+				return Chat{}, fmt.Errorf("%s: %w", name, err)
+			}
+			text := s.text(msg.Text)
+			for _, file := range msg.Files {
+				text = strings.TrimSpace(text + "\n[file: " + file.Name + "]")
+			}
+			if text == "" {
+				continue
+			}
+
+			imported := Message{UserKey: s.user(msg.User, msg.UserProfile), Text: text, SentAt: sentAt}
+			if msg.ThreadTs != "" && msg.ThreadTs != msg.Ts {
+				imported.ThreadAt, err = parseSlackTs(msg.ThreadTs)
+				if err != nil {
+					return Chat{}, fmt.Errorf("%s: %w", name, err)
+				}
+			}
+			chat.Messages = append(chat.Messages, imported)
+			if len(channel.Members) == 0 && !slices.Contains(chat.Members, imported.UserKey) {
+				// exports of old workspaces don't list the members, the senders are taken instead
+				chat.Members = append(chat.Members, imported.UserKey)
+			}
+		}
+	}
+
+	chat.Title = channel.Name
+	if channel.Name == "" || strings.HasPrefix(channel.Name, "mpdm-") {
+		// direct messages are named after the people in them
+		names := make([]string, len(channel.Members))
+		for i, member := range channel.Members {
+			names[i] = s.name(member)
+		}
+		chat.Title = strings.Join(names, ", ")
+	}
+	if chat.Title == "" {
+		chat.Title = channel.Id
+	}
+	chat.Title = truncate(chat.Title, MAX_TITLE_LENGTH)
+	return chat, nil
+}
+
+// text turns the markup of Slack messages into plain text: mentions of users and
+// channels become @name and #name, mentions of everyone @all and links are
+// written out along with their labels.
+func (s *slackReader) text(markup string) string {
+	text := slackMarkup.ReplaceAllStringFunc(markup, func(match string) string {
+		inner := match[1 : len(match)-1]
+		target, label, _ := strings.Cut(inner, "|")
+		switch {
+		case strings.HasPrefix(target, "@"):
+			if label != "" {
+				return "@" + label
+			}
+			return "@" + s.name(target[1:])
+		case strings.HasPrefix(target, "#"):
+			if label != "" {
+				return "#" + label
+			}
+			return target
+		case target == "!here" || target == "!channel" || target == "!everyone":
+			return "@all"
+		case strings.HasPrefix(target, "!"):
+			return label
+		case label == "" || label == target:
+			return target
+		}
+		return label + " (" + target + ")"
+	})
+	return strings.TrimSpace(slackEntities.Replace(text))
+}
+
+// parseSlackTs reads the timestamps Slack identifies messages by, seconds with microseconds.
+func parseSlackTs(ts string) (time.Time, error) {
+	secs, micros, _ := strings.Cut(ts, ".")
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", ts)
+	}
+	var micro int64
+	if micros != "" {
+		micros = (micros + "000000")[:6]
+		micro, err = strconv.ParseInt(micros, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", ts)
+		}
+	}
+	return time.Unix(sec, micro*1000).UTC(), nil
+}
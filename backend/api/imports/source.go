@@ -0,0 +1,99 @@
+package imports
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+const (
+	MAX_TITLE_LENGTH = 32
+	MAX_NAME_LENGTH  = 32
+	// MAX_CONTENT_LENGTH is what the messages table holds, longer messages are split
+	MAX_CONTENT_LENGTH = 1024
+	// MAX_FILE_SIZE bounds the files of a zipped export that are read into memory
+	MAX_FILE_SIZE = 64 << 20
+)
+
+// Export is an export of another messenger as it was read.
+type Export struct {
+	Users []User
+	Chats []Chat
+}
+
+// User is a user of the source. Id is what ImportOptions.UserMap refers to them
+// by, Key tells them apart from all the other users of the source.
+type User struct {
+	Id   string
+	Key  string
+	Name string
+}
+
+// Chat is a chat of the source, Members are the keys of its users.
+type Chat struct {
+	Title    string
+	Members  []string
+	Messages []Message
+}
+
+// Message is a message of the source, replies have ThreadAt set to when the
+// message they reply to was sent.
+type Message struct {
+	UserKey  string
+	Text     string
+	SentAt   time.Time
+	ThreadAt time.Time
+}
+
+// Reader reads the export of a source uploaded for imp.
+type Reader func(r io.ReaderAt, size int64, imp models.Import) (*Export, error)
+
+// Readers read the exports of models.ImportSources.
+var Readers = map[string]Reader{
+	"slack":    ReadSlack,
+	"whatsapp": ReadWhatsApp,
+}
+
+// readZipJSON decodes a file of a zip into v, it returns false when there is no such file.
+func readZipJSON(files map[string]*zip.File, name string, v any) (bool, error) {
+	file, ok := files[name]
+	if !ok {
+		return false, nil
+	}
+	if file.UncompressedSize64 > MAX_FILE_SIZE {
+		return true, fmt.Errorf("%s is larger than %d bytes", name, MAX_FILE_SIZE)
+	}
+	r, err := file.Open()
+	if err != nil {
+		return true, err
+	}
+	defer r.Close()
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return true, fmt.Errorf("%s isn't valid: %w", name, err)
+	}
+	return true, nil
+}
+
+// truncate cuts s to max runes.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+// split cuts text into parts of up to max runes.
+func split(text string, max int) []string {
+	runes := []rune(text)
+	parts := make([]string, 0, len(runes)/max+1)
+	for len(runes) > max {
+		parts = append(parts, string(runes[:max]))
+		runes = runes[max:]
+	}
+	return append(parts, string(runes))
+}
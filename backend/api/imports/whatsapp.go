@@ -0,0 +1,205 @@
+package imports
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"golang.org/x/exp/slices"
+)
+
+const DEFAULT_WHATSAPP_TITLE = "WhatsApp chat"
+
+// whatsAppLine matches the lines starting a message, as Android writes them,
+// "31/12/2021, 21:05 - Name: text", and as iOS does, "[31/12/2021, 21:05:33] Name: text".
+// Dates are written in the order of the locale of the phone and times in 12 or 24 hours.
+var whatsAppLine = regexp.MustCompile(`^\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),? (\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?(?: ?([AaPp])\.? ?[Mm]\.?)?(?:\] | - )(.*)$`)
+
+var whatsAppAttachment = regexp.MustCompile(`^<attached: (.+)>$`)
+
+// whatsAppInvisible are the marks WhatsApp surrounds parts of lines with.
+var whatsAppInvisible = strings.NewReplacer("\u200e", "", "\u200f", "", "\u202a", "", "\u202c", "", "\u202f", " ", "\u00a0", " ")
+
+type whatsAppMessage struct {
+	date   [3]int
+	hour   int
+	minute int
+	second int
+	name   string
+	text   string
+}
+
+// ReadWhatsApp reads the text export of a WhatsApp chat, on its own or zipped along
+// with its media. Lines that don't start a message continue the one before them,
+// the notices WhatsApp writes about the chat are left out. Senders are only known
+// by their names, so they are told apart per user importing them.
+func ReadWhatsApp(r io.ReaderAt, size int64, imp models.Import) (*Export, error) {
+	text, name, err := openWhatsApp(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := scanWhatsApp(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, errors.New("the WhatsApp export has no messages")
+	}
+
+	order := imp.Options.DateOrder
+	if order == "" {
+		order = guessDateOrder(msgs)
+	}
+	location := time.UTC
+	if imp.Options.TimeZone != "" {
+		location, err = time.LoadLocation(imp.Options.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	title := imp.Options.Title
+	if title == "" {
+		title = strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(name, ".txt"), "WhatsApp Chat with "))
+	}
+	if title == "" || title == "_chat" {
+		title = DEFAULT_WHATSAPP_TITLE
+	}
+
+	export := &Export{Users: make([]User, 0)}
+	chat := Chat{Title: truncate(title, MAX_TITLE_LENGTH), Members: make([]string, 0), Messages: make([]Message, 0, len(msgs))}
+	for _, msg := range msgs {
+		sentAt, err := msg.time(order, location)
+		if err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("%d/%s", imp.UserId, msg.name)
+		if !slices.Contains(chat.Members, key) {
+			chat.Members = append(chat.Members, key)
+			export.Users = append(export.Users, User{Id: msg.name, Key: key, Name: truncate(msg.name, MAX_NAME_LENGTH)})
+		}
+		chat.Messages = append(chat.Messages, Message{UserKey: key, Text: msg.text, SentAt: sentAt})
+	}
+	export.Chats = []Chat{chat}
+	return export, nil
+}
+
+// openWhatsApp returns the chat of an export and the name of its file, zipped
+// exports have it in the only text file they hold.
+func openWhatsApp(r io.ReaderAt, size int64) (io.ReadCloser, string, error) {
+	archive, err := zip.NewReader(r, size)
+	if errors.Is(err, zip.ErrFormat) {
+		return io.NopCloser(io.NewSectionReader(r, 0, size)), "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	for _, file := range archive.File {
+		if path.Ext(file.Name) != ".txt" {
+			continue
+		}
+		if file.UncompressedSize64 > MAX_FILE_SIZE {
+			return nil, "", fmt.Errorf("%s is larger than %d bytes", file.Name, MAX_FILE_SIZE)
+		}
+		text, err := file.Open()
+		return text, path.Base(file.Name), err
+	}
+	return nil, "", errors.New("the WhatsApp export has no chat in it")
+}
+
+func scanWhatsApp(text io.ReadCloser) ([]whatsAppMessage, error) {
+	defer text.Close()
+	msgs := make([]whatsAppMessage, 0)
+	// notices get no message but the lines after them mustn't continue the message before
+	continuing := false
+	scanner := bufio.NewScanner(text)
+	scanner.Buffer(make([]byte, 0, 64*1024), MAX_FILE_SIZE)
+	for scanner.Scan() {
+		raw := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		line := whatsAppInvisible.Replace(raw)
+		match := whatsAppLine.FindStringSubmatch(line)
+		if match == nil {
+			if continuing {
+				msgs[len(msgs)-1].text += "\n" + line
+			}
+			continue
+		}
+
+		name, content, ok := strings.Cut(match[8], ": ")
+		// iOS marks notices and media with a left-to-right mark before them
+		_, rawContent, _ := strings.Cut(raw, ": ")
+		notice := strings.HasPrefix(rawContent, "\u200e")
+		if notice {
+			if attachment := whatsAppAttachment.FindStringSubmatch(content); attachment != nil {
+				content, notice = "[file: "+attachment[1]+"]", false
+			}
+		}
+		if !ok || notice {
+			continuing = false
+			continue
+		}
+
+		msg := whatsAppMessage{name: strings.TrimSpace(name), text: content}
+		for i := 0; i < 3; i++ {
+			msg.date[i], _ = strconv.Atoi(match[i+1])
+		}
+		msg.hour, _ = strconv.Atoi(match[4])
+		msg.minute, _ = strconv.Atoi(match[5])
+		msg.second, _ = strconv.Atoi(match[6])
+		switch strings.ToLower(match[7]) {
+		case "a":
+			msg.hour %= 12
+		case "p":
+			msg.hour = msg.hour%12 + 12
+		}
+		msgs = append(msgs, msg)
+		continuing = true
+	}
+	return msgs, scanner.Err()
+}
+
+// guessDateOrder tells the order of dates by the fields that can't be months,
+// dates that could be read either way are taken to be day first.
+func guessDateOrder(msgs []whatsAppMessage) string {
+	for _, msg := range msgs {
+		switch {
+		case msg.date[0] > 31:
+			return "ymd"
+		case msg.date[0] > 12:
+			return "dmy"
+		case msg.date[1] > 12:
+			return "mdy"
+		}
+	}
+	return "dmy"
+}
+
+func (msg whatsAppMessage) time(order string, location *time.Location) (time.Time, error) {
+	var year, month, day int
+	switch order {
+	case "dmy":
+		day, month, year = msg.date[0], msg.date[1], msg.date[2]
+	case "mdy":
+		month, day, year = msg.date[0], msg.date[1], msg.date[2]
+	case "ymd":
+		year, month, day = msg.date[0], msg.date[1], msg.date[2]
+	default:
+		return time.Time{}, fmt.Errorf("unknown date order %q", order)
+	}
+	if year < 100 {
+		year += 2000
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 || msg.hour > 23 || msg.minute > 59 || msg.second > 59 {
+		return time.Time{}, fmt.Errorf("invalid date %d/%d/%d of a message by %s", msg.date[0], msg.date[1], msg.date[2], msg.name)
+	}
+	return time.Date(year, time.Month(month), day, msg.hour, msg.minute, msg.second, 0, location), nil
+}
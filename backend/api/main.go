@@ -5,18 +5,37 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/BogPin/real-time-chat/backend/api/controllers"
+	"github.com/BogPin/real-time-chat/backend/api/exports"
+	"github.com/BogPin/real-time-chat/backend/api/imports"
+	"github.com/BogPin/real-time-chat/backend/api/media"
 	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/notifications"
+	"github.com/BogPin/real-time-chat/backend/api/previews"
 	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
 	"github.com/BogPin/real-time-chat/backend/api/utils"
+	wshandlers "github.com/BogPin/real-time-chat/backend/api/wsHandlers"
 	"github.com/BogPin/real-time-chat/backend/api/wss"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
-	"github.com/mitchellh/mapstructure"
-	"golang.org/x/exp/slices"
+)
+
+const (
+	NOTIFICATION_WORKERS   = 4
+	MEDIA_WORKERS          = 2
+	PREVIEW_WORKERS        = 2
+	UPLOAD_EXPIRY_INTERVAL = 10 * time.Minute
+	SCHEDULER_INTERVAL     = 15 * time.Second
+	EXPIRY_INTERVAL        = 15 * time.Second
+	EXPORT_INTERVAL        = 10 * time.Second
+	IMPORT_INTERVAL        = 10 * time.Second
 )
 
 func main() {
@@ -47,27 +66,115 @@ func main() {
 	usersRouter := apiRouter.PathPrefix("/users").Subrouter()
 	controllers.RegisterUsersRoutes(usersRouter, userService)
 
+	wsServer := wss.NewWsServer()
+	broadcaster := wshandlers.NewBroadcaster(wsServer)
+
+	messageStorer := models.NewMessageStorer(db)
 	participantStorer := models.NewParticipantStorer(db)
-	participantService := services.NewParticipantService(participantStorer)
+	participantService := services.NewParticipantService(participantStorer, messageStorer, broadcaster)
 	participantRouter := apiRouter.PathPrefix("/participants").Subrouter()
 	controllers.RegisterParticipantRoutes(participantRouter, participantService)
 
-	messageStorer := models.NewMessageStorer(db)
-	messageService := services.NewMessageService(messageStorer, participantService)
+	mentionStorer := models.NewMentionStorer(db)
+	reactionStorer := models.NewReactionStorer(db)
+	revisionStorer := models.NewRevisionStorer(db)
+	attachmentStorer := models.NewAttachmentStorer(db)
+	chatSettingsStorer := models.NewChatSettingsStorer(db)
+	pollStorer := models.NewPollStorer(db)
+	blobs := blobStorage(router)
+	mediaProcessor := media.NewProcessor(attachmentStorer, blobs)
+	linkPreviewStorer := models.NewLinkPreviewStorer(db)
+	previewer := previews.NewPreviewer(messageStorer, linkPreviewStorer, previews.NewHTTPUnfurler())
+	attachmentService := services.NewAttachmentService(attachmentStorer, messageStorer, participantService, blobs)
+	messageService := services.NewMessageService(
+		messageStorer,
+		mentionStorer,
+		reactionStorer,
+		revisionStorer,
+		attachmentStorer,
+		chatSettingsStorer,
+		pollStorer,
+		participantService,
+		broadcaster,
+		mediaProcessor,
+		previewer,
+		attachmentService.DeleteForMessages,
+	)
+	mediaProcessor.Start(MEDIA_WORKERS, messageService.BroadcastUpdated)
+	previewer.Start(PREVIEW_WORKERS, messageService.BroadcastUpdated)
+	reactionService := services.NewReactionService(reactionStorer, messageService, broadcaster)
 	messagesRouter := apiRouter.PathPrefix("/messages").Subrouter()
 	controllers.RegisterMessagesRoutes(messagesRouter, messageService)
+	controllers.RegisterReactionsRoutes(messagesRouter, reactionService)
+	pollService := services.NewPollService(pollStorer, messageService, participantService, broadcaster)
+	controllers.RegisterPollsRoutes(messagesRouter, pollService)
+	scheduledMessageStorer := models.NewScheduledMessageStorer(db)
+	scheduledMessageService := services.NewScheduledMessageService(scheduledMessageStorer, messageService, participantService)
+	scheduledMessagesRouter := apiRouter.PathPrefix("/scheduled-messages").Subrouter()
+	controllers.RegisterScheduledMessagesRoutes(scheduledMessagesRouter, scheduledMessageService)
+
+	attachmentsRouter := apiRouter.PathPrefix("/attachments").Subrouter()
+	controllers.RegisterAttachmentsRoutes(attachmentsRouter, attachmentService)
+	messageService.StartExpiring(EXPIRY_INTERVAL, attachmentService.DeleteForMessages)
+	uploadStorer := models.NewUploadStorer(db)
+	uploadService := services.NewUploadService(uploadStorer, attachmentStorer, participantService, blobs)
+	uploadService.StartExpiring(UPLOAD_EXPIRY_INTERVAL)
+	uploadsRouter := apiRouter.PathPrefix("/uploads").Subrouter()
+	controllers.RegisterUploadsRoutes(uploadsRouter, uploadService)
+
+	deviceStorer := models.NewDeviceStorer(db)
+	deviceService := services.NewDeviceService(deviceStorer)
+	devicesRouter := apiRouter.PathPrefix("/devices").Subrouter()
+	controllers.RegisterDevicesRoutes(devicesRouter, deviceService)
 
 	chatStorer := models.NewChatStorer(db)
-	chatService := services.NewChatService(chatStorer, participantStorer, messageStorer, participantService)
+	chatService := services.NewChatService(chatStorer, participantStorer, messageStorer, participantService, broadcaster)
 	chatsRouter := apiRouter.PathPrefix("/chats").Subrouter()
 	controllers.RegisterChatsRoutes(chatsRouter, chatService)
+	chatSettingsService := services.NewChatSettingsService(chatSettingsStorer, participantStorer, messageService)
+	controllers.RegisterChatSettingsRoutes(chatsRouter, chatSettingsService)
+	pinStorer := models.NewPinStorer(db)
+	pinService := services.NewPinService(pinStorer, chatSettingsStorer, messageService, participantService, broadcaster)
+	controllers.RegisterPinsRoutes(chatsRouter, pinService)
+	exportStorer := models.NewExportStorer(db)
+	exporter := exports.NewExporter(
+		exportStorer,
+		chatStorer,
+		participantStorer,
+		messageStorer,
+		mentionStorer,
+		revisionStorer,
+		reactionStorer,
+		attachmentStorer,
+		pollStorer,
+		blobs,
+	)
+	exporter.Start(EXPORT_INTERVAL)
+	exportService := services.NewExportService(exportStorer, messageStorer, participantService, exporter, blobs)
+	controllers.RegisterExportsRoutes(chatsRouter, exportService)
+	importStorer := models.NewImportStorer(db)
+	importer := imports.NewImporter(importStorer, chatStorer, participantStorer, blobs)
+	importer.Start(IMPORT_INTERVAL)
+	importService := services.NewImportService(importStorer, blobs)
+	importsRouter := apiRouter.PathPrefix("/imports").Subrouter()
+	controllers.RegisterImportsRoutes(importsRouter, importService)
 
-	wsServer := wss.NewWsServer()
 	wsRouter := router.PathPrefix("/ws").Subrouter()
 	authMiddleware := controllers.GetAuthMiddleware(authService, controllers.GetTokenFromQuery)
 	wsRouter.Use(authMiddleware)
 	wsRouter.Path("").HandlerFunc(wsServer.HttpHandler).Methods("GET")
 
+	dispatcher := notifications.NewDispatcher(chatStorer, participantStorer, deviceStorer, wsServer.Conns.Has, pushProviders()...)
+	dispatcher.Start(NOTIFICATION_WORKERS)
+
+	messageHandler := wshandlers.NewMessageHandler(wsServer, messageService, dispatcher)
+	callHandler := wshandlers.NewCallHandler(wsServer, participantService, messageService)
+	reactionHandler := wshandlers.NewReactionHandler(reactionService)
+	pinHandler := wshandlers.NewPinHandler(pinService)
+	pollHandler := wshandlers.NewPollHandler(pollService)
+	scheduledMessageService.Start(SCHEDULER_INTERVAL, messageHandler.Deliver)
+	controllers.RegisterForwardRoutes(messagesRouter, messageService, messageHandler.Deliver)
+
 	wsServer.HandleConnection(func(socket *wss.Socket) {
 		chats, err := chatService.GetUserChats(socket.UserId)
 		if err != nil {
@@ -77,58 +184,11 @@ func main() {
 			socket.Join(chat.Id)
 		}
 
-		socket.On("message", func(data any) {
-			msg := models.MessageFromRequest{}
-			if err := mapstructure.Decode(data, &msg); err != nil {
-				err = socket.Message(wss.NewErrorInvalidDataFormatMessage(msg))
-				if err != nil {
-					log.Println(err)
-				}
-			}
-			i := slices.IndexFunc(chats, func(c models.Chat) bool { return msg.ChatId == c.Id })
-			if i == -1 {
-				err := socket.Message(wss.NewErrorMessage("not allowed to write to that chat"))
-				if err != nil {
-					log.Println(err)
-				}
-				return
-			}
-			chatRoom, err := wsServer.Rooms.Get(msg.ChatId)
-			if err != nil {
-				err := socket.Message(wss.NewErrorMessage(err.Error()))
-				if err != nil {
-					log.Println(err)
-				}
-				return
-			}
-			fullMessage, httpErr := messageService.Create(socket.UserId, msg)
-			if httpErr != nil {
-				err := socket.Message(wss.NewErrorMessage(httpErr.Message()))
-				if err != nil {
-					log.Println(err)
-				}
-				return
-			}
-			chatRoom.Send(socket.UserId, wss.NewMessage("message", fullMessage))
-			err = socket.Message(wss.NewMessage("message", fullMessage))
-			if err != nil {
-				log.Println(err)
-			}
-		})
-
-		socket.On("disconnect", func(data any) {
-			chatRooms := wsServer.Rooms.GetAllForUser(socket.UserId)
-			for _, chatRoom := range chatRooms {
-				msg := models.MessageDTO{
-					SenderId: socket.UserId,
-					ChatId:   chatRoom.Id,
-					Type:     "text",
-					Content:  "bye, I leave",
-				}
-				chatRoom.Send(socket.UserId, wss.NewMessage("message", msg))
-			}
-		})
-
+		messageHandler.Register(socket)
+		callHandler.Register(socket)
+		reactionHandler.Register(socket)
+		pinHandler.Register(socket)
+		pollHandler.Register(socket)
 	})
 
 	port := ":" + utils.GetEnvVar("PORT")
@@ -143,3 +203,84 @@ func dbInit(user, password, host, port, dbname string) (*sql.DB, error) {
 	conStr := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", user, password, host, port, dbname)
 	return sql.Open("postgres", conStr)
 }
+
+// blobStorage configures where attachments are kept. Local storage serves
+// signed URLs from /files of this server, S3 ones are signed for the bucket.
+func blobStorage(router *mux.Router) storage.Storage {
+	if endpoint, ok := os.LookupEnv("S3_ENDPOINT"); ok {
+		_, pathStyle := os.LookupEnv("S3_PATH_STYLE")
+		blobs, err := storage.NewS3Storage(
+			endpoint,
+			utils.GetEnvVar("S3_REGION"),
+			utils.GetEnvVar("S3_BUCKET"),
+			utils.GetEnvVar("S3_ACCESS_KEY"),
+			utils.GetEnvVar("S3_SECRET_KEY"),
+			pathStyle,
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return blobs
+	}
+
+	baseURL := utils.GetEnvVar("STORAGE_URL")
+	blobs, err := storage.NewLocalStorage(utils.GetEnvVar("STORAGE_DIR"), baseURL+"/files", utils.GetEnvVar("STORAGE_SECRET"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	router.PathPrefix("/files/").Handler(http.StripPrefix("/files", blobs.Handler())).Methods("GET")
+	return blobs
+}
+
+// pushProviders returns the push providers that have their credentials configured.
+func pushProviders() []notifications.Provider {
+	providers := make([]notifications.Provider, 0)
+
+	if _, ok := os.LookupEnv("PUSH_FAKE"); ok {
+		for _, platform := range services.DevicePlatforms {
+			providers = append(providers, notifications.NewFakeProvider(platform))
+		}
+		return providers
+	}
+
+	if subscriber, ok := os.LookupEnv("VAPID_SUBSCRIBER"); ok {
+		provider, err := notifications.NewWebPushProvider(subscriber, utils.GetEnvVar("VAPID_PRIVATE_KEY"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		providers = append(providers, provider)
+	}
+
+	if keyFile, ok := os.LookupEnv("APNS_KEY_FILE"); ok {
+		keyPEM, err := os.ReadFile(keyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		baseURL := notifications.APNsProductionURL
+		if _, ok := os.LookupEnv("APNS_SANDBOX"); ok {
+			baseURL = notifications.APNsSandboxURL
+		}
+		keyId := utils.GetEnvVar("APNS_KEY_ID")
+		teamId := utils.GetEnvVar("APNS_TEAM_ID")
+		topic := utils.GetEnvVar("APNS_TOPIC")
+		provider, err := notifications.NewAPNsProvider(keyPEM, keyId, teamId, topic, baseURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		providers = append(providers, provider)
+	}
+
+	if credentialsFile, ok := os.LookupEnv("FCM_CREDENTIALS_FILE"); ok {
+		credentials, err := os.ReadFile(credentialsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		provider, err := notifications.NewFCMProvider(credentials, notifications.FCMURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
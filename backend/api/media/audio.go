@@ -0,0 +1,392 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const WAVEFORM_SAMPLES = 64
+
+var ErrBadAudio = errors.New("media: malformed or unsupported audio")
+
+// AudioInfo describes a voice note. Its Waveform holds WAVEFORM_SAMPLES values
+// from 0 to 100 for the player to draw.
+type AudioInfo struct {
+	MimeType string
+	Duration time.Duration
+	Waveform []int
+}
+
+// frame is an encoded audio packet, its duration is in the units of the container.
+type frame struct {
+	size     int
+	duration int64
+}
+
+// SniffAudio detects Ogg/Opus and M4A files from their first bytes, "" for other files.
+// MP4 files with generic brands may hold audio only, ProbeAudio tells.
+func SniffAudio(head []byte) string {
+	if len(head) >= 36 && string(head[:4]) == "OggS" && string(head[28:36]) == "OpusHead" {
+		return "audio/ogg"
+	}
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		switch string(head[8:12]) {
+		case "M4A ", "M4B ":
+			return "audio/mp4"
+		}
+	}
+	return ""
+}
+
+// ProbeAudio validates a voice note of mimeType and reads its duration and waveform.
+// Decoding Opus or AAC is out of reach here, so the waveform follows the bitrate
+// of the audio over time: both codecs spend bytes on loud and busy sounds and
+// little on silence, which is what the player needs to show.
+func ProbeAudio(data []byte, mimeType string) (*AudioInfo, error) {
+	var frames []frame
+	var duration time.Duration
+	var err error
+	switch mimeType {
+	case "audio/ogg":
+		frames, duration, err = probeOgg(data)
+	case "audio/mp4", "video/mp4":
+		frames, duration, err = probeMP4(data)
+		mimeType = "audio/mp4"
+	default:
+		err = ErrBadAudio
+	}
+	if err != nil {
+		return nil, err
+	}
+	if duration <= 0 || len(frames) == 0 {
+		return nil, ErrBadAudio
+	}
+	return &AudioInfo{MimeType: mimeType, Duration: duration, Waveform: waveform(frames)}, nil
+}
+
+// waveform averages the bitrate of frames over WAVEFORM_SAMPLES equal stretches
+// of time and scales it to the loudest one.
+func waveform(frames []frame) []int {
+	var total int64
+	for _, f := range frames {
+		total += f.duration
+	}
+	samples := WAVEFORM_SAMPLES
+	if len(frames) < samples {
+		samples = len(frames)
+	}
+
+	sizes := make([]float64, samples)
+	durations := make([]float64, samples)
+	var start int64
+	for _, f := range frames {
+		i := 0
+		if total > 0 {
+			i = int(start * int64(samples) / total)
+		}
+		if i >= samples {
+			i = samples - 1
+		}
+		sizes[i] += float64(f.size)
+		durations[i] += float64(f.duration)
+		start += f.duration
+	}
+
+	rates := make([]float64, samples)
+	maximum := 0.0
+	for i := range rates {
+		switch {
+		case durations[i] > 0:
+			rates[i] = sizes[i] / durations[i]
+		case i > 0:
+			rates[i] = rates[i-1]
+		}
+		if rates[i] > maximum {
+			maximum = rates[i]
+		}
+	}
+
+	values := make([]int, samples)
+	for i, rate := range rates {
+		if maximum > 0 {
+			values[i] = int(rate/maximum*100 + 0.5)
+		}
+	}
+	return values
+}
+
+// probeOgg reads the packets of an Ogg/Opus stream (RFC 7845).
+func probeOgg(data []byte) ([]frame, time.Duration, error) {
+	frames := make([]frame, 0)
+	var packet []byte
+	packets := 0
+	preSkip := int64(0)
+	granule := int64(-1)
+	var serial uint32
+
+	for i := 0; i < len(data); {
+		if i+27 > len(data) || string(data[i:i+4]) != "OggS" || data[i+4] != 0 {
+			return nil, 0, ErrBadAudio
+		}
+		headerType := data[i+5]
+		pageGranule := int64(binary.LittleEndian.Uint64(data[i+6:]))
+		pageSerial := binary.LittleEndian.Uint32(data[i+14:])
+		segments := int(data[i+26])
+		start := i + 27 + segments
+		if start > len(data) {
+			return nil, 0, ErrBadAudio
+		}
+		if i == 0 {
+			if headerType&0x02 == 0 {
+				return nil, 0, ErrBadAudio
+			}
+			serial = pageSerial
+		}
+
+		offset := start
+		for _, size := range data[i+27 : start] {
+			if offset+int(size) > len(data) {
+				return nil, 0, ErrBadAudio
+			}
+			if pageSerial == serial {
+				packet = append(packet, data[offset:offset+int(size)]...)
+			}
+			offset += int(size)
+			if size == 255 || pageSerial != serial {
+				continue
+			}
+
+			switch packets {
+			case 0:
+				if len(packet) < 19 || !bytes.HasPrefix(packet, []byte("OpusHead")) || packet[8]>>4 != 0 || packet[9] == 0 {
+					return nil, 0, ErrBadAudio
+				}
+				preSkip = int64(binary.LittleEndian.Uint16(packet[10:]))
+			case 1:
+				if !bytes.HasPrefix(packet, []byte("OpusTags")) {
+					return nil, 0, ErrBadAudio
+				}
+			default:
+				samples := opusSamples(packet)
+				if samples == 0 {
+					return nil, 0, ErrBadAudio
+				}
+				frames = append(frames, frame{size: len(packet), duration: samples})
+			}
+			packets++
+			packet = packet[:0]
+		}
+		// -1 marks pages on which no packet ends
+		if pageSerial == serial && pageGranule != -1 {
+			granule = pageGranule
+		}
+		i = offset
+	}
+
+	if granule < preSkip {
+		return nil, 0, ErrBadAudio
+	}
+	// Opus granule positions count 48 kHz samples
+	duration := time.Duration(granule-preSkip) * time.Second / 48000
+	return frames, duration, nil
+}
+
+// opusSamples reads how many 48 kHz samples an Opus packet holds from its TOC byte (RFC 6716 3.1).
+func opusSamples(packet []byte) int64 {
+	if len(packet) == 0 {
+		return 0
+	}
+	config := packet[0] >> 3
+	var frameSamples int64
+	switch {
+	case config < 12:
+		frameSamples = []int64{480, 960, 1920, 2880}[config%4]
+	case config < 16:
+		frameSamples = []int64{480, 960}[config%2]
+	default:
+		frameSamples = []int64{120, 240, 480, 960}[config%4]
+	}
+	switch packet[0] & 0x03 {
+	case 0:
+		return frameSamples
+	case 1, 2:
+		return 2 * frameSamples
+	}
+	if len(packet) < 2 {
+		return 0
+	}
+	return int64(packet[1]&0x3F) * frameSamples
+}
+
+type box struct {
+	kind string
+	body []byte
+}
+
+// readBoxes splits the body of an ISO BMFF box into its child boxes.
+func readBoxes(data []byte) ([]box, error) {
+	boxes := make([]box, 0)
+	for i := 0; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrBadAudio
+		}
+		size := int64(binary.BigEndian.Uint32(data[i:]))
+		header := int64(8)
+		switch size {
+		case 0:
+			size = int64(len(data) - i)
+		case 1:
+			if i+16 > len(data) {
+				return nil, ErrBadAudio
+			}
+			size = int64(binary.BigEndian.Uint64(data[i+8:]))
+			header = 16
+		}
+		if size < header || int64(i)+size > int64(len(data)) {
+			return nil, ErrBadAudio
+		}
+		boxes = append(boxes, box{kind: string(data[i+4 : i+8]), body: data[int64(i)+header : int64(i)+size]})
+		i += int(size)
+	}
+	return boxes, nil
+}
+
+// child finds the box at path under data, nil when there is none.
+func child(data []byte, path ...string) []byte {
+	for _, kind := range path {
+		boxes, err := readBoxes(data)
+		if err != nil {
+			return nil
+		}
+		found := false
+		for _, b := range boxes {
+			if b.kind == kind {
+				data, found = b.body, true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return data
+}
+
+// probeMP4 reads the AAC sound track of an MP4 file that has no video.
+func probeMP4(data []byte) ([]frame, time.Duration, error) {
+	boxes, err := readBoxes(data)
+	if err != nil || len(boxes) == 0 || boxes[0].kind != "ftyp" {
+		return nil, 0, ErrBadAudio
+	}
+	moov := child(data, "moov")
+	if moov == nil {
+		return nil, 0, ErrBadAudio
+	}
+	traks, err := readBoxes(moov)
+	if err != nil {
+		return nil, 0, ErrBadAudio
+	}
+
+	var sound []byte
+	for _, trak := range traks {
+		if trak.kind != "trak" {
+			continue
+		}
+		hdlr := child(trak.body, "mdia", "hdlr")
+		if len(hdlr) < 12 {
+			return nil, 0, ErrBadAudio
+		}
+		switch string(hdlr[8:12]) {
+		case "vide":
+			return nil, 0, ErrBadAudio
+		case "soun":
+			if sound == nil {
+				sound = trak.body
+			}
+		}
+	}
+	if sound == nil {
+		return nil, 0, ErrBadAudio
+	}
+
+	mdhd := child(sound, "mdia", "mdhd")
+	var timescale, ticks uint64
+	switch {
+	case len(mdhd) >= 20 && mdhd[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(mdhd[12:]))
+		ticks = uint64(binary.BigEndian.Uint32(mdhd[16:]))
+	case len(mdhd) >= 32 && mdhd[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(mdhd[20:]))
+		ticks = binary.BigEndian.Uint64(mdhd[24:])
+	default:
+		return nil, 0, ErrBadAudio
+	}
+	if timescale == 0 {
+		return nil, 0, ErrBadAudio
+	}
+
+	stbl := child(sound, "mdia", "minf", "stbl")
+	stsd := child(stbl, "stsd")
+	if len(stsd) < 16 || string(stsd[12:16]) != "mp4a" {
+		return nil, 0, ErrBadAudio
+	}
+
+	sizes, err := sampleSizes(child(stbl, "stsz"))
+	if err != nil {
+		return nil, 0, err
+	}
+	durations, err := sampleDurations(child(stbl, "stts"), len(sizes))
+	if err != nil {
+		return nil, 0, err
+	}
+	frames := make([]frame, len(sizes))
+	for i := range sizes {
+		frames[i] = frame{size: sizes[i], duration: durations[i]}
+	}
+
+	duration := time.Duration(ticks * uint64(time.Second) / timescale)
+	return frames, duration, nil
+}
+
+func sampleSizes(stsz []byte) ([]int, error) {
+	if len(stsz) < 12 {
+		return nil, ErrBadAudio
+	}
+	size := int(binary.BigEndian.Uint32(stsz[4:]))
+	count := int(binary.BigEndian.Uint32(stsz[8:]))
+	if count > 1<<24 || (size == 0 && len(stsz) < 12+4*count) {
+		return nil, ErrBadAudio
+	}
+	sizes := make([]int, count)
+	for i := range sizes {
+		sizes[i] = size
+		if size == 0 {
+			sizes[i] = int(binary.BigEndian.Uint32(stsz[12+4*i:]))
+		}
+	}
+	return sizes, nil
+}
+
+func sampleDurations(stts []byte, samples int) ([]int64, error) {
+	if len(stts) < 8 {
+		return nil, ErrBadAudio
+	}
+	entries := int(binary.BigEndian.Uint32(stts[4:]))
+	if len(stts) < 8+8*entries {
+		return nil, ErrBadAudio
+	}
+	durations := make([]int64, 0, samples)
+	for i := 0; i < entries && len(durations) < samples; i++ {
+		count := int(binary.BigEndian.Uint32(stts[8+8*i:]))
+		delta := int64(binary.BigEndian.Uint32(stts[12+8*i:]))
+		for j := 0; j < count && len(durations) < samples; j++ {
+			durations = append(durations, delta)
+		}
+	}
+	if len(durations) != samples {
+		return nil, ErrBadAudio
+	}
+	return durations, nil
+}
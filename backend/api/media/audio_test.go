@@ -0,0 +1,116 @@
+package media_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/media"
+	"github.com/stretchr/testify/assert"
+)
+
+// oggPage wraps packets that fit in single segments into an Ogg page.
+func oggPage(headerType byte, granule int64, sequence uint32, packets ...[]byte) []byte {
+	page := []byte("OggS\x00")
+	page = append(page, headerType)
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, 1)
+	page = binary.LittleEndian.AppendUint32(page, sequence)
+	page = binary.LittleEndian.AppendUint32(page, 0)
+	page = append(page, byte(len(packets)))
+	for _, packet := range packets {
+		page = append(page, byte(len(packet)))
+	}
+	for _, packet := range packets {
+		page = append(page, packet...)
+	}
+	return page
+}
+
+func TestProbeOggOpus(t *testing.T) {
+	//Arrange
+	head := []byte("OpusHead\x01\x01\x38\x01\x80\xbb\x00\x00\x00\x00\x00")
+	tags := []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")
+	packets := make([][]byte, 0)
+	for i := 0; i < 100; i++ {
+		// SILK 20 ms frames, loud in the middle
+		size := 10
+		if i >= 40 && i < 60 {
+			size = 80
+		}
+		packet := make([]byte, size)
+		packet[0] = 1 << 3
+		packets = append(packets, packet)
+	}
+	data := oggPage(0x02, 0, 0, head)
+	data = append(data, oggPage(0, 0, 1, tags)...)
+	data = append(data, oggPage(0x04, 100*960+312, 2, packets...)...)
+
+	//Act
+	info, err := media.ProbeAudio(data, media.SniffAudio(data))
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "audio/ogg", info.MimeType)
+	assert.Equal(t, 2*time.Second, info.Duration)
+	if assert.Len(t, info.Waveform, media.WAVEFORM_SAMPLES) {
+		assert.Equal(t, 13, info.Waveform[0])
+		assert.Equal(t, 100, info.Waveform[32])
+	}
+}
+
+func TestProbeOggRejectsVorbis(t *testing.T) {
+	//Arrange
+	data := oggPage(0x02, 0, 0, []byte("\x01vorbis\x00\x00\x00\x00\x01\x44\xac\x00\x00"))
+
+	//Act
+	info, err := media.ProbeAudio(data, "audio/ogg")
+
+	//Assert
+	assert.Nil(t, info)
+	assert.ErrorIs(t, err, media.ErrBadAudio)
+}
+
+func mp4Box(kind string, children ...[]byte) []byte {
+	size := 8
+	for _, c := range children {
+		size += len(c)
+	}
+	b := binary.BigEndian.AppendUint32(nil, uint32(size))
+	b = append(b, kind...)
+	for _, c := range children {
+		b = append(b, c...)
+	}
+	return b
+}
+
+func TestProbeM4A(t *testing.T) {
+	//Arrange
+	mdhd := make([]byte, 24)
+	binary.BigEndian.PutUint32(mdhd[12:], 44100)
+	binary.BigEndian.PutUint32(mdhd[16:], 44100*3/2)
+	hdlr := append(make([]byte, 8), "soun"...)
+	hdlr = append(hdlr, make([]byte, 13)...)
+	stsd := append(binary.BigEndian.AppendUint32(make([]byte, 4), 1), mp4Box("mp4a", make([]byte, 28))...)
+	stsz := binary.BigEndian.AppendUint32(make([]byte, 4), 0)
+	stsz = binary.BigEndian.AppendUint32(stsz, 3)
+	for _, size := range []uint32{100, 400, 200} {
+		stsz = binary.BigEndian.AppendUint32(stsz, size)
+	}
+	stts := binary.BigEndian.AppendUint32(make([]byte, 4), 1)
+	stts = binary.BigEndian.AppendUint32(stts, 3)
+	stts = binary.BigEndian.AppendUint32(stts, 1024)
+
+	stbl := mp4Box("stbl", mp4Box("stsd", stsd), mp4Box("stts", stts), mp4Box("stsz", stsz))
+	trak := mp4Box("trak", mp4Box("mdia", mp4Box("mdhd", mdhd), mp4Box("hdlr", hdlr), mp4Box("minf", stbl)))
+	data := append(mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom")), mp4Box("moov", trak)...)
+
+	//Act
+	info, err := media.ProbeAudio(data, media.SniffAudio(data))
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "audio/mp4", info.MimeType)
+	assert.Equal(t, 1500*time.Millisecond, info.Duration)
+	assert.Equal(t, []int{25, 100, 50}, info.Waveform)
+}
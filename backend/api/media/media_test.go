@@ -0,0 +1,153 @@
+package media_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/media"
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// exifSegment is an APP1 segment with an orientation tag and a bogus GPS payload.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, []byte("\x00\x00\x00\x00GPS 50.45N 30.52E")...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func TestStripJPEGDropsExif(t *testing.T) {
+	//Arrange
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := append(append([]byte{0xFF, 0xD8}, exifSegment(6)...), buf.Bytes()[2:]...)
+
+	//Act
+	orientation := media.JPEGOrientation(data)
+	stripped, err := media.StripJPEG(data)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 6, orientation)
+	assert.False(t, bytes.Contains(stripped, []byte("GPS")))
+	assert.Equal(t, buf.Bytes(), stripped)
+}
+
+func TestStripWebPDropsExif(t *testing.T) {
+	//Arrange
+	vp8x := []byte("VP8X\x0a\x00\x00\x00\x08\x00\x00\x00\x3f\x01\x00\xef\x00\x00")
+	exif := []byte("EXIF\x04\x00\x00\x00GPS!")
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), vp8x...)
+	data = append(data, exif...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	//Act
+	stripped, err := media.StripWebP(data)
+	width, height, sizeErr := media.WebPSize(stripped)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Nil(t, sizeErr)
+	assert.False(t, bytes.Contains(stripped, []byte("GPS")))
+	assert.Equal(t, byte(0), stripped[20])
+	assert.Equal(t, uint32(len(stripped)-8), binary.LittleEndian.Uint32(stripped[4:]))
+	assert.Equal(t, 320, width)
+	assert.Equal(t, 240, height)
+}
+
+func TestOrientRotatesClockwise(t *testing.T) {
+	//Arrange
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	//Act
+	oriented := media.Orient(img, 6)
+
+	//Assert
+	assert.Equal(t, image.Rect(0, 0, 1, 2), oriented.Bounds())
+	assert.Equal(t, red, oriented.At(0, 0))
+	assert.Equal(t, blue, oriented.At(0, 1))
+}
+
+func TestBlurhashOfSolidImage(t *testing.T) {
+	//Arrange
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 12))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	//Act
+	hash := media.Blurhash(img, 4, 3)
+
+	//Assert
+	assert.Len(t, hash, 28)
+	assert.Equal(t, byte('L'), hash[0])
+	dc := 0
+	for _, c := range hash[2:6] {
+		dc = dc*83 + strings.IndexRune(base83, c)
+	}
+	assert.Equal(t, 0xFFFFFF, dc)
+}
+
+func TestProcessMakesVariants(t *testing.T) {
+	//Arrange
+	img := image.NewNRGBA(image.Rect(0, 0, 600, 400))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment := models.Attachment{Id: 1, Key: "chats/1/image", MimeType: "image/png", Size: int64(buf.Len())}
+	if err := blobs.Put(attachment.Key, bytes.NewReader(buf.Bytes()), attachment.Size, attachment.MimeType); err != nil {
+		t.Fatal(err)
+	}
+	processor := media.NewProcessor(nil, blobs)
+
+	//Act
+	result, err := processor.Process(attachment)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 600, result.Width)
+	assert.Equal(t, 400, result.Height)
+	assert.Len(t, result.Blurhash, 28)
+	if assert.Len(t, result.Variants, 2) {
+		assert.Equal(t, "small", result.Variants[0].Name)
+		assert.Equal(t, 160, result.Variants[0].Width)
+		assert.Equal(t, 107, result.Variants[0].Height)
+		assert.Equal(t, 480, result.Variants[1].Width)
+	}
+	_, err = blobs.Get(models.VariantKey(attachment.Key, "medium"))
+	assert.Nil(t, err)
+}
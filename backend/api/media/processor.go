@@ -0,0 +1,272 @@
+// Package media reads attachments. Images are processed in the background:
+// metadata such as GPS location is stripped, dimensions and a blurhash are
+// recorded and thumbnails are made. Voice notes are probed on upload.
+package media
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+)
+
+const (
+	MAX_IMAGE_SIZE   = 50 << 20
+	MAX_IMAGE_PIXELS = 50 << 20
+	PROCESSING_LEASE = 10 * time.Minute
+	SWEEP_INTERVAL   = time.Minute
+	SWEEP_BATCH      = 100
+	QUEUE_SIZE       = 256
+	BLURHASH_SIZE    = 32
+)
+
+// VariantSizes are the thumbnails made of an image, each fits a square of its size.
+var VariantSizes = []struct {
+	Name string
+	Size int
+}{
+	{"small", 160},
+	{"medium", 480},
+	{"large", 1280},
+}
+
+type Processor struct {
+	AttachmentStorer models.IAttachmentStorer
+	Storage          storage.Storage
+	queue            chan models.Message
+}
+
+func NewProcessor(attachmentStorer models.IAttachmentStorer, blobs storage.Storage) *Processor {
+	return &Processor{
+		AttachmentStorer: attachmentStorer,
+		Storage:          blobs,
+		queue:            make(chan models.Message, QUEUE_SIZE),
+	}
+}
+
+// Start runs workers processing the queued messages, onProcessed is called
+// with the id of a message once its images are processed. Images left
+// unprocessed by a full queue or a restart are picked up every SWEEP_INTERVAL.
+// Images are leased to one worker at a time so any number of instances can run.
+func (p *Processor) Start(workers int, onProcessed func(messageId int)) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for msg := range p.queue {
+				if p.processMessage(msg) {
+					onProcessed(msg.Id)
+				}
+			}
+		}()
+	}
+	go func() {
+		p.sweep()
+		for range time.Tick(SWEEP_INTERVAL) {
+			p.sweep()
+		}
+	}()
+}
+
+// Enqueue queues the images of msg for processing without blocking the caller.
+func (p *Processor) Enqueue(msg models.Message) {
+	for _, attachment := range msg.Attachments {
+		if !attachment.Processing {
+			continue
+		}
+		select {
+		case p.queue <- msg:
+		default:
+			log.Printf("media queue is full, images of message %d are left for the sweep\n", msg.Id)
+		}
+		return
+	}
+}
+
+func (p *Processor) sweep() {
+	attachments, err := p.AttachmentStorer.GetUnprocessed(SWEEP_BATCH)
+	if err != nil {
+		log.Printf("error while looking for unprocessed images: %v\n", err)
+		return
+	}
+	messages := make(map[int]*models.Message)
+	for _, attachment := range attachments {
+		if messages[attachment.MessageId] == nil {
+			messages[attachment.MessageId] = &models.Message{Id: attachment.MessageId}
+		}
+		msg := messages[attachment.MessageId]
+		msg.Attachments = append(msg.Attachments, attachment)
+	}
+	for _, msg := range messages {
+		p.Enqueue(*msg)
+	}
+}
+
+// processMessage processes the images of msg that aren't processed by someone
+// else and reports whether any were.
+func (p *Processor) processMessage(msg models.Message) bool {
+	processed := false
+	for _, attachment := range msg.Attachments {
+		if !attachment.Processing {
+			continue
+		}
+		claimed, err := p.AttachmentStorer.ClaimForProcessing(attachment.Id, PROCESSING_LEASE)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			log.Printf("error while claiming attachment %d: %v\n", attachment.Id, err)
+			continue
+		}
+
+		result, err := p.Process(*claimed)
+		if errors.Is(err, ErrBadImage) {
+			log.Printf("attachment %d isn't a valid image: %v\n", attachment.Id, err)
+			result = &models.ProcessedImage{Size: claimed.Size, Failed: true}
+		} else if err != nil {
+			// the lease runs out and the sweep retries
+			log.Printf("error while processing attachment %d: %v\n", attachment.Id, err)
+			continue
+		}
+
+		if err := p.AttachmentStorer.SetProcessed(attachment.Id, *result); err != nil {
+			log.Printf("error while saving processed attachment %d: %v\n", attachment.Id, err)
+			continue
+		}
+		processed = true
+	}
+	return processed
+}
+
+// Process strips the metadata of an image attachment in place and makes its
+// thumbnails. Images that can't be read fail with ErrBadImage.
+func (p *Processor) Process(attachment models.Attachment) (*models.ProcessedImage, error) {
+	if attachment.Size > MAX_IMAGE_SIZE {
+		return nil, ErrBadImage
+	}
+	blob, err := p.Storage.Get(attachment.Key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	stripped := data
+	orientation := 1
+	switch attachment.MimeType {
+	case "image/jpeg":
+		orientation = JPEGOrientation(data)
+		stripped, err = StripJPEG(data)
+	case "image/png":
+		stripped, err = StripPNG(data)
+	case "image/webp":
+		stripped, err = StripWebP(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// there is no WebP decoder at hand, so WebP images get no thumbnails
+	if attachment.MimeType == "image/webp" {
+		width, height, err := WebPSize(stripped)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.replace(attachment, data, stripped); err != nil {
+			return nil, err
+		}
+		return &models.ProcessedImage{Width: width, Height: height, Size: int64(len(stripped))}, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(stripped))
+	if err != nil || config.Width*config.Height > MAX_IMAGE_PIXELS {
+		return nil, ErrBadImage
+	}
+	img, _, err := image.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, ErrBadImage
+	}
+
+	// the orientation is gone with the metadata, so the pixels are turned instead
+	if orientation != 1 {
+		img = Orient(img, orientation)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+		stripped = buf.Bytes()
+	}
+	if err := p.replace(attachment, data, stripped); err != nil {
+		return nil, err
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	result := &models.ProcessedImage{
+		Width:    width,
+		Height:   height,
+		Size:     int64(len(stripped)),
+		Variants: make([]models.AttachmentVariant, 0, len(VariantSizes)),
+	}
+
+	for _, variant := range VariantSizes {
+		if width <= variant.Size && height <= variant.Size {
+			break
+		}
+		fitWidth, fitHeight := Fit(width, height, variant.Size)
+		thumbnail := Resize(img, fitWidth, fitHeight)
+		encoded, mimeType, err := encode(thumbnail)
+		if err != nil {
+			return nil, err
+		}
+		key := models.VariantKey(attachment.Key, variant.Name)
+		err = p.Storage.Put(key, bytes.NewReader(encoded), int64(len(encoded)), mimeType)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, models.AttachmentVariant{
+			Name:     variant.Name,
+			Width:    thumbnail.Rect.Dx(),
+			Height:   thumbnail.Rect.Dy(),
+			MimeType: mimeType,
+			Size:     int64(len(encoded)),
+		})
+	}
+
+	xComponents, yComponents := 4, 3
+	if height > width {
+		xComponents, yComponents = 3, 4
+	}
+	fitWidth, fitHeight := Fit(width, height, BLURHASH_SIZE)
+	result.Blurhash = Blurhash(Resize(img, fitWidth, fitHeight), xComponents, yComponents)
+
+	return result, nil
+}
+
+// replace overwrites the original of an attachment when stripping changed it.
+func (p *Processor) replace(attachment models.Attachment, original, stripped []byte) error {
+	if bytes.Equal(original, stripped) {
+		return nil
+	}
+	return p.Storage.Put(attachment.Key, bytes.NewReader(stripped), int64(len(stripped)), attachment.MimeType)
+}
+
+// encode stores opaque thumbnails as JPEG and the ones with transparency as PNG.
+func encode(img *image.NRGBA) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if img.Opaque() {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", err
+}
@@ -0,0 +1,296 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Attachment is an uploaded file kept in blob storage under Key. It belongs to
+// the chat it was uploaded to and gets a MessageId once it is sent.
+// Duration in milliseconds and Waveform are set for audio.
+// Images are Processing until their metadata is stripped and their variants are made.
+type Attachment struct {
+	Id               int                 `json:"id"`
+	UploaderId       int                 `json:"uploaderId"`
+	ChatId           int                 `json:"chatId"`
+	MessageId        int                 `json:"messageId,omitempty"`
+	Key              string              `json:"-"`
+	FileName         string              `json:"fileName"`
+	MimeType         string              `json:"mimeType"`
+	Size             int64               `json:"size"`
+	Width            int                 `json:"width,omitempty"`
+	Height           int                 `json:"height,omitempty"`
+	Blurhash         string              `json:"blurhash,omitempty"`
+	Duration         int                 `json:"duration,omitempty"`
+	Waveform         []int               `json:"waveform,omitempty"`
+	Variants         []AttachmentVariant `json:"variants,omitempty"`
+	Processing       bool                `json:"processing,omitempty"`
+	ProcessingFailed bool                `json:"processingFailed,omitempty"`
+	CreatedAt        string              `json:"createdAt"`
+}
+
+// AttachmentVariant is a resized copy of an image attachment kept under VariantKey.
+type AttachmentVariant struct {
+	Name     string `json:"name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+}
+
+func VariantKey(key, name string) string {
+	return key + "_" + name
+}
+
+// ProcessedImage is what processing found out about an image attachment.
+type ProcessedImage struct {
+	Width    int
+	Height   int
+	Blurhash string
+	Size     int64
+	Variants []AttachmentVariant
+	Failed   bool
+}
+
+type AttachmentDTO struct {
+	UploaderId int
+	ChatId     int
+	Key        string
+	FileName   string
+	MimeType   string
+	Size       int64
+	Duration   int
+	Waveform   []int
+}
+
+// AttachmentURL is a signed download URL of an attachment valid until ExpiresAt.
+type AttachmentURL struct {
+	Url       string `json:"url"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+type IAttachmentStorer interface {
+	Create(dto AttachmentDTO) (*Attachment, error)
+	CreateInTx(tx *sql.Tx, dto AttachmentDTO) (*Attachment, error)
+	GetOne(id int) (*Attachment, error)
+	GetForMessages(messageIds []int) (map[int][]Attachment, error)
+	AttachInTx(tx *sql.Tx, ids []int, messageId, uploaderId, chatId int) (int, error)
+	CopyInTx(tx *sql.Tx, fromMessageId, toMessageId, uploaderId, chatId int) ([]Attachment, error)
+	GetUnprocessed(limit int) ([]Attachment, error)
+	ClaimForProcessing(id int, lease time.Duration) (*Attachment, error)
+	SetProcessed(id int, image ProcessedImage) error
+	DeleteForMessages(messageIds []int) ([]Attachment, error)
+}
+
+const attachmentColumns = `id, uploader_id, chat_id, message_id, key, file_name, mime_type, size,
+	width, height, blurhash, duration, waveform, variants, processed_at IS NULL AND mime_type LIKE 'image/%', processing_failed, created_at`
+
+// unprocessedAttachment matches the sent images that aren't processed and aren't being processed.
+const unprocessedAttachment = `processed_at IS NULL AND message_id IS NOT NULL AND mime_type LIKE 'image/%'
+	AND (processing_until IS NULL OR processing_until < now())`
+
+func scanAttachment(row rowScanner) (*Attachment, error) {
+	var attachment Attachment
+	var uploaderId, messageId, width, height, duration sql.NullInt64
+	var blurhash sql.NullString
+	var waveform pq.Int64Array
+	var variants []byte
+	err := row.Scan(
+		&attachment.Id,
+		&uploaderId,
+		&attachment.ChatId,
+		&messageId,
+		&attachment.Key,
+		&attachment.FileName,
+		&attachment.MimeType,
+		&attachment.Size,
+		&width,
+		&height,
+		&blurhash,
+		&duration,
+		&waveform,
+		&variants,
+		&attachment.Processing,
+		&attachment.ProcessingFailed,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	attachment.UploaderId = int(uploaderId.Int64)
+	attachment.MessageId = int(messageId.Int64)
+	attachment.Width = int(width.Int64)
+	attachment.Height = int(height.Int64)
+	attachment.Blurhash = blurhash.String
+	attachment.Duration = int(duration.Int64)
+	for _, value := range waveform {
+		attachment.Waveform = append(attachment.Waveform, int(value))
+	}
+	if err := json.Unmarshal(variants, &attachment.Variants); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func scanAttachments(rows *sql.Rows) ([]Attachment, error) {
+	defer rows.Close()
+	attachments := make([]Attachment, 0)
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+type AttachmentStorer struct {
+	DB *sql.DB
+}
+
+func NewAttachmentStorer(db *sql.DB) AttachmentStorer {
+	return AttachmentStorer{DB: db}
+}
+
+const insertAttachment = `INSERT INTO attachments (uploader_id, chat_id, key, file_name, mime_type, size, duration, waveform)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING ` + attachmentColumns
+
+func insertAttachmentArgs(dto AttachmentDTO) []any {
+	var waveform pq.Int64Array
+	for _, value := range dto.Waveform {
+		waveform = append(waveform, int64(value))
+	}
+	return []any{
+		dto.UploaderId,
+		dto.ChatId,
+		dto.Key,
+		dto.FileName,
+		dto.MimeType,
+		dto.Size,
+		sql.NullInt64{Int64: int64(dto.Duration), Valid: dto.Duration != 0},
+		waveform,
+	}
+}
+
+func (as AttachmentStorer) Create(dto AttachmentDTO) (*Attachment, error) {
+	row := as.DB.QueryRow(insertAttachment, insertAttachmentArgs(dto)...)
+	return scanAttachment(row)
+}
+
+func (as AttachmentStorer) CreateInTx(tx *sql.Tx, dto AttachmentDTO) (*Attachment, error) {
+	row := tx.QueryRow(insertAttachment, insertAttachmentArgs(dto)...)
+	return scanAttachment(row)
+}
+
+func (as AttachmentStorer) GetOne(id int) (*Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE id = $1"
+	row := as.DB.QueryRow(query, id)
+	return scanAttachment(row)
+}
+
+func (as AttachmentStorer) GetForMessages(messageIds []int) (map[int][]Attachment, error) {
+	attachments := make(map[int][]Attachment)
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE message_id = ANY($1) ORDER BY id"
+	rows, err := as.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments[attachment.MessageId] = append(attachments[attachment.MessageId], *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// AttachInTx links attachments uploaderId uploaded to chatId and hasn't sent yet
+// to messageId, it returns how many of ids were linked.
+func (as AttachmentStorer) AttachInTx(tx *sql.Tx, ids []int, messageId, uploaderId, chatId int) (int, error) {
+	query := `UPDATE attachments SET message_id = $1
+		WHERE id = ANY($2) AND uploader_id = $3 AND chat_id = $4 AND message_id IS NULL`
+	result, err := tx.Exec(query, messageId, pq.Array(ids), uploaderId, chatId)
+	if err != nil {
+		return 0, err
+	}
+	attached, err := result.RowsAffected()
+	return int(attached), err
+}
+
+// CopyInTx attaches the attachments of fromMessageId to toMessageId as well,
+// the copies share the blobs of the originals.
+func (as AttachmentStorer) CopyInTx(tx *sql.Tx, fromMessageId, toMessageId, uploaderId, chatId int) ([]Attachment, error) {
+	query := `INSERT INTO attachments (uploader_id, chat_id, message_id, key, file_name, mime_type, size,
+			width, height, blurhash, duration, waveform, variants, processed_at, processing_failed)
+		SELECT $3, $4, $2, key, file_name, mime_type, size,
+			width, height, blurhash, duration, waveform, variants, processed_at, processing_failed
+		FROM attachments WHERE message_id = $1 ORDER BY id
+		RETURNING ` + attachmentColumns
+	rows, err := tx.Query(query, fromMessageId, toMessageId, uploaderId, chatId)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+func (as AttachmentStorer) GetUnprocessed(limit int) ([]Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE " + unprocessedAttachment + " ORDER BY id LIMIT $1"
+	rows, err := as.DB.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+// ClaimForProcessing leases an unprocessed image to the caller, it returns
+// sql.ErrNoRows when the image is processed or leased to someone else.
+func (as AttachmentStorer) ClaimForProcessing(id int, lease time.Duration) (*Attachment, error) {
+	query := "UPDATE attachments SET processing_until = now() + $2 * interval '1 second' WHERE id = $1 AND " +
+		unprocessedAttachment + " RETURNING " + attachmentColumns
+	row := as.DB.QueryRow(query, id, lease.Seconds())
+	return scanAttachment(row)
+}
+
+func (as AttachmentStorer) SetProcessed(id int, image ProcessedImage) error {
+	if image.Variants == nil {
+		image.Variants = make([]AttachmentVariant, 0)
+	}
+	variants, err := json.Marshal(image.Variants)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE attachments SET width = $2, height = $3, blurhash = $4, size = $5, variants = $6,
+		processing_failed = $7, processed_at = now(), processing_until = NULL
+		WHERE id = $1`
+	_, err = as.DB.Exec(
+		query,
+		id,
+		sql.NullInt64{Int64: int64(image.Width), Valid: image.Width != 0},
+		sql.NullInt64{Int64: int64(image.Height), Valid: image.Height != 0},
+		sql.NullString{String: image.Blurhash, Valid: image.Blurhash != ""},
+		image.Size,
+		variants,
+		image.Failed,
+	)
+	return err
+}
+
+// DeleteForMessages removes the attachments of messageIds and returns the ones
+// whose blobs no other attachment shares, so the blobs can be deleted.
+func (as AttachmentStorer) DeleteForMessages(messageIds []int) ([]Attachment, error) {
+	query := `WITH deleted AS (DELETE FROM attachments WHERE message_id = ANY($1) RETURNING ` + attachmentColumns + `)
+		SELECT * FROM deleted WHERE NOT EXISTS (
+			SELECT 1 FROM attachments a WHERE a.key = deleted.key AND NOT a.message_id = ANY($1)
+		)`
+	rows, err := as.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
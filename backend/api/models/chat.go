@@ -9,6 +9,8 @@ type Chat struct {
 	Title     string `json:"title"`
 	CreatorId int    `json:"creatorId"`
 	CreatedAt string `json:"createdAt"`
+	// MessageTTL is the MessageTTL of the chat settings, so clients see that messages disappear.
+	MessageTTL int `json:"messageTtl"`
 }
 
 type ChatDTO struct {
@@ -64,11 +66,14 @@ func (cs ChatStorer) CreateInTx(tx *sql.Tx, dto ChatDTO) (*Chat, error) {
 	return &chat, nil
 }
 
+// chatColumns are the columns of chats along with the message TTL of their settings.
+const chatColumns = "c.id, c.title, c.creator_id, c.created_at, coalesce(s.message_ttl, 0)"
+
 func (cs ChatStorer) GetOne(id int) (*Chat, error) {
 	var chat Chat
-	query := "SELECT * FROM chats WHERE id = $1"
+	query := "SELECT " + chatColumns + " FROM chats c LEFT JOIN chat_settings s ON s.chat_id = c.id WHERE c.id = $1"
 	row := cs.DB.QueryRow(query, id)
-	err := row.Scan(&chat.Id, &chat.Title, &chat.CreatorId, &chat.CreatedAt)
+	err := row.Scan(&chat.Id, &chat.Title, &chat.CreatorId, &chat.CreatedAt, &chat.MessageTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -77,14 +82,15 @@ func (cs ChatStorer) GetOne(id int) (*Chat, error) {
 
 func (cs ChatStorer) GetUserChats(userId int) ([]Chat, error) {
 	userChats := make([]Chat, 0)
-	query := "SELECT c.* FROM chats c JOIN participants p ON c.id = p.chat_id WHERE p.user_id = $1"
+	query := "SELECT " + chatColumns + ` FROM chats c JOIN participants p ON c.id = p.chat_id
+		LEFT JOIN chat_settings s ON s.chat_id = c.id WHERE p.user_id = $1`
 	rows, err := cs.DB.Query(query, userId)
 	if err != nil {
 		return nil, err
 	}
 	for rows.Next() {
 		var chat Chat
-		err := rows.Scan(&chat.Id, &chat.Title, &chat.CreatorId, &chat.CreatedAt)
+		err := rows.Scan(&chat.Id, &chat.Title, &chat.CreatorId, &chat.CreatedAt, &chat.MessageTTL)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ChatSettings are chat wide rules admins can change. Chats without
+// a stored row use the zero value, which puts no restrictions.
+type ChatSettings struct {
+	ChatId int `json:"chatId"`
+	// EditWindow is how many seconds after sending a message can be edited, 0 for no limit.
+	EditWindow int `json:"editWindow"`
+	// DeleteWindow is how many seconds after sending a message can be deleted for everyone by its sender, 0 for no limit.
+	DeleteWindow int `json:"deleteWindow"`
+	// MaxPins is how many messages can be pinned at once, 0 for the default.
+	MaxPins int `json:"maxPins"`
+	// MembersCanPin lets participants who aren't admins pin and unpin messages.
+	MembersCanPin bool `json:"membersCanPin"`
+	// MessageTTL is how many seconds new messages live before they are deleted for everyone, 0 to keep them.
+	MessageTTL int `json:"messageTtl"`
+}
+
+type IChatSettingsStorer interface {
+	Get(chatId int) (*ChatSettings, error)
+	Upsert(settings ChatSettings) (*ChatSettings, error)
+}
+
+type ChatSettingsStorer struct {
+	DB *sql.DB
+}
+
+func NewChatSettingsStorer(db *sql.DB) ChatSettingsStorer {
+	return ChatSettingsStorer{DB: db}
+}
+
+func (cs ChatSettingsStorer) Get(chatId int) (*ChatSettings, error) {
+	settings := ChatSettings{ChatId: chatId}
+	query := `SELECT edit_window, delete_window, max_pins, members_can_pin, message_ttl
+		FROM chat_settings WHERE chat_id = $1`
+	row := cs.DB.QueryRow(query, chatId)
+	err := row.Scan(&settings.EditWindow, &settings.DeleteWindow, &settings.MaxPins, &settings.MembersCanPin, &settings.MessageTTL)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (cs ChatSettingsStorer) Upsert(settings ChatSettings) (*ChatSettings, error) {
+	var updSettings ChatSettings
+	query := `INSERT INTO chat_settings (chat_id, edit_window, delete_window, max_pins, members_can_pin, message_ttl)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (chat_id) DO UPDATE SET edit_window = EXCLUDED.edit_window, delete_window = EXCLUDED.delete_window,
+		max_pins = EXCLUDED.max_pins, members_can_pin = EXCLUDED.members_can_pin, message_ttl = EXCLUDED.message_ttl
+		RETURNING chat_id, edit_window, delete_window, max_pins, members_can_pin, message_ttl`
+	row := cs.DB.QueryRow(
+		query,
+		settings.ChatId,
+		settings.EditWindow,
+		settings.DeleteWindow,
+		settings.MaxPins,
+		settings.MembersCanPin,
+		settings.MessageTTL,
+	)
+	err := row.Scan(
+		&updSettings.ChatId,
+		&updSettings.EditWindow,
+		&updSettings.DeleteWindow,
+		&updSettings.MaxPins,
+		&updSettings.MembersCanPin,
+		&updSettings.MessageTTL,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &updSettings, nil
+}
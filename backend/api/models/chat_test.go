@@ -146,10 +146,11 @@ func TestGetOneChatSuccess(t *testing.T) {
 	chatIdStr := "1"
 	creatorIdStr := "1"
 	expectedChat := models.Chat{
-		Id:        1,
-		Title:     "test-chat",
-		CreatorId: 1,
-		CreatedAt: "2023-06-27",
+		Id:         1,
+		Title:      "test-chat",
+		CreatorId:  1,
+		CreatedAt:  "2023-06-27",
+		MessageTTL: 3600,
 	}
 
 	db, mock, err := sqlmock.New()
@@ -160,8 +161,8 @@ func TestGetOneChatSuccess(t *testing.T) {
 
 	chatStorer := models.NewChatStorer(db)
 
-	row := sqlmock.NewRows([]string{"id", "title", "creator_id", "created_at"}).
-		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt)
+	row := sqlmock.NewRows([]string{"id", "title", "creator_id", "created_at", "message_ttl"}).
+		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt, expectedChat.MessageTTL)
 	mock.ExpectQuery("SELECT (.+) FROM chats").WillReturnRows(row)
 
 	//Act
@@ -200,10 +201,11 @@ func TestGetUserChatsSuccess(t *testing.T) {
 	chatIdStr := "1"
 	creatorIdStr := "1"
 	expectedChat := models.Chat{
-		Id:        1,
-		Title:     "test-chat",
-		CreatorId: 1,
-		CreatedAt: "2023-06-27",
+		Id:         1,
+		Title:      "test-chat",
+		CreatorId:  1,
+		CreatedAt:  "2023-06-27",
+		MessageTTL: 3600,
 	}
 	expectedChats := []models.Chat{expectedChat, expectedChat, expectedChat}
 
@@ -215,10 +217,10 @@ func TestGetUserChatsSuccess(t *testing.T) {
 
 	chatStorer := models.NewChatStorer(db)
 
-	rows := sqlmock.NewRows([]string{"id", "title", "creator_id", "created_at"}).
-		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt).
-		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt).
-		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt)
+	rows := sqlmock.NewRows([]string{"id", "title", "creator_id", "created_at", "message_ttl"}).
+		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt, expectedChat.MessageTTL).
+		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt, expectedChat.MessageTTL).
+		AddRow(chatIdStr, expectedChat.Title, creatorIdStr, expectedChat.CreatedAt, expectedChat.MessageTTL)
 	mock.ExpectQuery("SELECT (.+) FROM chats").WillReturnRows(rows)
 
 	//Act
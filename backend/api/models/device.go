@@ -0,0 +1,80 @@
+package models
+
+import "database/sql"
+
+type Device struct {
+	Id        int    `json:"id"`
+	UserId    int    `json:"userId"`
+	Platform  string `json:"platform"`
+	Token     string `json:"token"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type DeviceDTO struct {
+	UserId   int    `json:"userId"`
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+}
+
+type DeviceFromRequest struct {
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+}
+
+type IDeviceStorer interface {
+	Create(dto DeviceDTO) (*Device, error)
+	GetUserDevices(userId int) ([]Device, error)
+	Delete(id int) (*Device, error)
+}
+
+type DeviceStorer struct {
+	DB *sql.DB
+}
+
+func NewDeviceStorer(db *sql.DB) DeviceStorer {
+	return DeviceStorer{DB: db}
+}
+
+// Create registers a device token for a user. A token that is already
+// registered is moved to the new user, as it means the device changed hands.
+func (ds DeviceStorer) Create(dto DeviceDTO) (*Device, error) {
+	var device Device
+	query := `INSERT INTO devices (user_id, platform, token) VALUES ($1, $2, $3)
+		ON CONFLICT (platform, token) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING id, user_id, platform, token, created_at`
+	row := ds.DB.QueryRow(query, dto.UserId, dto.Platform, dto.Token)
+	err := row.Scan(&device.Id, &device.UserId, &device.Platform, &device.Token, &device.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (ds DeviceStorer) GetUserDevices(userId int) ([]Device, error) {
+	devices := make([]Device, 0)
+	query := "SELECT id, user_id, platform, token, created_at FROM devices WHERE user_id = $1"
+	rows, err := ds.DB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var device Device
+		err := rows.Scan(&device.Id, &device.UserId, &device.Platform, &device.Token, &device.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+func (ds DeviceStorer) Delete(id int) (*Device, error) {
+	var device Device
+	query := "DELETE FROM devices WHERE id = $1 RETURNING id, user_id, platform, token, created_at"
+	row := ds.DB.QueryRow(query, id)
+	err := row.Scan(&device.Id, &device.UserId, &device.Platform, &device.Token, &device.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
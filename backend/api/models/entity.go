@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// Entity formats a part of message content. Offset and Length are in UTF-16
+// code units like the ones of mentions. URL is set for links and Language,
+// when given, for code blocks.
+type Entity struct {
+	Type     string `json:"type"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	URL      string `json:"url,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+const (
+	EntityBold   = "bold"
+	EntityItalic = "italic"
+	EntityCode   = "code"
+	EntityPre    = "pre"
+	EntityLink   = "link"
+	EntityQuote  = "quote"
+)
+
+// entitiesJSON encodes entities for the jsonb column, nil ones as an empty list.
+func entitiesJSON(entities []Entity) (string, error) {
+	if entities == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(entities)
+	return string(data), err
+}
+
+func parseEntities(data []byte) ([]Entity, error) {
+	entities := make([]Entity, 0)
+	if len(data) == 0 {
+		return entities, nil
+	}
+	err := json.Unmarshal(data, &entities)
+	return entities, err
+}
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Export is a file with the history of a chat made in the background. Status is
+// "pending" until a worker picks it up, "running" while it writes it and then
+// "ready" or "failed" with the Error. Ready exports are kept until ExpiresAt.
+type Export struct {
+	Id          int    `json:"id"`
+	ChatId      int    `json:"chatId"`
+	RequestedBy int    `json:"requestedBy"`
+	Format      string `json:"format"`
+	Status      string `json:"status"`
+	Key         string `json:"-"`
+	Size        int64  `json:"size"`
+	Messages    int    `json:"messages"`
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	FinishedAt  string `json:"finishedAt,omitempty"`
+	ExpiresAt   string `json:"expiresAt,omitempty"`
+}
+
+type ExportFromRequest struct {
+	Format string `json:"format"`
+}
+
+type ExportFormat struct {
+	ContentType string
+	Extension   string
+}
+
+// ExportFormats are the formats chats can be exported in.
+var ExportFormats = map[string]ExportFormat{
+	"jsonl": {ContentType: "application/x-ndjson", Extension: "jsonl"},
+	"text":  {ContentType: "text/plain; charset=utf-8", Extension: "txt"},
+	"html":  {ContentType: "text/html; charset=utf-8", Extension: "html"},
+}
+
+// ExportHeader opens an export. Senders are everyone who wrote to the chat,
+// including the ones who don't participate in it anymore.
+type ExportHeader struct {
+	Chat         Chat         `json:"chat"`
+	Participants []ChatUser   `json:"participants"`
+	Senders      []ExportUser `json:"senders"`
+	ExportedAt   string       `json:"exportedAt"`
+}
+
+type ExportUser struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Tag  string `json:"tag"`
+}
+
+// ExportedMessage is a message as it is exported, with every reaction on it
+// and the content it had before each edit.
+type ExportedMessage struct {
+	Message
+	Reactions []Reaction `json:"reactions"`
+	Revisions []Revision `json:"revisions"`
+}
+
+type IExportStorer interface {
+	Create(chatId, requestedBy int, format string) (*Export, error)
+	GetOne(id int) (*Export, error)
+	GetForChat(chatId, requestedBy int) ([]Export, error)
+	CountPending(requestedBy int) (int, error)
+	GetSenders(chatId int) ([]ExportUser, error)
+	Claim(lease time.Duration) (*Export, error)
+	SetProgress(id, messages int, lease time.Duration) error
+	Finish(id int, key string, size int64, ttl time.Duration) error
+	Fail(id int, reason string) error
+	DeleteExpired() ([]Export, error)
+}
+
+const exportColumns = "id, chat_id, requested_by, format, status, key, size, messages, error, created_at, finished_at, expires_at"
+
+func scanExport(row rowScanner) (*Export, error) {
+	var export Export
+	var key, finishedAt, expiresAt sql.NullString
+	err := row.Scan(
+		&export.Id,
+		&export.ChatId,
+		&export.RequestedBy,
+		&export.Format,
+		&export.Status,
+		&key,
+		&export.Size,
+		&export.Messages,
+		&export.Error,
+		&export.CreatedAt,
+		&finishedAt,
+		&expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	export.Key = key.String
+	export.FinishedAt = finishedAt.String
+	export.ExpiresAt = expiresAt.String
+	return &export, nil
+}
+
+func scanExports(rows *sql.Rows) ([]Export, error) {
+	defer rows.Close()
+	exports := make([]Export, 0)
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *export)
+	}
+	return exports, rows.Err()
+}
+
+type ExportStorer struct {
+	DB *sql.DB
+}
+
+func NewExportStorer(db *sql.DB) ExportStorer {
+	return ExportStorer{DB: db}
+}
+
+func (es ExportStorer) Create(chatId, requestedBy int, format string) (*Export, error) {
+	query := "INSERT INTO chat_exports (chat_id, requested_by, format) VALUES ($1, $2, $3) RETURNING " + exportColumns
+	row := es.DB.QueryRow(query, chatId, requestedBy, format)
+	return scanExport(row)
+}
+
+// GetOne returns an export that hasn't expired.
+func (es ExportStorer) GetOne(id int) (*Export, error) {
+	query := "SELECT " + exportColumns + " FROM chat_exports WHERE id = $1 AND (expires_at IS NULL OR expires_at > now())"
+	row := es.DB.QueryRow(query, id)
+	return scanExport(row)
+}
+
+// GetForChat returns the exports of a chat that haven't expired, newest first,
+// only the ones requestedBy asked for unless it is 0.
+func (es ExportStorer) GetForChat(chatId, requestedBy int) ([]Export, error) {
+	query := "SELECT " + exportColumns + ` FROM chat_exports
+		WHERE chat_id = $1 AND ($2::integer = 0 OR requested_by = $2)
+		AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY created_at DESC, id DESC`
+	rows, err := es.DB.Query(query, chatId, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	return scanExports(rows)
+}
+
+func (es ExportStorer) CountPending(requestedBy int) (int, error) {
+	var count int
+	query := "SELECT count(*) FROM chat_exports WHERE requested_by = $1 AND status IN ('pending', 'running')"
+	err := es.DB.QueryRow(query, requestedBy).Scan(&count)
+	return count, err
+}
+
+// GetSenders returns everyone who sent messages to a chat.
+func (es ExportStorer) GetSenders(chatId int) ([]ExportUser, error) {
+	query := `SELECT id, name, tag FROM users
+		WHERE id IN (SELECT DISTINCT sender_id FROM messages WHERE chat_id = $1) ORDER BY id`
+	rows, err := es.DB.Query(query, chatId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := make([]ExportUser, 0)
+	for rows.Next() {
+		var user ExportUser
+		if err := rows.Scan(&user.Id, &user.Name, &user.Tag); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Claim leases the oldest export waiting to be written to the caller, it returns
+// sql.ErrNoRows when there is none. Exports whose worker stopped before finishing
+// them are claimed again once their lease runs out.
+func (es ExportStorer) Claim(lease time.Duration) (*Export, error) {
+	query := `UPDATE chat_exports SET status = 'running', messages = 0, claimed_until = now() + $1 * interval '1 second'
+		WHERE id = (
+			SELECT id FROM chat_exports
+			WHERE status = 'pending' OR status = 'running' AND claimed_until < now()
+			ORDER BY created_at LIMIT 1 FOR UPDATE SKIP LOCKED
+		) RETURNING ` + exportColumns
+	row := es.DB.QueryRow(query, lease.Seconds())
+	return scanExport(row)
+}
+
+// SetProgress records how many messages were written so far and extends the lease.
+func (es ExportStorer) SetProgress(id, messages int, lease time.Duration) error {
+	query := "UPDATE chat_exports SET messages = $2, claimed_until = now() + $3 * interval '1 second' WHERE id = $1"
+	_, err := es.DB.Exec(query, id, messages, lease.Seconds())
+	return err
+}
+
+func (es ExportStorer) Finish(id int, key string, size int64, ttl time.Duration) error {
+	query := `UPDATE chat_exports SET status = 'ready', key = $2, size = $3, claimed_until = NULL,
+			finished_at = now(), expires_at = now() + $4 * interval '1 second'
+		WHERE id = $1`
+	_, err := es.DB.Exec(query, id, key, size, ttl.Seconds())
+	return err
+}
+
+// Fail keeps an export that couldn't be written with the reason for a day, it isn't retried.
+func (es ExportStorer) Fail(id int, reason string) error {
+	query := `UPDATE chat_exports SET status = 'failed', error = $2, claimed_until = NULL,
+			finished_at = now(), expires_at = now() + interval '1 day'
+		WHERE id = $1`
+	_, err := es.DB.Exec(query, id, reason)
+	return err
+}
+
+// DeleteExpired removes the exports past their expiry and returns them so their
+// files can be deleted. Each export is returned by one call only.
+func (es ExportStorer) DeleteExpired() ([]Export, error) {
+	query := "DELETE FROM chat_exports WHERE expires_at <= now() RETURNING " + exportColumns
+	rows, err := es.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	return scanExports(rows)
+}
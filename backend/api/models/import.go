@@ -0,0 +1,305 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Import recreates the chats of an export of another messenger uploaded by UserId.
+// Status is "pending" until a worker picks it up, "running" while it imports and
+// then "done", or "failed" when nothing could be imported. Errors tell what was
+// left out, the import goes on past them.
+type Import struct {
+	Id      int           `json:"id"`
+	UserId  int           `json:"userId"`
+	Source  string        `json:"source"`
+	Options ImportOptions `json:"options"`
+	Key     string        `json:"-"`
+	Status  string        `json:"status"`
+	ImportProgress
+	CreatedAt  string `json:"createdAt"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
+
+// ImportSources are the messengers whose exports can be imported.
+var ImportSources = []string{"slack", "whatsapp"}
+
+type ImportProgress struct {
+	ChatIds          []int    `json:"chatIds"`
+	UsersCreated     int      `json:"usersCreated"`
+	MessagesTotal    int      `json:"messagesTotal"`
+	MessagesImported int      `json:"messagesImported"`
+	Errors           []string `json:"errors"`
+}
+
+// ImportOptions tune how an export is read. UserMap maps users of the source, by
+// their Slack id or their WhatsApp name, to the importing user, nobody else can be
+// mapped to. The others are mapped to the users earlier imports of the same user
+// mapped them to or to new placeholder users. Title
+// names the chat of a WhatsApp export. DateOrder is "dmy", "mdy" or "ymd" for
+// WhatsApp dates, guessed when empty, and TimeZone is the IANA zone their times
+// are in, UTC when empty.
+type ImportOptions struct {
+	UserMap   map[string]int `json:"userMap,omitempty"`
+	Title     string         `json:"title,omitempty"`
+	DateOrder string         `json:"dateOrder,omitempty"`
+	TimeZone  string         `json:"timeZone,omitempty"`
+}
+
+// ImportedMessage is a message of the source as it is inserted. Replies have
+// ParentAt set to when the message they reply to was sent.
+type ImportedMessage struct {
+	SenderId int
+	Content  string
+	SentAt   time.Time
+	ParentAt time.Time
+}
+
+type IImportStorer interface {
+	Create(userId int, source string, options ImportOptions, key string) (*Import, error)
+	GetOne(id int) (*Import, error)
+	GetForUser(userId int) ([]Import, error)
+	CountPending(userId int) (int, error)
+	Claim(lease time.Duration) (*Import, error)
+	SetProgress(id int, progress ImportProgress, lease time.Duration) error
+	Finish(id int, status string, progress ImportProgress) error
+	GetUsers(importerId int, source string, keys []string) (map[string]int, error)
+	MapUser(importerId int, source, key string, userId int) error
+	CreatePlaceholder(importerId int, source, key, tag, name, password string) (int, error)
+	InsertMessagesInTx(tx *sql.Tx, chatId int, msgs []ImportedMessage) (int64, error)
+}
+
+const importColumns = `id, user_id, source, options, key, status, chat_ids, users_created,
+	messages_total, messages_imported, errors, created_at, finished_at`
+
+func scanImport(row rowScanner) (*Import, error) {
+	var imp Import
+	var options, errors []byte
+	var chatIds pq.Int64Array
+	var finishedAt sql.NullString
+	err := row.Scan(
+		&imp.Id,
+		&imp.UserId,
+		&imp.Source,
+		&options,
+		&imp.Key,
+		&imp.Status,
+		&chatIds,
+		&imp.UsersCreated,
+		&imp.MessagesTotal,
+		&imp.MessagesImported,
+		&errors,
+		&imp.CreatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &imp.Options); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(errors, &imp.Errors); err != nil {
+		return nil, err
+	}
+	imp.ChatIds = make([]int, len(chatIds))
+	for i, id := range chatIds {
+		imp.ChatIds[i] = int(id)
+	}
+	imp.FinishedAt = finishedAt.String
+	return &imp, nil
+}
+
+func scanImports(rows *sql.Rows) ([]Import, error) {
+	defer rows.Close()
+	imports := make([]Import, 0)
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, *imp)
+	}
+	return imports, rows.Err()
+}
+
+// progressArgs are the values of progress as they are stored.
+func progressArgs(progress ImportProgress) ([]any, error) {
+	errors := progress.Errors
+	if errors == nil {
+		errors = make([]string, 0)
+	}
+	errorsJSON, err := json.Marshal(errors)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		pq.Array(progress.ChatIds),
+		progress.UsersCreated,
+		progress.MessagesTotal,
+		progress.MessagesImported,
+		string(errorsJSON),
+	}, nil
+}
+
+type ImportStorer struct {
+	DB *sql.DB
+}
+
+func NewImportStorer(db *sql.DB) ImportStorer {
+	return ImportStorer{DB: db}
+}
+
+func (is ImportStorer) Create(userId int, source string, options ImportOptions, key string) (*Import, error) {
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	query := "INSERT INTO imports (user_id, source, options, key) VALUES ($1, $2, $3, $4) RETURNING " + importColumns
+	row := is.DB.QueryRow(query, userId, source, string(optionsJSON), key)
+	return scanImport(row)
+}
+
+func (is ImportStorer) GetOne(id int) (*Import, error) {
+	query := "SELECT " + importColumns + " FROM imports WHERE id = $1"
+	row := is.DB.QueryRow(query, id)
+	return scanImport(row)
+}
+
+// GetForUser returns the imports of userId, newest first.
+func (is ImportStorer) GetForUser(userId int) ([]Import, error) {
+	query := "SELECT " + importColumns + " FROM imports WHERE user_id = $1 ORDER BY created_at DESC, id DESC"
+	rows, err := is.DB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	return scanImports(rows)
+}
+
+func (is ImportStorer) CountPending(userId int) (int, error) {
+	var count int
+	query := "SELECT count(*) FROM imports WHERE user_id = $1 AND status IN ('pending', 'running')"
+	err := is.DB.QueryRow(query, userId).Scan(&count)
+	return count, err
+}
+
+// Claim leases the oldest import waiting to be run to the caller, it returns
+// sql.ErrNoRows when there is none. Imports whose worker stopped before finishing
+// them aren't run again as they may have recreated chats already, they fail.
+func (is ImportStorer) Claim(lease time.Duration) (*Import, error) {
+	query := `UPDATE imports SET status = 'failed', finished_at = now(), claimed_until = NULL,
+			errors = errors || '["the import was interrupted"]'::jsonb
+		WHERE status = 'running' AND claimed_until < now()`
+	if _, err := is.DB.Exec(query); err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE imports SET status = 'running', claimed_until = now() + $1 * interval '1 second'
+		WHERE id = (
+			SELECT id FROM imports WHERE status = 'pending'
+			ORDER BY created_at LIMIT 1 FOR UPDATE SKIP LOCKED
+		) RETURNING ` + importColumns
+	row := is.DB.QueryRow(query, lease.Seconds())
+	return scanImport(row)
+}
+
+// SetProgress records how far an import got and extends the lease.
+func (is ImportStorer) SetProgress(id int, progress ImportProgress, lease time.Duration) error {
+	args, err := progressArgs(progress)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE imports SET chat_ids = $2, users_created = $3, messages_total = $4, messages_imported = $5,
+			errors = $6, claimed_until = now() + $7 * interval '1 second'
+		WHERE id = $1`
+	_, err = is.DB.Exec(query, append(append([]any{id}, args...), lease.Seconds())...)
+	return err
+}
+
+func (is ImportStorer) Finish(id int, status string, progress ImportProgress) error {
+	args, err := progressArgs(progress)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE imports SET chat_ids = $2, users_created = $3, messages_total = $4, messages_imported = $5,
+			errors = $6, status = $7, claimed_until = NULL, finished_at = now()
+		WHERE id = $1`
+	_, err = is.DB.Exec(query, append(append([]any{id}, args...), status)...)
+	return err
+}
+
+// GetUsers returns the local users keys of source were mapped to by earlier imports of importerId.
+func (is ImportStorer) GetUsers(importerId int, source string, keys []string) (map[string]int, error) {
+	users := make(map[string]int)
+	query := "SELECT source_key, user_id FROM imported_users WHERE importer_id = $1 AND source = $2 AND source_key = ANY($3)"
+	rows, err := is.DB.Query(query, importerId, source, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var userId int
+		if err := rows.Scan(&key, &userId); err != nil {
+			return nil, err
+		}
+		users[key] = userId
+	}
+	return users, rows.Err()
+}
+
+// MapUser remembers that key of source is userId for later imports of importerId,
+// the mappings of other importers are left as they are.
+func (is ImportStorer) MapUser(importerId int, source, key string, userId int) error {
+	query := `INSERT INTO imported_users (importer_id, source, source_key, user_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (importer_id, source, source_key) DO UPDATE SET user_id = $4, placeholder = false`
+	_, err := is.DB.Exec(query, importerId, source, key, userId)
+	return err
+}
+
+// CreatePlaceholder makes a user standing for key of source and maps key to it for importerId.
+func (is ImportStorer) CreatePlaceholder(importerId int, source, key, tag, name, password string) (int, error) {
+	query := `WITH placeholder AS (
+			INSERT INTO users (tag, name, password, description) VALUES ($4, $5, $6, $7)
+			RETURNING id
+		)
+		INSERT INTO imported_users (importer_id, source, source_key, user_id, placeholder)
+		SELECT $1, $2, $3, id, true FROM placeholder RETURNING user_id`
+	var userId int
+	err := is.DB.QueryRow(query, importerId, source, key, tag, name, password, "Imported from "+source).Scan(&userId)
+	return userId, err
+}
+
+// InsertMessagesInTx inserts msgs to a chat as they were sent. Replies are attached to
+// the messages of the chat sent at their ParentAt, which have to be inserted before.
+func (is ImportStorer) InsertMessagesInTx(tx *sql.Tx, chatId int, msgs []ImportedMessage) (int64, error) {
+	senderIds := make([]int, len(msgs))
+	contents := make([]string, len(msgs))
+	sentAts := make([]string, len(msgs))
+	parentAts := make([]sql.NullString, len(msgs))
+	for i, msg := range msgs {
+		senderIds[i] = msg.SenderId
+		contents[i] = msg.Content
+		sentAts[i] = msg.SentAt.UTC().Format(importTimeLayout)
+		if !msg.ParentAt.IsZero() {
+			parentAts[i] = sql.NullString{String: msg.ParentAt.UTC().Format(importTimeLayout), Valid: true}
+		}
+	}
+	query := `INSERT INTO messages (sender_id, chat_id, type, content, created_at, parent_id)
+		SELECT m.sender_id, $1, 'text', m.content, m.sent_at, (
+				SELECT p.id FROM messages p
+				WHERE p.chat_id = $1 AND p.parent_id IS NULL AND p.created_at = m.parent_at
+				ORDER BY p.id LIMIT 1
+			)
+		FROM unnest($2::integer[], $3::text[], $4::timestamp[], $5::timestamp[]) AS m(sender_id, content, sent_at, parent_at)`
+	result, err := tx.Exec(query, chatId, pq.Array(senderIds), pq.Array(contents), pq.Array(sentAts), pq.Array(parentAts))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// importTimeLayout keeps the microseconds of times, messages of a thread are told by them.
+const importTimeLayout = "2006-01-02 15:04:05.999999"
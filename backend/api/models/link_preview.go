@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// LinkPreview is what the page a message links to says about itself.
+// ImageURL isn't fetched by the server, clients load it themselves.
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+	SiteName    string `json:"siteName,omitempty"`
+}
+
+// ILinkPreviewStorer caches previews by the link they were made for.
+type ILinkPreviewStorer interface {
+	Get(url string, maxAge, failedMaxAge time.Duration) (*LinkPreview, error)
+	Save(url string, preview *LinkPreview) error
+	DeleteStale(maxAge time.Duration) (int64, error)
+}
+
+type LinkPreviewStorer struct {
+	DB *sql.DB
+}
+
+func NewLinkPreviewStorer(db *sql.DB) LinkPreviewStorer {
+	return LinkPreviewStorer{DB: db}
+}
+
+// Get returns the cached preview of url fetched less than maxAge ago, nil when
+// the link failed to be previewed less than failedMaxAge ago. sql.ErrNoRows is
+// returned when the link has to be fetched.
+func (ls LinkPreviewStorer) Get(url string, maxAge, failedMaxAge time.Duration) (*LinkPreview, error) {
+	var data []byte
+	query := `SELECT preview FROM link_previews WHERE url = $1
+		AND fetched_at > now() - CASE WHEN preview IS NULL THEN $3 ELSE $2 END * interval '1 second'`
+	err := ls.DB.QueryRow(query, url, maxAge.Seconds(), failedMaxAge.Seconds()).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	return parsePreview(data)
+}
+
+// Save caches the preview of url, a nil one records that it can't be previewed.
+func (ls LinkPreviewStorer) Save(url string, preview *LinkPreview) error {
+	data, err := previewJSON(preview)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO link_previews (url, preview) VALUES ($1, $2)
+		ON CONFLICT (url) DO UPDATE SET preview = EXCLUDED.preview, fetched_at = now()`
+	_, err = ls.DB.Exec(query, url, data)
+	return err
+}
+
+func (ls LinkPreviewStorer) DeleteStale(maxAge time.Duration) (int64, error) {
+	query := "DELETE FROM link_previews WHERE fetched_at < now() - $1 * interval '1 second'"
+	result, err := ls.DB.Exec(query, maxAge.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// previewJSON encodes preview for a jsonb column, nil as NULL.
+func previewJSON(preview *LinkPreview) (sql.NullString, error) {
+	if preview == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(preview)
+	return sql.NullString{String: string(data), Valid: true}, err
+}
+
+func parsePreview(data []byte) (*LinkPreview, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var preview LinkPreview
+	err := json.Unmarshal(data, &preview)
+	return &preview, err
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Mention marks an @tag in message content. Offset and Length are in UTF-16
+// code units, the way clients index strings. All is set for @all mentions,
+// which address every participant of the chat and have no UserId.
+type Mention struct {
+	UserId int  `json:"userId,omitempty"`
+	All    bool `json:"all,omitempty"`
+	Offset int  `json:"offset"`
+	Length int  `json:"length"`
+}
+
+type IMentionStorer interface {
+	CreateInTx(tx *sql.Tx, messageId int, mentions []Mention) error
+	DeleteInTx(tx *sql.Tx, messageId int) error
+	GetForMessages(messageIds []int) (map[int][]Mention, error)
+}
+
+type MentionStorer struct {
+	DB *sql.DB
+}
+
+func NewMentionStorer(db *sql.DB) MentionStorer {
+	return MentionStorer{DB: db}
+}
+
+func (ms MentionStorer) CreateInTx(tx *sql.Tx, messageId int, mentions []Mention) error {
+	query := `INSERT INTO mentions (message_id, user_id, "position", length) VALUES ($1, $2, $3, $4)`
+	for _, mention := range mentions {
+		userId := sql.NullInt64{Int64: int64(mention.UserId), Valid: !mention.All}
+		_, err := tx.Exec(query, messageId, userId, mention.Offset, mention.Length)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms MentionStorer) DeleteInTx(tx *sql.Tx, messageId int) error {
+	query := "DELETE FROM mentions WHERE message_id = $1"
+	_, err := tx.Exec(query, messageId)
+	return err
+}
+
+func (ms MentionStorer) GetForMessages(messageIds []int) (map[int][]Mention, error) {
+	mentions := make(map[int][]Mention)
+	query := `SELECT message_id, user_id, "position", length FROM mentions WHERE message_id = ANY($1) ORDER BY "position"`
+	rows, err := ms.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageId int
+		var userId sql.NullInt64
+		var mention Mention
+		err := rows.Scan(&messageId, &userId, &mention.Offset, &mention.Length)
+		if err != nil {
+			return nil, err
+		}
+		mention.UserId = int(userId.Int64)
+		mention.All = !userId.Valid
+		mentions[messageId] = append(mentions[messageId], mention)
+	}
+	return mentions, rows.Err()
+}
@@ -1,40 +1,226 @@
 package models
 
-import "database/sql"
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
 
 type Message struct {
-	Id        int    `json:"id"`
-	SenderId  int    `json:"senderId"`
-	ChatId    int    `json:"chatId"`
-	Type      string `json:"type"`
-	Content   string `json:"content"`
-	CreatedAt string `json:"createdAt"`
+	Id          int               `json:"id"`
+	SenderId    int               `json:"senderId"`
+	ChatId      int               `json:"chatId"`
+	Type        string            `json:"type"`
+	Content     string            `json:"content"`
+	CreatedAt   string            `json:"createdAt"`
+	ParentId    int               `json:"parentId,omitempty"`
+	EditedAt    string            `json:"editedAt,omitempty"`
+	DeletedAt   string            `json:"deletedAt,omitempty"`
+	DeletedBy   int               `json:"deletedBy,omitempty"`
+	ExpiresAt   string            `json:"expiresAt,omitempty"`
+	Forwarded   *Forward          `json:"forwarded,omitempty"`
+	Format      string            `json:"format,omitempty"`
+	Entities    []Entity          `json:"entities"`
+	Preview     *LinkPreview      `json:"preview,omitempty"`
+	Poll        *Poll             `json:"poll,omitempty"`
+	Mentions    []Mention         `json:"mentions"`
+	Reactions   []ReactionSummary `json:"reactions"`
+	Attachments []Attachment      `json:"attachments"`
+	ListenedBy  []int             `json:"listenedBy,omitempty"`
+	Thread      *Thread           `json:"thread,omitempty"`
+}
+
+// ListenEvent tells that UserId listened to the audio message MessageId.
+type ListenEvent struct {
+	MessageId int `json:"messageId"`
+	ChatId    int `json:"chatId"`
+	UserId    int `json:"userId"`
+}
+
+// Forward points to the original of a forwarded message. Ids are 0 once
+// the original message, its sender or chat are gone.
+type Forward struct {
+	MessageId int `json:"messageId"`
+	SenderId  int `json:"senderId"`
+	ChatId    int `json:"chatId"`
+}
+
+type ForwardFromRequest struct {
+	ChatId     int   `json:"chatId"`
+	MessageIds []int `json:"messageIds"`
+}
+
+// Thread summarizes replies to a root message.
+type Thread struct {
+	ReplyCount        int    `json:"replyCount"`
+	LastReplyId       int    `json:"lastReplyId"`
+	LastReplySenderId int    `json:"lastReplySenderId"`
+	LastReplyAt       string `json:"lastReplyAt"`
+}
+
+type ThreadView struct {
+	Root    Message   `json:"root"`
+	Replies []Message `json:"replies"`
 }
 
 type MessageDTO struct {
-	SenderId int    `json:"senderId"`
-	ChatId   int    `json:"chatId"`
-	Type     string `json:"type"`
-	Content  string `json:"content"`
+	SenderId  int      `json:"senderId"`
+	ChatId    int      `json:"chatId"`
+	Type      string   `json:"type"`
+	Content   string   `json:"content"`
+	ParentId  int      `json:"parentId"`
+	Forwarded *Forward `json:"forwarded,omitempty"`
+	Entities  []Entity `json:"entities,omitempty"`
 }
 
+// MessageFromRequest is a message to send. Content is plain text unless
+// Format is "markdown", in which case it is rendered into text and entities.
+// Messages of type "poll" have a Poll, their question is their content.
 type MessageFromRequest struct {
-	ChatId  int    `json:"chatId"`
-	Type    string `json:"type"`
-	Content string `json:"content"`
+	ChatId        int              `json:"chatId"`
+	Type          string           `json:"type"`
+	Content       string           `json:"content"`
+	Format        string           `json:"format"`
+	ParentId      int              `json:"parentId"`
+	AttachmentIds []int            `json:"attachmentIds"`
+	Poll          *PollFromRequest `json:"poll"`
+}
+
+// HistoryQuery selects a page of chat history. At most one of Cursor, Before,
+// After and Around is set, none selects the latest messages.
+type HistoryQuery struct {
+	ChatId int
+	Cursor string
+	Before int
+	After  int
+	Around int
+	Limit  int
+}
+
+// MessagePage is a page of chat history, newest first. NextCursor points to older
+// messages and PrevCursor to newer ones, each is empty when there are none.
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+	PrevCursor string    `json:"prevCursor,omitempty"`
+}
+
+type MessageDeleteFromRequest struct {
+	Id          int  `json:"id"`
+	ForEveryone bool `json:"forEveryone"`
+}
+
+type SystemContent struct {
+	Event string `json:"event"`
+	Data  any    `json:"data"`
 }
 
 type IMessageStorer interface {
+	Begin() (*sql.Tx, error)
 	Create(tdo MessageDTO) (*Message, error)
+	CreateInTx(tx *sql.Tx, tdo MessageDTO) (*Message, error)
 	GetOne(id int) (*Message, error)
-	GetChatMessages(chatId, page int) ([]Message, error)
-	Update(message Message) (*Message, error)
-	Delete(id int) (*Message, error)
+	GetMany(ids []int) ([]Message, error)
+	GetChatMessagesBefore(userId, chatId, beforeId, limit int) ([]Message, error)
+	GetChatMessagesAfter(userId, chatId, afterId, limit int) ([]Message, error)
+	GetChatHistory(chatId, afterId, limit int) ([]Message, error)
+	CountChatMessages(chatId int) (int, error)
+	GetUserMentions(userId, page int) ([]Message, error)
+	GetReplies(userId, parentId, page int) ([]Message, error)
+	GetThreads(parentIds []int) (map[int]Thread, error)
+	UpdateInTx(tx *sql.Tx, message Message, editWindow int) (*Message, error)
+	SetPreview(messageId int, content string, preview LinkPreview) (bool, error)
+	Delete(id, deletedBy, deleteWindow int) (*Message, error)
+	DeleteExpired(limit int) ([]Message, error)
+	Hide(userId, messageId int) error
+	MarkListened(userId, messageId int) (bool, error)
+	GetListeners(messageIds []int) (map[int][]int, error)
+	Search(userId int, query SearchQuery) ([]SearchResult, error)
 	DeleteAll(chatId int) (sql.Result, error)
 }
 
 const PAGE_SIZE = 50
 
+const messageColumns = "id, sender_id, chat_id, type, content, created_at, parent_id, edited_at, deleted_at, deleted_by, expires_at, forwarded_from_id, forwarded_sender_id, forwarded_chat_id, entities, preview"
+
+// messageExpiry is when a new message of type $3 in chat $2 expires by the settings of
+// the chat, NULL when it doesn't. System messages stay so the chat history makes sense.
+const messageExpiry = `(SELECT now() + message_ttl * interval '1 second' FROM chat_settings
+	WHERE chat_id = $2 AND message_ttl > 0 AND $3::message_type <> 'system')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMessage(row rowScanner) (*Message, error) {
+	var message Message
+	var parentId sql.NullInt64
+	var editedAt, deletedAt, expiresAt sql.NullString
+	var deletedBy sql.NullInt64
+	var forwardedFromId, forwardedSenderId, forwardedChatId sql.NullInt64
+	var entities, preview []byte
+	err := row.Scan(
+		&message.Id,
+		&message.SenderId,
+		&message.ChatId,
+		&message.Type,
+		&message.Content,
+		&message.CreatedAt,
+		&parentId,
+		&editedAt,
+		&deletedAt,
+		&deletedBy,
+		&expiresAt,
+		&forwardedFromId,
+		&forwardedSenderId,
+		&forwardedChatId,
+		&entities,
+		&preview,
+	)
+	if err != nil {
+		return nil, err
+	}
+	message.Entities, err = parseEntities(entities)
+	if err != nil {
+		return nil, err
+	}
+	message.Preview, err = parsePreview(preview)
+	if err != nil {
+		return nil, err
+	}
+	message.ParentId = int(parentId.Int64)
+	message.EditedAt = editedAt.String
+	message.DeletedAt = deletedAt.String
+	message.DeletedBy = int(deletedBy.Int64)
+	message.ExpiresAt = expiresAt.String
+	if forwardedFromId.Valid || forwardedSenderId.Valid || forwardedChatId.Valid {
+		message.Forwarded = &Forward{
+			MessageId: int(forwardedFromId.Int64),
+			SenderId:  int(forwardedSenderId.Int64),
+			ChatId:    int(forwardedChatId.Int64),
+		}
+	}
+	return &message, nil
+}
+
+// nullableId maps the zero id to NULL.
+func nullableId(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+func scanMessages(rows *sql.Rows) ([]Message, error) {
+	defer rows.Close()
+	messages := make([]Message, 0)
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+	return messages, rows.Err()
+}
+
 type MessageStorer struct {
 	DB *sql.DB
 }
@@ -43,66 +229,294 @@ func NewMessageStorer(db *sql.DB) MessageStorer {
 	return MessageStorer{DB: db}
 }
 
+func (cs MessageStorer) Begin() (*sql.Tx, error) {
+	return cs.DB.Begin()
+}
+
+const insertMessage = `INSERT INTO messages
+	(sender_id, chat_id, type, content, parent_id, forwarded_from_id, forwarded_sender_id, forwarded_chat_id, entities, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, ` + messageExpiry + ") RETURNING " + messageColumns
+
+func insertMessageArgs(tdo MessageDTO) ([]any, error) {
+	forwarded := Forward{}
+	if tdo.Forwarded != nil {
+		forwarded = *tdo.Forwarded
+	}
+	entities, err := entitiesJSON(tdo.Entities)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		tdo.SenderId,
+		tdo.ChatId,
+		tdo.Type,
+		tdo.Content,
+		nullableId(tdo.ParentId),
+		nullableId(forwarded.MessageId),
+		nullableId(forwarded.SenderId),
+		nullableId(forwarded.ChatId),
+		entities,
+	}, nil
+}
+
 func (cs MessageStorer) Create(tdo MessageDTO) (*Message, error) {
-	var message Message
-	query := "INSERT INTO messages (sender_id, chat_id, type, content) VALUES ($1, $2, $3, $4) RETURNING *"
-	row := cs.DB.QueryRow(query, tdo.SenderId, tdo.ChatId, tdo.Type, tdo.Content)
-	err := row.Scan(&message.Id, &message.SenderId, &message.ChatId, &message.Type, &message.Content, &message.CreatedAt)
+	args, err := insertMessageArgs(tdo)
 	if err != nil {
 		return nil, err
 	}
-	return &message, nil
+	row := cs.DB.QueryRow(insertMessage, args...)
+	return scanMessage(row)
+}
+
+func (cs MessageStorer) CreateInTx(tx *sql.Tx, tdo MessageDTO) (*Message, error) {
+	args, err := insertMessageArgs(tdo)
+	if err != nil {
+		return nil, err
+	}
+	row := tx.QueryRow(insertMessage, args...)
+	return scanMessage(row)
 }
 
 func (cs MessageStorer) GetOne(id int) (*Message, error) {
-	var message Message
-	query := "SELECT * FROM messages WHERE id = $1"
+	query := "SELECT " + messageColumns + " FROM messages WHERE id = $1"
 	row := cs.DB.QueryRow(query, id)
-	err := row.Scan(&message.Id, &message.SenderId, &message.ChatId, &message.Type, &message.Content)
+	return scanMessage(row)
+}
+
+// GetMany returns the messages with ids that exist in no particular order.
+func (cs MessageStorer) GetMany(ids []int) ([]Message, error) {
+	query := "SELECT " + messageColumns + " FROM messages WHERE id = ANY($1)"
+	rows, err := cs.DB.Query(query, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
-	return &message, nil
+	return scanMessages(rows)
 }
 
-func (cs MessageStorer) GetChatMessages(chatId, page int) ([]Message, error) {
-	messages := make([]Message, 0)
-	query := "SELECT * FROM messages WHERE chat_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3"
-	rows, err := cs.DB.Query(query, chatId, PAGE_SIZE, page*PAGE_SIZE)
+// GetChatMessagesBefore returns up to limit messages of the main flow of a chat sent
+// before beforeId, the latest ones when beforeId is 0, newest first. Thread replies
+// and messages userId deleted for themselves are left out.
+func (cs MessageStorer) GetChatMessagesBefore(userId, chatId, beforeId, limit int) ([]Message, error) {
+	query := "SELECT " + messageColumns + ` FROM messages
+		WHERE chat_id = $1 AND parent_id IS NULL
+		AND id NOT IN (SELECT message_id FROM hidden_messages WHERE user_id = $2)
+		AND ($3::integer = 0 OR (created_at, id) < (SELECT created_at, id FROM messages WHERE id = $3))
+		ORDER BY created_at DESC, id DESC LIMIT $4`
+	rows, err := cs.DB.Query(query, chatId, userId, beforeId, limit)
 	if err != nil {
 		return nil, err
 	}
+	return scanMessages(rows)
+}
+
+// GetChatMessagesAfter is GetChatMessagesBefore for messages sent after afterId,
+// the ones sent right after it are returned, still newest first.
+func (cs MessageStorer) GetChatMessagesAfter(userId, chatId, afterId, limit int) ([]Message, error) {
+	query := "SELECT " + messageColumns + ` FROM (
+			SELECT * FROM messages
+			WHERE chat_id = $1 AND parent_id IS NULL
+			AND id NOT IN (SELECT message_id FROM hidden_messages WHERE user_id = $2)
+			AND (created_at, id) > (SELECT created_at, id FROM messages WHERE id = $3)
+			ORDER BY created_at, id LIMIT $4
+		) AS page
+		ORDER BY created_at DESC, id DESC`
+	rows, err := cs.DB.Query(query, chatId, userId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+// GetChatHistory returns up to limit messages of a chat sent after afterId, from the
+// first one when it is 0, oldest first. Thread replies and deleted messages are
+// included and nothing is hidden, this is the history as the chat has it.
+func (cs MessageStorer) GetChatHistory(chatId, afterId, limit int) ([]Message, error) {
+	query := "SELECT " + messageColumns + ` FROM messages
+		WHERE chat_id = $1
+		AND ($2::integer = 0 OR (created_at, id) > (SELECT created_at, id FROM messages WHERE id = $2))
+		ORDER BY created_at, id LIMIT $3`
+	rows, err := cs.DB.Query(query, chatId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+func (cs MessageStorer) CountChatMessages(chatId int) (int, error) {
+	var count int
+	err := cs.DB.QueryRow("SELECT count(*) FROM messages WHERE chat_id = $1", chatId).Scan(&count)
+	return count, err
+}
+
+// GetUserMentions returns messages of other users that mention userId,
+// directly or with @all, in chats userId still participates in, leaving
+// out the ones userId deleted for themselves.
+func (cs MessageStorer) GetUserMentions(userId, page int) ([]Message, error) {
+	query := "SELECT " + messageColumns + ` FROM messages
+		WHERE sender_id <> $1
+		AND chat_id IN (SELECT chat_id FROM participants WHERE user_id = $1)
+		AND id IN (SELECT message_id FROM mentions WHERE user_id = $1 OR user_id IS NULL)
+		AND id NOT IN (SELECT message_id FROM hidden_messages WHERE user_id = $1)
+		ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+	rows, err := cs.DB.Query(query, userId, PAGE_SIZE, page*PAGE_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+// GetReplies returns replies to parentId in the order they were sent,
+// leaving out the ones userId deleted for themselves.
+func (cs MessageStorer) GetReplies(userId, parentId, page int) ([]Message, error) {
+	query := "SELECT " + messageColumns + ` FROM messages
+		WHERE parent_id = $1
+		AND id NOT IN (SELECT message_id FROM hidden_messages WHERE user_id = $2)
+		ORDER BY created_at, id LIMIT $3 OFFSET $4`
+	rows, err := cs.DB.Query(query, parentId, userId, PAGE_SIZE, page*PAGE_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+// GetThreads summarizes replies of every message in parentIds that has any.
+func (cs MessageStorer) GetThreads(parentIds []int) (map[int]Thread, error) {
+	threads := make(map[int]Thread)
+	query := `SELECT DISTINCT ON (parent_id) parent_id, count(*) OVER (PARTITION BY parent_id), id, sender_id, created_at
+		FROM messages WHERE parent_id = ANY($1)
+		ORDER BY parent_id, created_at DESC, id DESC`
+	rows, err := cs.DB.Query(query, pq.Array(parentIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 	for rows.Next() {
-		var message Message
-		err := rows.Scan(&message.Id, &message.SenderId, &message.ChatId, &message.Type, &message.Content)
+		var parentId int
+		var thread Thread
+		err := rows.Scan(&parentId, &thread.ReplyCount, &thread.LastReplyId, &thread.LastReplySenderId, &thread.LastReplyAt)
 		if err != nil {
 			return nil, err
 		}
-		messages = append(messages, message)
+		threads[parentId] = thread
 	}
-	return messages, nil
+	return threads, rows.Err()
 }
 
-func (cs MessageStorer) Update(message Message) (*Message, error) {
-	var updMessage Message
-	query := "UPDATE messages SET content=$1 WHERE id=$2 RETURNING *"
-	row := cs.DB.QueryRow(query, message.Content, message.Id)
-	err := row.Scan(&updMessage.Id, &updMessage.SenderId, &updMessage.ChatId, &updMessage.Type, &updMessage.Content)
+// UpdateInTx changes the content and entities of a message sent less than editWindow seconds ago,
+// any message when editWindow is 0, and drops its link preview. sql.ErrNoRows is returned for
+// messages out of the window.
+func (cs MessageStorer) UpdateInTx(tx *sql.Tx, message Message, editWindow int) (*Message, error) {
+	entities, err := entitiesJSON(message.Entities)
 	if err != nil {
 		return nil, err
 	}
-	return &updMessage, nil
+	query := `UPDATE messages SET content = $1, entities = $4, preview = NULL, edited_at = now()
+		WHERE id = $2 AND ($3::integer = 0 OR created_at > now() - $3::integer * interval '1 second')
+		RETURNING ` + messageColumns
+	row := tx.QueryRow(query, message.Content, message.Id, editWindow, entities)
+	return scanMessage(row)
 }
 
-func (cs MessageStorer) Delete(id int) (*Message, error) {
-	var message Message
-	query := "DELETE FROM messages WHERE id = $1"
-	row := cs.DB.QueryRow(query, id)
-	err := row.Scan(&message.Id, &message.SenderId, &message.ChatId, &message.Type, &message.Content)
+// SetPreview attaches a link preview to a message that still has content and isn't
+// deleted, it reports false when the message has changed since.
+func (cs MessageStorer) SetPreview(messageId int, content string, preview LinkPreview) (bool, error) {
+	data, err := previewJSON(&preview)
+	if err != nil {
+		return false, err
+	}
+	query := "UPDATE messages SET preview = $3 WHERE id = $1 AND content = $2 AND deleted_at IS NULL"
+	result, err := cs.DB.Exec(query, messageId, content, data)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
+
+// Delete turns a message sent less than deleteWindow seconds ago, any message when
+// deleteWindow is 0, into a tombstone. Its content, mentions, reactions, revisions, listens,
+// pins and poll are dropped while the row keeps its place in history. sql.ErrNoRows is
+// returned for messages that are already deleted or out of the window.
+func (cs MessageStorer) Delete(id, deletedBy, deleteWindow int) (*Message, error) {
+	query := `WITH deleted AS (
+			UPDATE messages SET content = '', entities = '[]', preview = NULL, deleted_at = now(), deleted_by = $2
+			WHERE id = $1 AND deleted_at IS NULL
+			AND ($3::integer = 0 OR created_at > now() - $3::integer * interval '1 second')
+			RETURNING ` + messageColumns + `
+		),
+		mentions AS (DELETE FROM mentions WHERE message_id IN (SELECT id FROM deleted)),
+		reactions AS (DELETE FROM reactions WHERE message_id IN (SELECT id FROM deleted)),
+		revisions AS (DELETE FROM message_revisions WHERE message_id IN (SELECT id FROM deleted)),
+		listens AS (DELETE FROM message_listens WHERE message_id IN (SELECT id FROM deleted)),
+		pins AS (DELETE FROM pinned_messages WHERE message_id IN (SELECT id FROM deleted)),
+		polls AS (DELETE FROM polls WHERE message_id IN (SELECT id FROM deleted))
+		SELECT ` + messageColumns + " FROM deleted"
+	row := cs.DB.QueryRow(query, id, deletedBy, deleteWindow)
+	return scanMessage(row)
+}
+
+// DeleteExpired turns up to limit messages that outlived the TTL of their chat into
+// tombstones like Delete does, dropping who listened to them and their pins too.
+// Rows locked by another instance are skipped so each message is returned once.
+func (cs MessageStorer) DeleteExpired(limit int) ([]Message, error) {
+	query := `WITH deleted AS (
+			UPDATE messages SET content = '', entities = '[]', preview = NULL, deleted_at = now()
+			WHERE id IN (
+				SELECT id FROM messages WHERE expires_at <= now() AND deleted_at IS NULL
+				ORDER BY expires_at LIMIT $1 FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + messageColumns + `
+		),
+		mentions AS (DELETE FROM mentions WHERE message_id IN (SELECT id FROM deleted)),
+		reactions AS (DELETE FROM reactions WHERE message_id IN (SELECT id FROM deleted)),
+		revisions AS (DELETE FROM message_revisions WHERE message_id IN (SELECT id FROM deleted)),
+		listens AS (DELETE FROM message_listens WHERE message_id IN (SELECT id FROM deleted)),
+		pins AS (DELETE FROM pinned_messages WHERE message_id IN (SELECT id FROM deleted)),
+		polls AS (DELETE FROM polls WHERE message_id IN (SELECT id FROM deleted))
+		SELECT ` + messageColumns + " FROM deleted"
+	rows, err := cs.DB.Query(query, limit)
 	if err != nil {
 		return nil, err
 	}
-	return &message, nil
+	return scanMessages(rows)
+}
+
+// Hide deletes a message for userId only.
+func (cs MessageStorer) Hide(userId, messageId int) error {
+	query := "INSERT INTO hidden_messages (user_id, message_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	_, err := cs.DB.Exec(query, userId, messageId)
+	return err
+}
+
+// MarkListened records that userId listened to an audio message,
+// it reports false when they had already.
+func (cs MessageStorer) MarkListened(userId, messageId int) (bool, error) {
+	query := "INSERT INTO message_listens (message_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	result, err := cs.DB.Exec(query, messageId, userId)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	return inserted > 0, err
+}
+
+// GetListeners returns who listened to each of messageIds in the order they did.
+func (cs MessageStorer) GetListeners(messageIds []int) (map[int][]int, error) {
+	listeners := make(map[int][]int)
+	query := "SELECT message_id, user_id FROM message_listens WHERE message_id = ANY($1) ORDER BY listened_at"
+	rows, err := cs.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageId, userId int
+		if err := rows.Scan(&messageId, &userId); err != nil {
+			return nil, err
+		}
+		listeners[messageId] = append(listeners[messageId], userId)
+	}
+	return listeners, rows.Err()
 }
 
 func (cs MessageStorer) DeleteAll(chatId int) (sql.Result, error) {
@@ -0,0 +1,186 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/attachment.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sql "database/sql"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIAttachmentStorer is a mock of IAttachmentStorer interface.
+type MockIAttachmentStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAttachmentStorerMockRecorder
+}
+
+// MockIAttachmentStorerMockRecorder is the mock recorder for MockIAttachmentStorer.
+type MockIAttachmentStorerMockRecorder struct {
+	mock *MockIAttachmentStorer
+}
+
+// NewMockIAttachmentStorer creates a new mock instance.
+func NewMockIAttachmentStorer(ctrl *gomock.Controller) *MockIAttachmentStorer {
+	mock := &MockIAttachmentStorer{ctrl: ctrl}
+	mock.recorder = &MockIAttachmentStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAttachmentStorer) EXPECT() *MockIAttachmentStorerMockRecorder {
+	return m.recorder
+}
+
+// AttachInTx mocks base method.
+func (m *MockIAttachmentStorer) AttachInTx(tx *sql.Tx, ids []int, messageId, uploaderId, chatId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachInTx", tx, ids, messageId, uploaderId, chatId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttachInTx indicates an expected call of AttachInTx.
+func (mr *MockIAttachmentStorerMockRecorder) AttachInTx(tx, ids, messageId, uploaderId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachInTx", reflect.TypeOf((*MockIAttachmentStorer)(nil).AttachInTx), tx, ids, messageId, uploaderId, chatId)
+}
+
+// ClaimForProcessing mocks base method.
+func (m *MockIAttachmentStorer) ClaimForProcessing(id int, lease time.Duration) (*models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimForProcessing", id, lease)
+	ret0, _ := ret[0].(*models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimForProcessing indicates an expected call of ClaimForProcessing.
+func (mr *MockIAttachmentStorerMockRecorder) ClaimForProcessing(id, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimForProcessing", reflect.TypeOf((*MockIAttachmentStorer)(nil).ClaimForProcessing), id, lease)
+}
+
+// CopyInTx mocks base method.
+func (m *MockIAttachmentStorer) CopyInTx(tx *sql.Tx, fromMessageId, toMessageId, uploaderId, chatId int) ([]models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyInTx", tx, fromMessageId, toMessageId, uploaderId, chatId)
+	ret0, _ := ret[0].([]models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CopyInTx indicates an expected call of CopyInTx.
+func (mr *MockIAttachmentStorerMockRecorder) CopyInTx(tx, fromMessageId, toMessageId, uploaderId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyInTx", reflect.TypeOf((*MockIAttachmentStorer)(nil).CopyInTx), tx, fromMessageId, toMessageId, uploaderId, chatId)
+}
+
+// Create mocks base method.
+func (m *MockIAttachmentStorer) Create(dto models.AttachmentDTO) (*models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", dto)
+	ret0, _ := ret[0].(*models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIAttachmentStorerMockRecorder) Create(dto interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIAttachmentStorer)(nil).Create), dto)
+}
+
+// CreateInTx mocks base method.
+func (m *MockIAttachmentStorer) CreateInTx(tx *sql.Tx, dto models.AttachmentDTO) (*models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInTx", tx, dto)
+	ret0, _ := ret[0].(*models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInTx indicates an expected call of CreateInTx.
+func (mr *MockIAttachmentStorerMockRecorder) CreateInTx(tx, dto interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInTx", reflect.TypeOf((*MockIAttachmentStorer)(nil).CreateInTx), tx, dto)
+}
+
+// DeleteForMessages mocks base method.
+func (m *MockIAttachmentStorer) DeleteForMessages(messageIds []int) ([]models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteForMessages", messageIds)
+	ret0, _ := ret[0].([]models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteForMessages indicates an expected call of DeleteForMessages.
+func (mr *MockIAttachmentStorerMockRecorder) DeleteForMessages(messageIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteForMessages", reflect.TypeOf((*MockIAttachmentStorer)(nil).DeleteForMessages), messageIds)
+}
+
+// GetForMessages mocks base method.
+func (m *MockIAttachmentStorer) GetForMessages(messageIds []int) (map[int][]models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForMessages", messageIds)
+	ret0, _ := ret[0].(map[int][]models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForMessages indicates an expected call of GetForMessages.
+func (mr *MockIAttachmentStorerMockRecorder) GetForMessages(messageIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForMessages", reflect.TypeOf((*MockIAttachmentStorer)(nil).GetForMessages), messageIds)
+}
+
+// GetOne mocks base method.
+func (m *MockIAttachmentStorer) GetOne(id int) (*models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", id)
+	ret0, _ := ret[0].(*models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIAttachmentStorerMockRecorder) GetOne(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIAttachmentStorer)(nil).GetOne), id)
+}
+
+// GetUnprocessed mocks base method.
+func (m *MockIAttachmentStorer) GetUnprocessed(limit int) ([]models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnprocessed", limit)
+	ret0, _ := ret[0].([]models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnprocessed indicates an expected call of GetUnprocessed.
+func (mr *MockIAttachmentStorerMockRecorder) GetUnprocessed(limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnprocessed", reflect.TypeOf((*MockIAttachmentStorer)(nil).GetUnprocessed), limit)
+}
+
+// SetProcessed mocks base method.
+func (m *MockIAttachmentStorer) SetProcessed(id int, image models.ProcessedImage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProcessed", id, image)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProcessed indicates an expected call of SetProcessed.
+func (mr *MockIAttachmentStorerMockRecorder) SetProcessed(id, image interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProcessed", reflect.TypeOf((*MockIAttachmentStorer)(nil).SetProcessed), id, image)
+}
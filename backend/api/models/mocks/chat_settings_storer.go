@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/chat_settings.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIChatSettingsStorer is a mock of IChatSettingsStorer interface.
+type MockIChatSettingsStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIChatSettingsStorerMockRecorder
+}
+
+// MockIChatSettingsStorerMockRecorder is the mock recorder for MockIChatSettingsStorer.
+type MockIChatSettingsStorerMockRecorder struct {
+	mock *MockIChatSettingsStorer
+}
+
+// NewMockIChatSettingsStorer creates a new mock instance.
+func NewMockIChatSettingsStorer(ctrl *gomock.Controller) *MockIChatSettingsStorer {
+	mock := &MockIChatSettingsStorer{ctrl: ctrl}
+	mock.recorder = &MockIChatSettingsStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIChatSettingsStorer) EXPECT() *MockIChatSettingsStorerMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockIChatSettingsStorer) Get(chatId int) (*models.ChatSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", chatId)
+	ret0, _ := ret[0].(*models.ChatSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIChatSettingsStorerMockRecorder) Get(chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIChatSettingsStorer)(nil).Get), chatId)
+}
+
+// Upsert mocks base method.
+func (m *MockIChatSettingsStorer) Upsert(settings models.ChatSettings) (*models.ChatSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", settings)
+	ret0, _ := ret[0].(*models.ChatSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockIChatSettingsStorerMockRecorder) Upsert(settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockIChatSettingsStorer)(nil).Upsert), settings)
+}
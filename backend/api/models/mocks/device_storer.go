@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/device.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIDeviceStorer is a mock of IDeviceStorer interface.
+type MockIDeviceStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDeviceStorerMockRecorder
+}
+
+// MockIDeviceStorerMockRecorder is the mock recorder for MockIDeviceStorer.
+type MockIDeviceStorerMockRecorder struct {
+	mock *MockIDeviceStorer
+}
+
+// NewMockIDeviceStorer creates a new mock instance.
+func NewMockIDeviceStorer(ctrl *gomock.Controller) *MockIDeviceStorer {
+	mock := &MockIDeviceStorer{ctrl: ctrl}
+	mock.recorder = &MockIDeviceStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDeviceStorer) EXPECT() *MockIDeviceStorerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIDeviceStorer) Create(dto models.DeviceDTO) (*models.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", dto)
+	ret0, _ := ret[0].(*models.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIDeviceStorerMockRecorder) Create(dto interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIDeviceStorer)(nil).Create), dto)
+}
+
+// Delete mocks base method.
+func (m *MockIDeviceStorer) Delete(id int) (*models.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(*models.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIDeviceStorerMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIDeviceStorer)(nil).Delete), id)
+}
+
+// GetUserDevices mocks base method.
+func (m *MockIDeviceStorer) GetUserDevices(userId int) ([]models.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDevices", userId)
+	ret0, _ := ret[0].([]models.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDevices indicates an expected call of GetUserDevices.
+func (mr *MockIDeviceStorerMockRecorder) GetUserDevices(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDevices", reflect.TypeOf((*MockIDeviceStorer)(nil).GetUserDevices), userId)
+}
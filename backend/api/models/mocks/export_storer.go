@@ -0,0 +1,183 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/export.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIExportStorer is a mock of IExportStorer interface.
+type MockIExportStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIExportStorerMockRecorder
+}
+
+// MockIExportStorerMockRecorder is the mock recorder for MockIExportStorer.
+type MockIExportStorerMockRecorder struct {
+	mock *MockIExportStorer
+}
+
+// NewMockIExportStorer creates a new mock instance.
+func NewMockIExportStorer(ctrl *gomock.Controller) *MockIExportStorer {
+	mock := &MockIExportStorer{ctrl: ctrl}
+	mock.recorder = &MockIExportStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIExportStorer) EXPECT() *MockIExportStorerMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockIExportStorer) Claim(lease time.Duration) (*models.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", lease)
+	ret0, _ := ret[0].(*models.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockIExportStorerMockRecorder) Claim(lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockIExportStorer)(nil).Claim), lease)
+}
+
+// CountPending mocks base method.
+func (m *MockIExportStorer) CountPending(requestedBy int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPending", requestedBy)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPending indicates an expected call of CountPending.
+func (mr *MockIExportStorerMockRecorder) CountPending(requestedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPending", reflect.TypeOf((*MockIExportStorer)(nil).CountPending), requestedBy)
+}
+
+// Create mocks base method.
+func (m *MockIExportStorer) Create(chatId, requestedBy int, format string) (*models.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", chatId, requestedBy, format)
+	ret0, _ := ret[0].(*models.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIExportStorerMockRecorder) Create(chatId, requestedBy, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIExportStorer)(nil).Create), chatId, requestedBy, format)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockIExportStorer) DeleteExpired() ([]models.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired")
+	ret0, _ := ret[0].([]models.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIExportStorerMockRecorder) DeleteExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIExportStorer)(nil).DeleteExpired))
+}
+
+// Fail mocks base method.
+func (m *MockIExportStorer) Fail(id int, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockIExportStorerMockRecorder) Fail(id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockIExportStorer)(nil).Fail), id, reason)
+}
+
+// Finish mocks base method.
+func (m *MockIExportStorer) Finish(id int, key string, size int64, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", id, key, size, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockIExportStorerMockRecorder) Finish(id, key, size, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockIExportStorer)(nil).Finish), id, key, size, ttl)
+}
+
+// GetForChat mocks base method.
+func (m *MockIExportStorer) GetForChat(chatId, requestedBy int) ([]models.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForChat", chatId, requestedBy)
+	ret0, _ := ret[0].([]models.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForChat indicates an expected call of GetForChat.
+func (mr *MockIExportStorerMockRecorder) GetForChat(chatId, requestedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForChat", reflect.TypeOf((*MockIExportStorer)(nil).GetForChat), chatId, requestedBy)
+}
+
+// GetOne mocks base method.
+func (m *MockIExportStorer) GetOne(id int) (*models.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", id)
+	ret0, _ := ret[0].(*models.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIExportStorerMockRecorder) GetOne(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIExportStorer)(nil).GetOne), id)
+}
+
+// GetSenders mocks base method.
+func (m *MockIExportStorer) GetSenders(chatId int) ([]models.ExportUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSenders", chatId)
+	ret0, _ := ret[0].([]models.ExportUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSenders indicates an expected call of GetSenders.
+func (mr *MockIExportStorerMockRecorder) GetSenders(chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSenders", reflect.TypeOf((*MockIExportStorer)(nil).GetSenders), chatId)
+}
+
+// SetProgress mocks base method.
+func (m *MockIExportStorer) SetProgress(id, messages int, lease time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProgress", id, messages, lease)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProgress indicates an expected call of SetProgress.
+func (mr *MockIExportStorerMockRecorder) SetProgress(id, messages, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProgress", reflect.TypeOf((*MockIExportStorer)(nil).SetProgress), id, messages, lease)
+}
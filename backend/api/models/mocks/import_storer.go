@@ -0,0 +1,199 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/import.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sql "database/sql"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIImportStorer is a mock of IImportStorer interface.
+type MockIImportStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIImportStorerMockRecorder
+}
+
+// MockIImportStorerMockRecorder is the mock recorder for MockIImportStorer.
+type MockIImportStorerMockRecorder struct {
+	mock *MockIImportStorer
+}
+
+// NewMockIImportStorer creates a new mock instance.
+func NewMockIImportStorer(ctrl *gomock.Controller) *MockIImportStorer {
+	mock := &MockIImportStorer{ctrl: ctrl}
+	mock.recorder = &MockIImportStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIImportStorer) EXPECT() *MockIImportStorerMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockIImportStorer) Claim(lease time.Duration) (*models.Import, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", lease)
+	ret0, _ := ret[0].(*models.Import)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockIImportStorerMockRecorder) Claim(lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockIImportStorer)(nil).Claim), lease)
+}
+
+// CountPending mocks base method.
+func (m *MockIImportStorer) CountPending(userId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPending", userId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPending indicates an expected call of CountPending.
+func (mr *MockIImportStorerMockRecorder) CountPending(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPending", reflect.TypeOf((*MockIImportStorer)(nil).CountPending), userId)
+}
+
+// Create mocks base method.
+func (m *MockIImportStorer) Create(userId int, source string, options models.ImportOptions, key string) (*models.Import, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, source, options, key)
+	ret0, _ := ret[0].(*models.Import)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIImportStorerMockRecorder) Create(userId, source, options, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIImportStorer)(nil).Create), userId, source, options, key)
+}
+
+// CreatePlaceholder mocks base method.
+func (m *MockIImportStorer) CreatePlaceholder(importerId int, source, key, tag, name, password string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePlaceholder", importerId, source, key, tag, name, password)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePlaceholder indicates an expected call of CreatePlaceholder.
+func (mr *MockIImportStorerMockRecorder) CreatePlaceholder(importerId, source, key, tag, name, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePlaceholder", reflect.TypeOf((*MockIImportStorer)(nil).CreatePlaceholder), importerId, source, key, tag, name, password)
+}
+
+// Finish mocks base method.
+func (m *MockIImportStorer) Finish(id int, status string, progress models.ImportProgress) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", id, status, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockIImportStorerMockRecorder) Finish(id, status, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockIImportStorer)(nil).Finish), id, status, progress)
+}
+
+// GetForUser mocks base method.
+func (m *MockIImportStorer) GetForUser(userId int) ([]models.Import, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUser", userId)
+	ret0, _ := ret[0].([]models.Import)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForUser indicates an expected call of GetForUser.
+func (mr *MockIImportStorerMockRecorder) GetForUser(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUser", reflect.TypeOf((*MockIImportStorer)(nil).GetForUser), userId)
+}
+
+// GetOne mocks base method.
+func (m *MockIImportStorer) GetOne(id int) (*models.Import, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", id)
+	ret0, _ := ret[0].(*models.Import)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIImportStorerMockRecorder) GetOne(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIImportStorer)(nil).GetOne), id)
+}
+
+// GetUsers mocks base method.
+func (m *MockIImportStorer) GetUsers(importerId int, source string, keys []string) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", importerId, source, keys)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockIImportStorerMockRecorder) GetUsers(importerId, source, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockIImportStorer)(nil).GetUsers), importerId, source, keys)
+}
+
+// InsertMessagesInTx mocks base method.
+func (m *MockIImportStorer) InsertMessagesInTx(tx *sql.Tx, chatId int, msgs []models.ImportedMessage) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertMessagesInTx", tx, chatId, msgs)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertMessagesInTx indicates an expected call of InsertMessagesInTx.
+func (mr *MockIImportStorerMockRecorder) InsertMessagesInTx(tx, chatId, msgs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMessagesInTx", reflect.TypeOf((*MockIImportStorer)(nil).InsertMessagesInTx), tx, chatId, msgs)
+}
+
+// MapUser mocks base method.
+func (m *MockIImportStorer) MapUser(importerId int, source, key string, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MapUser", importerId, source, key, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MapUser indicates an expected call of MapUser.
+func (mr *MockIImportStorerMockRecorder) MapUser(importerId, source, key, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapUser", reflect.TypeOf((*MockIImportStorer)(nil).MapUser), importerId, source, key, userId)
+}
+
+// SetProgress mocks base method.
+func (m *MockIImportStorer) SetProgress(id int, progress models.ImportProgress, lease time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProgress", id, progress, lease)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProgress indicates an expected call of SetProgress.
+func (mr *MockIImportStorerMockRecorder) SetProgress(id, progress, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProgress", reflect.TypeOf((*MockIImportStorer)(nil).SetProgress), id, progress, lease)
+}
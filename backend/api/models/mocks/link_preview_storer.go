@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/link_preview.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockILinkPreviewStorer is a mock of ILinkPreviewStorer interface.
+type MockILinkPreviewStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockILinkPreviewStorerMockRecorder
+}
+
+// MockILinkPreviewStorerMockRecorder is the mock recorder for MockILinkPreviewStorer.
+type MockILinkPreviewStorerMockRecorder struct {
+	mock *MockILinkPreviewStorer
+}
+
+// NewMockILinkPreviewStorer creates a new mock instance.
+func NewMockILinkPreviewStorer(ctrl *gomock.Controller) *MockILinkPreviewStorer {
+	mock := &MockILinkPreviewStorer{ctrl: ctrl}
+	mock.recorder = &MockILinkPreviewStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILinkPreviewStorer) EXPECT() *MockILinkPreviewStorerMockRecorder {
+	return m.recorder
+}
+
+// DeleteStale mocks base method.
+func (m *MockILinkPreviewStorer) DeleteStale(maxAge time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStale", maxAge)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStale indicates an expected call of DeleteStale.
+func (mr *MockILinkPreviewStorerMockRecorder) DeleteStale(maxAge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStale", reflect.TypeOf((*MockILinkPreviewStorer)(nil).DeleteStale), maxAge)
+}
+
+// Get mocks base method.
+func (m *MockILinkPreviewStorer) Get(url string, maxAge, failedMaxAge time.Duration) (*models.LinkPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", url, maxAge, failedMaxAge)
+	ret0, _ := ret[0].(*models.LinkPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockILinkPreviewStorerMockRecorder) Get(url, maxAge, failedMaxAge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockILinkPreviewStorer)(nil).Get), url, maxAge, failedMaxAge)
+}
+
+// Save mocks base method.
+func (m *MockILinkPreviewStorer) Save(url string, preview *models.LinkPreview) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", url, preview)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockILinkPreviewStorerMockRecorder) Save(url, preview interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockILinkPreviewStorer)(nil).Save), url, preview)
+}
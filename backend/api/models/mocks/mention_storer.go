@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/mention.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sql "database/sql"
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIMentionStorer is a mock of IMentionStorer interface.
+type MockIMentionStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMentionStorerMockRecorder
+}
+
+// MockIMentionStorerMockRecorder is the mock recorder for MockIMentionStorer.
+type MockIMentionStorerMockRecorder struct {
+	mock *MockIMentionStorer
+}
+
+// NewMockIMentionStorer creates a new mock instance.
+func NewMockIMentionStorer(ctrl *gomock.Controller) *MockIMentionStorer {
+	mock := &MockIMentionStorer{ctrl: ctrl}
+	mock.recorder = &MockIMentionStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMentionStorer) EXPECT() *MockIMentionStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateInTx mocks base method.
+func (m *MockIMentionStorer) CreateInTx(tx *sql.Tx, messageId int, mentions []models.Mention) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInTx", tx, messageId, mentions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInTx indicates an expected call of CreateInTx.
+func (mr *MockIMentionStorerMockRecorder) CreateInTx(tx, messageId, mentions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInTx", reflect.TypeOf((*MockIMentionStorer)(nil).CreateInTx), tx, messageId, mentions)
+}
+
+// DeleteInTx mocks base method.
+func (m *MockIMentionStorer) DeleteInTx(tx *sql.Tx, messageId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInTx", tx, messageId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInTx indicates an expected call of DeleteInTx.
+func (mr *MockIMentionStorerMockRecorder) DeleteInTx(tx, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInTx", reflect.TypeOf((*MockIMentionStorer)(nil).DeleteInTx), tx, messageId)
+}
+
+// GetForMessages mocks base method.
+func (m *MockIMentionStorer) GetForMessages(messageIds []int) (map[int][]models.Mention, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForMessages", messageIds)
+	ret0, _ := ret[0].(map[int][]models.Mention)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForMessages indicates an expected call of GetForMessages.
+func (mr *MockIMentionStorerMockRecorder) GetForMessages(messageIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForMessages", reflect.TypeOf((*MockIMentionStorer)(nil).GetForMessages), messageIds)
+}
@@ -35,6 +35,36 @@ func (m *MockIMessageStorer) EXPECT() *MockIMessageStorerMockRecorder {
 	return m.recorder
 }
 
+// Begin mocks base method.
+func (m *MockIMessageStorer) Begin() (*sql.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin")
+	ret0, _ := ret[0].(*sql.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockIMessageStorerMockRecorder) Begin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockIMessageStorer)(nil).Begin))
+}
+
+// CountChatMessages mocks base method.
+func (m *MockIMessageStorer) CountChatMessages(chatId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountChatMessages", chatId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountChatMessages indicates an expected call of CountChatMessages.
+func (mr *MockIMessageStorerMockRecorder) CountChatMessages(chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountChatMessages", reflect.TypeOf((*MockIMessageStorer)(nil).CountChatMessages), chatId)
+}
+
 // Create mocks base method.
 func (m *MockIMessageStorer) Create(tdo models.MessageDTO) (*models.Message, error) {
 	m.ctrl.T.Helper()
@@ -50,19 +80,34 @@ func (mr *MockIMessageStorerMockRecorder) Create(tdo interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIMessageStorer)(nil).Create), tdo)
 }
 
+// CreateInTx mocks base method.
+func (m *MockIMessageStorer) CreateInTx(tx *sql.Tx, tdo models.MessageDTO) (*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInTx", tx, tdo)
+	ret0, _ := ret[0].(*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInTx indicates an expected call of CreateInTx.
+func (mr *MockIMessageStorerMockRecorder) CreateInTx(tx, tdo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInTx", reflect.TypeOf((*MockIMessageStorer)(nil).CreateInTx), tx, tdo)
+}
+
 // Delete mocks base method.
-func (m *MockIMessageStorer) Delete(id int) (*models.Message, error) {
+func (m *MockIMessageStorer) Delete(id, deletedBy, deleteWindow int) (*models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", id, deletedBy, deleteWindow)
 	ret0, _ := ret[0].(*models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockIMessageStorerMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockIMessageStorerMockRecorder) Delete(id, deletedBy, deleteWindow interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIMessageStorer)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIMessageStorer)(nil).Delete), id, deletedBy, deleteWindow)
 }
 
 // DeleteAll mocks base method.
@@ -80,19 +125,94 @@ func (mr *MockIMessageStorerMockRecorder) DeleteAll(chatId interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*MockIMessageStorer)(nil).DeleteAll), chatId)
 }
 
-// GetChatMessages mocks base method.
-func (m *MockIMessageStorer) GetChatMessages(chatId, page int) ([]models.Message, error) {
+// DeleteExpired mocks base method.
+func (m *MockIMessageStorer) DeleteExpired(limit int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", limit)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIMessageStorerMockRecorder) DeleteExpired(limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIMessageStorer)(nil).DeleteExpired), limit)
+}
+
+// GetChatHistory mocks base method.
+func (m *MockIMessageStorer) GetChatHistory(chatId, afterId, limit int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatHistory", chatId, afterId, limit)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatHistory indicates an expected call of GetChatHistory.
+func (mr *MockIMessageStorerMockRecorder) GetChatHistory(chatId, afterId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatHistory", reflect.TypeOf((*MockIMessageStorer)(nil).GetChatHistory), chatId, afterId, limit)
+}
+
+// GetChatMessagesAfter mocks base method.
+func (m *MockIMessageStorer) GetChatMessagesAfter(userId, chatId, afterId, limit int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatMessagesAfter", userId, chatId, afterId, limit)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatMessagesAfter indicates an expected call of GetChatMessagesAfter.
+func (mr *MockIMessageStorerMockRecorder) GetChatMessagesAfter(userId, chatId, afterId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMessagesAfter", reflect.TypeOf((*MockIMessageStorer)(nil).GetChatMessagesAfter), userId, chatId, afterId, limit)
+}
+
+// GetChatMessagesBefore mocks base method.
+func (m *MockIMessageStorer) GetChatMessagesBefore(userId, chatId, beforeId, limit int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatMessagesBefore", userId, chatId, beforeId, limit)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatMessagesBefore indicates an expected call of GetChatMessagesBefore.
+func (mr *MockIMessageStorerMockRecorder) GetChatMessagesBefore(userId, chatId, beforeId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMessagesBefore", reflect.TypeOf((*MockIMessageStorer)(nil).GetChatMessagesBefore), userId, chatId, beforeId, limit)
+}
+
+// GetListeners mocks base method.
+func (m *MockIMessageStorer) GetListeners(messageIds []int) (map[int][]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListeners", messageIds)
+	ret0, _ := ret[0].(map[int][]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListeners indicates an expected call of GetListeners.
+func (mr *MockIMessageStorerMockRecorder) GetListeners(messageIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListeners", reflect.TypeOf((*MockIMessageStorer)(nil).GetListeners), messageIds)
+}
+
+// GetMany mocks base method.
+func (m *MockIMessageStorer) GetMany(ids []int) ([]models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChatMessages", chatId, page)
+	ret := m.ctrl.Call(m, "GetMany", ids)
 	ret0, _ := ret[0].([]models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetChatMessages indicates an expected call of GetChatMessages.
-func (mr *MockIMessageStorerMockRecorder) GetChatMessages(chatId, page interface{}) *gomock.Call {
+// GetMany indicates an expected call of GetMany.
+func (mr *MockIMessageStorerMockRecorder) GetMany(ids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMessages", reflect.TypeOf((*MockIMessageStorer)(nil).GetChatMessages), chatId, page)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*MockIMessageStorer)(nil).GetMany), ids)
 }
 
 // GetOne mocks base method.
@@ -110,17 +230,162 @@ func (mr *MockIMessageStorerMockRecorder) GetOne(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIMessageStorer)(nil).GetOne), id)
 }
 
-// Update mocks base method.
-func (m *MockIMessageStorer) Update(message models.Message) (*models.Message, error) {
+// GetReplies mocks base method.
+func (m *MockIMessageStorer) GetReplies(userId, parentId, page int) ([]models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", message)
+	ret := m.ctrl.Call(m, "GetReplies", userId, parentId, page)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReplies indicates an expected call of GetReplies.
+func (mr *MockIMessageStorerMockRecorder) GetReplies(userId, parentId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReplies", reflect.TypeOf((*MockIMessageStorer)(nil).GetReplies), userId, parentId, page)
+}
+
+// GetThreads mocks base method.
+func (m *MockIMessageStorer) GetThreads(parentIds []int) (map[int]models.Thread, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThreads", parentIds)
+	ret0, _ := ret[0].(map[int]models.Thread)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThreads indicates an expected call of GetThreads.
+func (mr *MockIMessageStorerMockRecorder) GetThreads(parentIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThreads", reflect.TypeOf((*MockIMessageStorer)(nil).GetThreads), parentIds)
+}
+
+// GetUserMentions mocks base method.
+func (m *MockIMessageStorer) GetUserMentions(userId, page int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMentions", userId, page)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserMentions indicates an expected call of GetUserMentions.
+func (mr *MockIMessageStorerMockRecorder) GetUserMentions(userId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMentions", reflect.TypeOf((*MockIMessageStorer)(nil).GetUserMentions), userId, page)
+}
+
+// Hide mocks base method.
+func (m *MockIMessageStorer) Hide(userId, messageId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hide", userId, messageId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Hide indicates an expected call of Hide.
+func (mr *MockIMessageStorerMockRecorder) Hide(userId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hide", reflect.TypeOf((*MockIMessageStorer)(nil).Hide), userId, messageId)
+}
+
+// MarkListened mocks base method.
+func (m *MockIMessageStorer) MarkListened(userId, messageId int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkListened", userId, messageId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkListened indicates an expected call of MarkListened.
+func (mr *MockIMessageStorerMockRecorder) MarkListened(userId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkListened", reflect.TypeOf((*MockIMessageStorer)(nil).MarkListened), userId, messageId)
+}
+
+// Search mocks base method.
+func (m *MockIMessageStorer) Search(userId int, query models.SearchQuery) ([]models.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", userId, query)
+	ret0, _ := ret[0].([]models.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockIMessageStorerMockRecorder) Search(userId, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockIMessageStorer)(nil).Search), userId, query)
+}
+
+// SetPreview mocks base method.
+func (m *MockIMessageStorer) SetPreview(messageId int, content string, preview models.LinkPreview) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPreview", messageId, content, preview)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPreview indicates an expected call of SetPreview.
+func (mr *MockIMessageStorerMockRecorder) SetPreview(messageId, content, preview interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPreview", reflect.TypeOf((*MockIMessageStorer)(nil).SetPreview), messageId, content, preview)
+}
+
+// UpdateInTx mocks base method.
+func (m *MockIMessageStorer) UpdateInTx(tx *sql.Tx, message models.Message, editWindow int) (*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInTx", tx, message, editWindow)
 	ret0, _ := ret[0].(*models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Update indicates an expected call of Update.
-func (mr *MockIMessageStorerMockRecorder) Update(message interface{}) *gomock.Call {
+// UpdateInTx indicates an expected call of UpdateInTx.
+func (mr *MockIMessageStorerMockRecorder) UpdateInTx(tx, message, editWindow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInTx", reflect.TypeOf((*MockIMessageStorer)(nil).UpdateInTx), tx, message, editWindow)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIMessageStorer)(nil).Update), message)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
 }
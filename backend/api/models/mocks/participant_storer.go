@@ -139,3 +139,18 @@ func (mr *MockIParticipantStorerMockRecorder) Update(participant interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIParticipantStorer)(nil).Update), participant)
 }
+
+// UpdateMuted mocks base method.
+func (m *MockIParticipantStorer) UpdateMuted(userId, chatId int, muted bool) (*models.Participant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMuted", userId, chatId, muted)
+	ret0, _ := ret[0].(*models.Participant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMuted indicates an expected call of UpdateMuted.
+func (mr *MockIParticipantStorerMockRecorder) UpdateMuted(userId, chatId, muted interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMuted", reflect.TypeOf((*MockIParticipantStorer)(nil).UpdateMuted), userId, chatId, muted)
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/pin.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIPinStorer is a mock of IPinStorer interface.
+type MockIPinStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPinStorerMockRecorder
+}
+
+// MockIPinStorerMockRecorder is the mock recorder for MockIPinStorer.
+type MockIPinStorerMockRecorder struct {
+	mock *MockIPinStorer
+}
+
+// NewMockIPinStorer creates a new mock instance.
+func NewMockIPinStorer(ctrl *gomock.Controller) *MockIPinStorer {
+	mock := &MockIPinStorer{ctrl: ctrl}
+	mock.recorder = &MockIPinStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPinStorer) EXPECT() *MockIPinStorerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIPinStorer) Create(chatId, messageId, pinnedBy, maxPins int) (*models.Pin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", chatId, messageId, pinnedBy, maxPins)
+	ret0, _ := ret[0].(*models.Pin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIPinStorerMockRecorder) Create(chatId, messageId, pinnedBy, maxPins interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIPinStorer)(nil).Create), chatId, messageId, pinnedBy, maxPins)
+}
+
+// Delete mocks base method.
+func (m *MockIPinStorer) Delete(chatId, messageId int) (*models.Pin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", chatId, messageId)
+	ret0, _ := ret[0].(*models.Pin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIPinStorerMockRecorder) Delete(chatId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIPinStorer)(nil).Delete), chatId, messageId)
+}
+
+// GetForChat mocks base method.
+func (m *MockIPinStorer) GetForChat(chatId int) ([]models.Pin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForChat", chatId)
+	ret0, _ := ret[0].([]models.Pin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForChat indicates an expected call of GetForChat.
+func (mr *MockIPinStorerMockRecorder) GetForChat(chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForChat", reflect.TypeOf((*MockIPinStorer)(nil).GetForChat), chatId)
+}
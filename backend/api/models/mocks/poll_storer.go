@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/poll.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sql "database/sql"
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIPollStorer is a mock of IPollStorer interface.
+type MockIPollStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPollStorerMockRecorder
+}
+
+// MockIPollStorerMockRecorder is the mock recorder for MockIPollStorer.
+type MockIPollStorerMockRecorder struct {
+	mock *MockIPollStorer
+}
+
+// NewMockIPollStorer creates a new mock instance.
+func NewMockIPollStorer(ctrl *gomock.Controller) *MockIPollStorer {
+	mock := &MockIPollStorer{ctrl: ctrl}
+	mock.recorder = &MockIPollStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPollStorer) EXPECT() *MockIPollStorerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockIPollStorer) Close(messageId int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close", messageId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockIPollStorerMockRecorder) Close(messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockIPollStorer)(nil).Close), messageId)
+}
+
+// CreateInTx mocks base method.
+func (m *MockIPollStorer) CreateInTx(tx *sql.Tx, messageId int, dto models.PollDTO) (*models.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInTx", tx, messageId, dto)
+	ret0, _ := ret[0].(*models.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInTx indicates an expected call of CreateInTx.
+func (mr *MockIPollStorerMockRecorder) CreateInTx(tx, messageId, dto interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInTx", reflect.TypeOf((*MockIPollStorer)(nil).CreateInTx), tx, messageId, dto)
+}
+
+// GetForMessages mocks base method.
+func (m *MockIPollStorer) GetForMessages(messageIds []int, userId int) (map[int]models.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForMessages", messageIds, userId)
+	ret0, _ := ret[0].(map[int]models.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForMessages indicates an expected call of GetForMessages.
+func (mr *MockIPollStorerMockRecorder) GetForMessages(messageIds, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForMessages", reflect.TypeOf((*MockIPollStorer)(nil).GetForMessages), messageIds, userId)
+}
+
+// Retract mocks base method.
+func (m *MockIPollStorer) Retract(messageId, userId int, optionIds []int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retract", messageId, userId, optionIds)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Retract indicates an expected call of Retract.
+func (mr *MockIPollStorerMockRecorder) Retract(messageId, userId, optionIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retract", reflect.TypeOf((*MockIPollStorer)(nil).Retract), messageId, userId, optionIds)
+}
+
+// Vote mocks base method.
+func (m *MockIPollStorer) Vote(messageId, userId int, optionIds []int, replace bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", messageId, userId, optionIds, replace)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockIPollStorerMockRecorder) Vote(messageId, userId, optionIds, replace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockIPollStorer)(nil).Vote), messageId, userId, optionIds, replace)
+}
@@ -0,0 +1,126 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/reaction.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIReactionStorer is a mock of IReactionStorer interface.
+type MockIReactionStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIReactionStorerMockRecorder
+}
+
+// MockIReactionStorerMockRecorder is the mock recorder for MockIReactionStorer.
+type MockIReactionStorerMockRecorder struct {
+	mock *MockIReactionStorer
+}
+
+// NewMockIReactionStorer creates a new mock instance.
+func NewMockIReactionStorer(ctrl *gomock.Controller) *MockIReactionStorer {
+	mock := &MockIReactionStorer{ctrl: ctrl}
+	mock.recorder = &MockIReactionStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIReactionStorer) EXPECT() *MockIReactionStorerMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method.
+func (m *MockIReactionStorer) Count(messageId int, emoji string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", messageId, emoji)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockIReactionStorerMockRecorder) Count(messageId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockIReactionStorer)(nil).Count), messageId, emoji)
+}
+
+// CountForMessage mocks base method.
+func (m *MockIReactionStorer) CountForMessage(messageId, userId int) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountForMessage", messageId, userId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CountForMessage indicates an expected call of CountForMessage.
+func (mr *MockIReactionStorerMockRecorder) CountForMessage(messageId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountForMessage", reflect.TypeOf((*MockIReactionStorer)(nil).CountForMessage), messageId, userId)
+}
+
+// Create mocks base method.
+func (m *MockIReactionStorer) Create(messageId, userId int, emoji string, maxEmojis, maxByUser int) (*models.Reaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", messageId, userId, emoji, maxEmojis, maxByUser)
+	ret0, _ := ret[0].(*models.Reaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIReactionStorerMockRecorder) Create(messageId, userId, emoji, maxEmojis, maxByUser interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIReactionStorer)(nil).Create), messageId, userId, emoji, maxEmojis, maxByUser)
+}
+
+// Delete mocks base method.
+func (m *MockIReactionStorer) Delete(messageId, userId int, emoji string) (*models.Reaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", messageId, userId, emoji)
+	ret0, _ := ret[0].(*models.Reaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIReactionStorerMockRecorder) Delete(messageId, userId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIReactionStorer)(nil).Delete), messageId, userId, emoji)
+}
+
+// GetAllForMessages mocks base method.
+func (m *MockIReactionStorer) GetAllForMessages(messageIds []int) (map[int][]models.Reaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllForMessages", messageIds)
+	ret0, _ := ret[0].(map[int][]models.Reaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllForMessages indicates an expected call of GetAllForMessages.
+func (mr *MockIReactionStorerMockRecorder) GetAllForMessages(messageIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForMessages", reflect.TypeOf((*MockIReactionStorer)(nil).GetAllForMessages), messageIds)
+}
+
+// GetForMessages mocks base method.
+func (m *MockIReactionStorer) GetForMessages(messageIds []int, userId int) (map[int][]models.ReactionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForMessages", messageIds, userId)
+	ret0, _ := ret[0].(map[int][]models.ReactionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForMessages indicates an expected call of GetForMessages.
+func (mr *MockIReactionStorerMockRecorder) GetForMessages(messageIds, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForMessages", reflect.TypeOf((*MockIReactionStorer)(nil).GetForMessages), messageIds, userId)
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/revision.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sql "database/sql"
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIRevisionStorer is a mock of IRevisionStorer interface.
+type MockIRevisionStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIRevisionStorerMockRecorder
+}
+
+// MockIRevisionStorerMockRecorder is the mock recorder for MockIRevisionStorer.
+type MockIRevisionStorerMockRecorder struct {
+	mock *MockIRevisionStorer
+}
+
+// NewMockIRevisionStorer creates a new mock instance.
+func NewMockIRevisionStorer(ctrl *gomock.Controller) *MockIRevisionStorer {
+	mock := &MockIRevisionStorer{ctrl: ctrl}
+	mock.recorder = &MockIRevisionStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIRevisionStorer) EXPECT() *MockIRevisionStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateInTx mocks base method.
+func (m *MockIRevisionStorer) CreateInTx(tx *sql.Tx, messageId int, content string) (*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInTx", tx, messageId, content)
+	ret0, _ := ret[0].(*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInTx indicates an expected call of CreateInTx.
+func (mr *MockIRevisionStorerMockRecorder) CreateInTx(tx, messageId, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInTx", reflect.TypeOf((*MockIRevisionStorer)(nil).CreateInTx), tx, messageId, content)
+}
+
+// GetForMessage mocks base method.
+func (m *MockIRevisionStorer) GetForMessage(messageId int) ([]models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForMessage", messageId)
+	ret0, _ := ret[0].([]models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForMessage indicates an expected call of GetForMessage.
+func (mr *MockIRevisionStorerMockRecorder) GetForMessage(messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForMessage", reflect.TypeOf((*MockIRevisionStorer)(nil).GetForMessage), messageId)
+}
+
+// GetForMessages mocks base method.
+func (m *MockIRevisionStorer) GetForMessages(messageIds []int) (map[int][]models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForMessages", messageIds)
+	ret0, _ := ret[0].(map[int][]models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForMessages indicates an expected call of GetForMessages.
+func (mr *MockIRevisionStorerMockRecorder) GetForMessages(messageIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForMessages", reflect.TypeOf((*MockIRevisionStorer)(nil).GetForMessages), messageIds)
+}
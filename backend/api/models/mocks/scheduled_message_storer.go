@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/scheduled_message.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sql "database/sql"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIScheduledMessageStorer is a mock of IScheduledMessageStorer interface.
+type MockIScheduledMessageStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIScheduledMessageStorerMockRecorder
+}
+
+// MockIScheduledMessageStorerMockRecorder is the mock recorder for MockIScheduledMessageStorer.
+type MockIScheduledMessageStorerMockRecorder struct {
+	mock *MockIScheduledMessageStorer
+}
+
+// NewMockIScheduledMessageStorer creates a new mock instance.
+func NewMockIScheduledMessageStorer(ctrl *gomock.Controller) *MockIScheduledMessageStorer {
+	mock := &MockIScheduledMessageStorer{ctrl: ctrl}
+	mock.recorder = &MockIScheduledMessageStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIScheduledMessageStorer) EXPECT() *MockIScheduledMessageStorerMockRecorder {
+	return m.recorder
+}
+
+// ClaimDue mocks base method.
+func (m *MockIScheduledMessageStorer) ClaimDue(limit int, lease time.Duration) ([]models.ScheduledMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDue", limit, lease)
+	ret0, _ := ret[0].([]models.ScheduledMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDue indicates an expected call of ClaimDue.
+func (mr *MockIScheduledMessageStorerMockRecorder) ClaimDue(limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDue", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).ClaimDue), limit, lease)
+}
+
+// CountPending mocks base method.
+func (m *MockIScheduledMessageStorer) CountPending(senderId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPending", senderId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPending indicates an expected call of CountPending.
+func (mr *MockIScheduledMessageStorerMockRecorder) CountPending(senderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPending", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).CountPending), senderId)
+}
+
+// Create mocks base method.
+func (m *MockIScheduledMessageStorer) Create(msg models.ScheduledMessage) (*models.ScheduledMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", msg)
+	ret0, _ := ret[0].(*models.ScheduledMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIScheduledMessageStorerMockRecorder) Create(msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).Create), msg)
+}
+
+// Delete mocks base method.
+func (m *MockIScheduledMessageStorer) Delete(id int) (*models.ScheduledMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(*models.ScheduledMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIScheduledMessageStorerMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).Delete), id)
+}
+
+// Fail mocks base method.
+func (m *MockIScheduledMessageStorer) Fail(id int, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockIScheduledMessageStorerMockRecorder) Fail(id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).Fail), id, reason)
+}
+
+// FinishInTx mocks base method.
+func (m *MockIScheduledMessageStorer) FinishInTx(tx *sql.Tx, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishInTx", tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishInTx indicates an expected call of FinishInTx.
+func (mr *MockIScheduledMessageStorerMockRecorder) FinishInTx(tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishInTx", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).FinishInTx), tx, id)
+}
+
+// GetForSender mocks base method.
+func (m *MockIScheduledMessageStorer) GetForSender(senderId, chatId int) ([]models.ScheduledMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForSender", senderId, chatId)
+	ret0, _ := ret[0].([]models.ScheduledMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForSender indicates an expected call of GetForSender.
+func (mr *MockIScheduledMessageStorerMockRecorder) GetForSender(senderId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForSender", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).GetForSender), senderId, chatId)
+}
+
+// GetOne mocks base method.
+func (m *MockIScheduledMessageStorer) GetOne(id int) (*models.ScheduledMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", id)
+	ret0, _ := ret[0].(*models.ScheduledMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIScheduledMessageStorerMockRecorder) GetOne(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).GetOne), id)
+}
+
+// Update mocks base method.
+func (m *MockIScheduledMessageStorer) Update(msg models.ScheduledMessage) (*models.ScheduledMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", msg)
+	ret0, _ := ret[0].(*models.ScheduledMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockIScheduledMessageStorerMockRecorder) Update(msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIScheduledMessageStorer)(nil).Update), msg)
+}
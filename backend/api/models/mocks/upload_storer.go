@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: models/upload.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sql "database/sql"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIUploadStorer is a mock of IUploadStorer interface.
+type MockIUploadStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIUploadStorerMockRecorder
+}
+
+// MockIUploadStorerMockRecorder is the mock recorder for MockIUploadStorer.
+type MockIUploadStorerMockRecorder struct {
+	mock *MockIUploadStorer
+}
+
+// NewMockIUploadStorer creates a new mock instance.
+func NewMockIUploadStorer(ctrl *gomock.Controller) *MockIUploadStorer {
+	mock := &MockIUploadStorer{ctrl: ctrl}
+	mock.recorder = &MockIUploadStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIUploadStorer) EXPECT() *MockIUploadStorerMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockIUploadStorer) Append(id string, offset, size int64, chunk string, ttl time.Duration) (*models.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", id, offset, size, chunk, ttl)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockIUploadStorerMockRecorder) Append(id, offset, size, chunk, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockIUploadStorer)(nil).Append), id, offset, size, chunk, ttl)
+}
+
+// Begin mocks base method.
+func (m *MockIUploadStorer) Begin() (*sql.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin")
+	ret0, _ := ret[0].(*sql.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockIUploadStorerMockRecorder) Begin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockIUploadStorer)(nil).Begin))
+}
+
+// Claim mocks base method.
+func (m *MockIUploadStorer) Claim(id string, ttl time.Duration) (*models.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", id, ttl)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockIUploadStorerMockRecorder) Claim(id, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockIUploadStorer)(nil).Claim), id, ttl)
+}
+
+// Create mocks base method.
+func (m *MockIUploadStorer) Create(dto models.UploadDTO, ttl time.Duration) (*models.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", dto, ttl)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIUploadStorerMockRecorder) Create(dto, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIUploadStorer)(nil).Create), dto, ttl)
+}
+
+// Delete mocks base method.
+func (m *MockIUploadStorer) Delete(id string) (*models.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIUploadStorerMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIUploadStorer)(nil).Delete), id)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockIUploadStorer) DeleteExpired() ([]models.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired")
+	ret0, _ := ret[0].([]models.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIUploadStorerMockRecorder) DeleteExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIUploadStorer)(nil).DeleteExpired))
+}
+
+// FinishInTx mocks base method.
+func (m *MockIUploadStorer) FinishInTx(tx *sql.Tx, id string, attachmentId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishInTx", tx, id, attachmentId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishInTx indicates an expected call of FinishInTx.
+func (mr *MockIUploadStorerMockRecorder) FinishInTx(tx, id, attachmentId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishInTx", reflect.TypeOf((*MockIUploadStorer)(nil).FinishInTx), tx, id, attachmentId)
+}
+
+// GetOne mocks base method.
+func (m *MockIUploadStorer) GetOne(id string) (*models.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", id)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIUploadStorerMockRecorder) GetOne(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIUploadStorer)(nil).GetOne), id)
+}
+
+// Release mocks base method.
+func (m *MockIUploadStorer) Release(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIUploadStorerMockRecorder) Release(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIUploadStorer)(nil).Release), id)
+}
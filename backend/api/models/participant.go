@@ -8,11 +8,13 @@ type Participant struct {
 	UserId int    `json:"userId"`
 	ChatId int    `json:"chatId"`
 	Role   string `json:"role"`
+	Muted  bool   `json:"muted"`
 }
 
 type ChatUser struct {
 	Participant
 	Name string `json:"name"`
+	Tag  string `json:"tag"`
 }
 
 type ParticipantFromRequest struct {
@@ -20,12 +22,18 @@ type ParticipantFromRequest struct {
 	ChatId int `json:"chatId"`
 }
 
+type MuteFromRequest struct {
+	ChatId int  `json:"chatId"`
+	Muted  bool `json:"muted"`
+}
+
 type IParticipantStorer interface {
 	Create(participant Participant) (*Participant, error)
 	CreateInTx(tx *sql.Tx, participant Participant) (*Participant, error)
 	GetOne(userId, chatId int) (*Participant, error)
 	GetChatUsers(chatId int) ([]ChatUser, error)
 	Update(participant Participant) (*Participant, error)
+	UpdateMuted(userId, chatId int, muted bool) (*Participant, error)
 	Delete(participant Participant) (*Participant, error)
 	DeleteAll(chatId int) (sql.Result, error)
 }
@@ -58,9 +66,9 @@ func (ps ParticipantStorer) CreateInTx(tx *sql.Tx, participant Participant) (*Pa
 
 func (ps ParticipantStorer) GetOne(userId, chatId int) (*Participant, error) {
 	var participant Participant
-	query := "SELECT * FROM participants WHERE user_id=$1 AND chat_id=$2"
+	query := "SELECT user_id, chat_id, role, muted FROM participants WHERE user_id=$1 AND chat_id=$2"
 	row := ps.DB.QueryRow(query, userId, chatId)
-	err := row.Scan(&participant.UserId, &participant.ChatId, &participant.Role)
+	err := row.Scan(&participant.UserId, &participant.ChatId, &participant.Role, &participant.Muted)
 	if err != nil {
 		return nil, err
 	}
@@ -69,14 +77,14 @@ func (ps ParticipantStorer) GetOne(userId, chatId int) (*Participant, error) {
 
 func (ps ParticipantStorer) GetChatUsers(chatId int) ([]ChatUser, error) {
 	chatUsers := make([]ChatUser, 0)
-	query := "SELECT u.id, u.name, p.chat_id, p.role FROM participants p JOIN users u ON p.user_id=u.id WHERE p.chat_id=$2"
+	query := "SELECT u.id, u.name, u.tag, p.chat_id, p.role, p.muted FROM participants p JOIN users u ON p.user_id=u.id WHERE p.chat_id=$1"
 	rows, err := ps.DB.Query(query, chatId)
 	if err != nil {
 		return nil, err
 	}
 	for rows.Next() {
 		var chatUser ChatUser
-		err := rows.Scan(&chatUser.UserId, &chatUser.Name, &chatUser.ChatId, &chatUser.Role)
+		err := rows.Scan(&chatUser.UserId, &chatUser.Name, &chatUser.Tag, &chatUser.ChatId, &chatUser.Role, &chatUser.Muted)
 		if err != nil {
 			return nil, err
 		}
@@ -87,9 +95,20 @@ func (ps ParticipantStorer) GetChatUsers(chatId int) ([]ChatUser, error) {
 
 func (ps ParticipantStorer) Update(participant Participant) (*Participant, error) {
 	var updParticipant Participant
-	query := "UPDATE participants SET role=$1 WHERE user_id=$1 AND chat_id=$2 RETURNING *"
+	query := "UPDATE participants SET role=$1 WHERE user_id=$2 AND chat_id=$3 RETURNING user_id, chat_id, role, muted"
 	row := ps.DB.QueryRow(query, participant.Role, participant.UserId, participant.ChatId)
-	err := row.Scan(&updParticipant.UserId, &updParticipant.ChatId, &updParticipant.Role)
+	err := row.Scan(&updParticipant.UserId, &updParticipant.ChatId, &updParticipant.Role, &updParticipant.Muted)
+	if err != nil {
+		return nil, err
+	}
+	return &updParticipant, nil
+}
+
+func (ps ParticipantStorer) UpdateMuted(userId, chatId int, muted bool) (*Participant, error) {
+	var updParticipant Participant
+	query := "UPDATE participants SET muted=$1 WHERE user_id=$2 AND chat_id=$3 RETURNING user_id, chat_id, role, muted"
+	row := ps.DB.QueryRow(query, muted, userId, chatId)
+	err := row.Scan(&updParticipant.UserId, &updParticipant.ChatId, &updParticipant.Role, &updParticipant.Muted)
 	if err != nil {
 		return nil, err
 	}
@@ -98,9 +117,9 @@ func (ps ParticipantStorer) Update(participant Participant) (*Participant, error
 
 func (ps ParticipantStorer) Delete(participant Participant) (*Participant, error) {
 	var dltParticipant Participant
-	query := "DELETE FROM participants WHERE user_id=$1 AND chat_id=$2 RETURNING *"
+	query := "DELETE FROM participants WHERE user_id=$1 AND chat_id=$2 RETURNING user_id, chat_id, role, muted"
 	row := ps.DB.QueryRow(query, participant.UserId, participant.ChatId)
-	err := row.Scan(&dltParticipant.UserId, &dltParticipant.ChatId, &dltParticipant.Role)
+	err := row.Scan(&dltParticipant.UserId, &dltParticipant.ChatId, &dltParticipant.Role, &dltParticipant.Muted)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// Pin is a message pinned to the top of its chat. Message is set when
+// pins are listed.
+type Pin struct {
+	ChatId    int      `json:"chatId"`
+	MessageId int      `json:"messageId"`
+	PinnedBy  int      `json:"pinnedBy"`
+	PinnedAt  string   `json:"pinnedAt"`
+	Message   *Message `json:"message,omitempty"`
+}
+
+type PinFromRequest struct {
+	ChatId    int `json:"chatId"`
+	MessageId int `json:"messageId"`
+}
+
+type IPinStorer interface {
+	Create(chatId, messageId, pinnedBy, maxPins int) (*Pin, error)
+	Delete(chatId, messageId int) (*Pin, error)
+	GetForChat(chatId int) ([]Pin, error)
+}
+
+type PinStorer struct {
+	DB *sql.DB
+}
+
+func NewPinStorer(db *sql.DB) PinStorer {
+	return PinStorer{DB: db}
+}
+
+func scanPin(row rowScanner) (*Pin, error) {
+	var pin Pin
+	var pinnedBy sql.NullInt64
+	err := row.Scan(&pin.ChatId, &pin.MessageId, &pinnedBy, &pin.PinnedAt)
+	if err != nil {
+		return nil, err
+	}
+	pin.PinnedBy = int(pinnedBy.Int64)
+	return &pin, nil
+}
+
+// Create pins a message unless it is pinned already or the chat has maxPins
+// pins, sql.ErrNoRows is returned then. Pins of deleted messages don't count.
+func (ps PinStorer) Create(chatId, messageId, pinnedBy, maxPins int) (*Pin, error) {
+	query := `INSERT INTO pinned_messages (chat_id, message_id, pinned_by)
+		SELECT $1, $2, $3 WHERE (
+			SELECT count(*) FROM pinned_messages p JOIN messages m ON m.id = p.message_id
+			WHERE p.chat_id = $1 AND m.deleted_at IS NULL
+		) < $4
+		ON CONFLICT DO NOTHING RETURNING chat_id, message_id, pinned_by, pinned_at`
+	row := ps.DB.QueryRow(query, chatId, messageId, pinnedBy, maxPins)
+	return scanPin(row)
+}
+
+func (ps PinStorer) Delete(chatId, messageId int) (*Pin, error) {
+	query := `DELETE FROM pinned_messages WHERE chat_id = $1 AND message_id = $2
+		RETURNING chat_id, message_id, pinned_by, pinned_at`
+	row := ps.DB.QueryRow(query, chatId, messageId)
+	return scanPin(row)
+}
+
+// GetForChat returns the pins of a chat, the latest pinned first.
+// Messages deleted for everyone lose their pins.
+func (ps PinStorer) GetForChat(chatId int) ([]Pin, error) {
+	query := `SELECT p.chat_id, p.message_id, p.pinned_by, p.pinned_at
+		FROM pinned_messages p JOIN messages m ON m.id = p.message_id
+		WHERE p.chat_id = $1 AND m.deleted_at IS NULL
+		ORDER BY p.pinned_at DESC, p.message_id DESC`
+	rows, err := ps.DB.Query(query, chatId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := make([]Pin, 0)
+	for rows.Next() {
+		pin, err := scanPin(rows)
+		if err != nil {
+			return nil, err
+		}
+		pins = append(pins, *pin)
+	}
+	return pins, rows.Err()
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Poll is attached to messages of type "poll" as seen by the user who requested
+// it, MyVotes are the ids of the options they voted for. Voters of options are
+// only told for polls that aren't anonymous.
+type Poll struct {
+	Question   string       `json:"question"`
+	Options    []PollOption `json:"options"`
+	Multiple   bool         `json:"multiple"`
+	Anonymous  bool         `json:"anonymous"`
+	ClosesAt   string       `json:"closesAt,omitempty"`
+	Closed     bool         `json:"closed"`
+	VoterCount int          `json:"voterCount"`
+	MyVotes    []int        `json:"myVotes"`
+}
+
+type PollOption struct {
+	Id     int    `json:"id"`
+	Text   string `json:"text"`
+	Votes  int    `json:"votes"`
+	Voters []int  `json:"voters,omitempty"`
+}
+
+// PollFromRequest is sent with a message of type "poll". ClosesAt is
+// an optional RFC 3339 time the poll stops taking votes at.
+type PollFromRequest struct {
+	Question  string   `json:"question"`
+	Options   []string `json:"options"`
+	Multiple  bool     `json:"multiple"`
+	Anonymous bool     `json:"anonymous"`
+	ClosesAt  string   `json:"closesAt"`
+}
+
+type PollDTO struct {
+	Question  string
+	Options   []string
+	Multiple  bool
+	Anonymous bool
+	ClosesAt  time.Time
+}
+
+type VoteFromRequest struct {
+	MessageId int   `json:"messageId"`
+	OptionIds []int `json:"optionIds"`
+}
+
+// PollEvent is what the chat is told when the tally of a poll changes.
+type PollEvent struct {
+	MessageId int  `json:"messageId"`
+	ChatId    int  `json:"chatId"`
+	Poll      Poll `json:"poll"`
+}
+
+type IPollStorer interface {
+	CreateInTx(tx *sql.Tx, messageId int, dto PollDTO) (*Poll, error)
+	GetForMessages(messageIds []int, userId int) (map[int]Poll, error)
+	Vote(messageId, userId int, optionIds []int, replace bool) (bool, error)
+	Retract(messageId, userId int, optionIds []int) (int64, error)
+	Close(messageId int) (bool, error)
+}
+
+// pollOpen matches the polls that take votes.
+const pollOpen = "closed_at IS NULL AND (closes_at IS NULL OR closes_at > now())"
+
+type PollStorer struct {
+	DB *sql.DB
+}
+
+func NewPollStorer(db *sql.DB) PollStorer {
+	return PollStorer{DB: db}
+}
+
+func (ps PollStorer) CreateInTx(tx *sql.Tx, messageId int, dto PollDTO) (*Poll, error) {
+	closesAt := sql.NullTime{Time: dto.ClosesAt, Valid: !dto.ClosesAt.IsZero()}
+	query := `INSERT INTO polls (message_id, question, multiple, anonymous, closes_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING closes_at`
+	var closesAtStr sql.NullString
+	err := tx.QueryRow(query, messageId, dto.Question, dto.Multiple, dto.Anonymous, closesAt).Scan(&closesAtStr)
+	if err != nil {
+		return nil, err
+	}
+
+	poll := Poll{
+		Question:  dto.Question,
+		Options:   make([]PollOption, len(dto.Options)),
+		Multiple:  dto.Multiple,
+		Anonymous: dto.Anonymous,
+		ClosesAt:  closesAtStr.String,
+		MyVotes:   make([]int, 0),
+	}
+	query = `INSERT INTO poll_options (message_id, "position", text)
+		SELECT $1, position, text FROM unnest($2::text[]) WITH ORDINALITY AS o(text, position)
+		RETURNING id, "position", text`
+	rows, err := tx.Query(query, messageId, pq.Array(dto.Options))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var option PollOption
+		var position int
+		if err := rows.Scan(&option.Id, &position, &option.Text); err != nil {
+			return nil, err
+		}
+		poll.Options[position-1] = option
+	}
+	return &poll, rows.Err()
+}
+
+// GetForMessages returns the polls of messageIds with their tallies as seen by userId.
+func (ps PollStorer) GetForMessages(messageIds []int, userId int) (map[int]Poll, error) {
+	polls := make(map[int]Poll)
+	query := `SELECT message_id, question, multiple, anonymous, closes_at, NOT (` + pollOpen + `),
+			(SELECT count(DISTINCT user_id) FROM poll_votes v WHERE v.message_id = p.message_id)
+		FROM polls p WHERE message_id = ANY($1)`
+	rows, err := ps.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageId int
+		var closesAt sql.NullString
+		poll := Poll{Options: make([]PollOption, 0), MyVotes: make([]int, 0)}
+		err := rows.Scan(&messageId, &poll.Question, &poll.Multiple, &poll.Anonymous, &closesAt, &poll.Closed, &poll.VoterCount)
+		if err != nil {
+			return nil, err
+		}
+		poll.ClosesAt = closesAt.String
+		polls[messageId] = poll
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT o.message_id, o.id, o.text, count(v.user_id) FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		WHERE o.message_id = ANY($1)
+		GROUP BY o.id ORDER BY o.message_id, o."position"`
+	rows, err = ps.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	optionIndex := make(map[int]int)
+	for rows.Next() {
+		var messageId int
+		var option PollOption
+		if err := rows.Scan(&messageId, &option.Id, &option.Text, &option.Votes); err != nil {
+			return nil, err
+		}
+		poll := polls[messageId]
+		optionIndex[option.Id] = len(poll.Options)
+		poll.Options = append(poll.Options, option)
+		polls[messageId] = poll
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// votes of anonymous polls are only read for userId to know their own
+	query = `SELECT v.message_id, v.option_id, v.user_id FROM poll_votes v
+		JOIN polls p ON p.message_id = v.message_id
+		WHERE v.message_id = ANY($1) AND (NOT p.anonymous OR v.user_id = $2)
+		ORDER BY v.voted_at, v.user_id`
+	rows, err = ps.DB.Query(query, pq.Array(messageIds), userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageId, optionId, voterId int
+		if err := rows.Scan(&messageId, &optionId, &voterId); err != nil {
+			return nil, err
+		}
+		poll := polls[messageId]
+		if voterId == userId {
+			poll.MyVotes = append(poll.MyVotes, optionId)
+		}
+		if !poll.Anonymous {
+			option := &poll.Options[optionIndex[optionId]]
+			option.Voters = append(option.Voters, voterId)
+		}
+		polls[messageId] = poll
+	}
+	return polls, rows.Err()
+}
+
+// Vote records the votes of userId for optionIds of an open poll, replacing their
+// previous votes when replace is set. It reports false when the poll is closed.
+func (ps PollStorer) Vote(messageId, userId int, optionIds []int, replace bool) (bool, error) {
+	tx, err := ps.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// votes replacing others are taken one at a time so a single choice stays single
+	lock := "FOR SHARE"
+	if replace {
+		lock = "FOR UPDATE"
+	}
+	var open int
+	err = tx.QueryRow("SELECT 1 FROM polls WHERE message_id = $1 AND "+pollOpen+" "+lock, messageId).Scan(&open)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if replace {
+		_, err = tx.Exec("DELETE FROM poll_votes WHERE message_id = $1 AND user_id = $2", messageId, userId)
+		if err != nil {
+			return false, err
+		}
+	}
+	query := `INSERT INTO poll_votes (option_id, message_id, user_id)
+		SELECT id, message_id, $2 FROM poll_options WHERE message_id = $1 AND id = ANY($3)
+		ON CONFLICT DO NOTHING`
+	_, err = tx.Exec(query, messageId, userId, pq.Array(optionIds))
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Retract drops the votes of userId for optionIds, all of them when there are
+// none, from an open poll and returns how many there were.
+func (ps PollStorer) Retract(messageId, userId int, optionIds []int) (int64, error) {
+	query := `DELETE FROM poll_votes
+		WHERE message_id = $1 AND user_id = $2 AND (cardinality($3::integer[]) = 0 OR option_id = ANY($3))
+		AND message_id IN (SELECT message_id FROM polls WHERE message_id = $1 AND ` + pollOpen + ")"
+	result, err := ps.DB.Exec(query, messageId, userId, pq.Array(optionIds))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Close stops a poll from taking votes, it reports false when it already was.
+func (ps PollStorer) Close(messageId int) (bool, error) {
+	query := "UPDATE polls SET closed_at = now() WHERE message_id = $1 AND " + pollOpen
+	result, err := ps.DB.Exec(query, messageId)
+	if err != nil {
+		return false, err
+	}
+	closed, err := result.RowsAffected()
+	return closed > 0, err
+}
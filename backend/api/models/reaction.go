@@ -0,0 +1,186 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// ErrReactionLimit is returned when a reaction would take a message over its limits.
+var ErrReactionLimit = errors.New("the message can't take more reactions")
+
+type Reaction struct {
+	MessageId int    `json:"messageId"`
+	UserId    int    `json:"userId"`
+	Emoji     string `json:"emoji"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ReactionSummary aggregates reactions with the same emoji on a message
+// from the point of view of the user who requested it.
+type ReactionSummary struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reactedByMe"`
+}
+
+type ReactionFromRequest struct {
+	MessageId int    `json:"messageId"`
+	Emoji     string `json:"emoji"`
+}
+
+// ReactionEvent is what the chat is told when a reaction is added or removed,
+// Count is the number of reactions with Emoji left on the message.
+type ReactionEvent struct {
+	MessageId int    `json:"messageId"`
+	ChatId    int    `json:"chatId"`
+	UserId    int    `json:"userId"`
+	Emoji     string `json:"emoji"`
+	Count     int    `json:"count"`
+}
+
+type IReactionStorer interface {
+	Create(messageId, userId int, emoji string, maxEmojis, maxByUser int) (*Reaction, error)
+	Delete(messageId, userId int, emoji string) (*Reaction, error)
+	Count(messageId int, emoji string) (int, error)
+	CountForMessage(messageId, userId int) (emojis int, byUser int, err error)
+	GetForMessages(messageIds []int, userId int) (map[int][]ReactionSummary, error)
+	GetAllForMessages(messageIds []int) (map[int][]Reaction, error)
+}
+
+type ReactionStorer struct {
+	DB *sql.DB
+}
+
+func NewReactionStorer(db *sql.DB) ReactionStorer {
+	return ReactionStorer{DB: db}
+}
+
+// Create adds a reaction unless the message would get more than maxEmojis different
+// emojis or userId more than maxByUser reactions on it, then it returns ErrReactionLimit.
+// It returns sql.ErrNoRows when userId already reacted with emoji.
+func (rs ReactionStorer) Create(messageId, userId int, emoji string, maxEmojis, maxByUser int) (reaction *Reaction, err error) {
+	tx, err := rs.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
+	// reactions to a message are added one at a time, so the limits are counted
+	// with the ones added before in view
+	if _, err = tx.Exec("SELECT id FROM messages WHERE id = $1 FOR UPDATE", messageId); err != nil {
+		return nil, err
+	}
+
+	var exists, allowed bool
+	query := `SELECT
+			EXISTS (SELECT 1 FROM reactions WHERE message_id = $1 AND user_id = $2 AND emoji = $3),
+			(SELECT count(*) FROM reactions WHERE message_id = $1 AND user_id = $2) < $5
+			AND (
+				EXISTS (SELECT 1 FROM reactions WHERE message_id = $1 AND emoji = $3)
+				OR (SELECT count(DISTINCT emoji) FROM reactions WHERE message_id = $1) < $4
+			)`
+	err = tx.QueryRow(query, messageId, userId, emoji, maxEmojis, maxByUser).Scan(&exists, &allowed)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, sql.ErrNoRows
+	}
+	if !allowed {
+		return nil, ErrReactionLimit
+	}
+
+	reaction = &Reaction{}
+	query = `INSERT INTO reactions (message_id, user_id, emoji) VALUES ($1, $2, $3)
+		RETURNING message_id, user_id, emoji, created_at`
+	row := tx.QueryRow(query, messageId, userId, emoji)
+	err = row.Scan(&reaction.MessageId, &reaction.UserId, &reaction.Emoji, &reaction.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return reaction, nil
+}
+
+func (rs ReactionStorer) Delete(messageId, userId int, emoji string) (*Reaction, error) {
+	var reaction Reaction
+	query := `DELETE FROM reactions WHERE message_id = $1 AND user_id = $2 AND emoji = $3
+		RETURNING message_id, user_id, emoji, created_at`
+	row := rs.DB.QueryRow(query, messageId, userId, emoji)
+	err := row.Scan(&reaction.MessageId, &reaction.UserId, &reaction.Emoji, &reaction.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+func (rs ReactionStorer) Count(messageId int, emoji string) (int, error) {
+	var count int
+	query := "SELECT count(*) FROM reactions WHERE message_id = $1 AND emoji = $2"
+	err := rs.DB.QueryRow(query, messageId, emoji).Scan(&count)
+	return count, err
+}
+
+// CountForMessage returns how many different emojis messageId has been reacted with
+// and how many reactions userId left on it.
+func (rs ReactionStorer) CountForMessage(messageId, userId int) (emojis int, byUser int, err error) {
+	query := "SELECT count(DISTINCT emoji), count(*) FILTER (WHERE user_id = $2) FROM reactions WHERE message_id = $1"
+	err = rs.DB.QueryRow(query, messageId, userId).Scan(&emojis, &byUser)
+	return emojis, byUser, err
+}
+
+// GetForMessages summarizes reactions on messageIds, emojis are ordered by their first use.
+func (rs ReactionStorer) GetForMessages(messageIds []int, userId int) (map[int][]ReactionSummary, error) {
+	reactions := make(map[int][]ReactionSummary)
+	query := `SELECT message_id, emoji, count(*), bool_or(user_id = $2) FROM reactions
+		WHERE message_id = ANY($1)
+		GROUP BY message_id, emoji
+		ORDER BY message_id, min(created_at)`
+	rows, err := rs.DB.Query(query, pq.Array(messageIds), userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageId int
+		var summary ReactionSummary
+		err := rows.Scan(&messageId, &summary.Emoji, &summary.Count, &summary.ReactedByMe)
+		if err != nil {
+			return nil, err
+		}
+		reactions[messageId] = append(reactions[messageId], summary)
+	}
+	return reactions, rows.Err()
+}
+
+// GetAllForMessages returns every reaction on messageIds with who left it, oldest first.
+func (rs ReactionStorer) GetAllForMessages(messageIds []int) (map[int][]Reaction, error) {
+	reactions := make(map[int][]Reaction)
+	query := `SELECT message_id, user_id, emoji, created_at FROM reactions
+		WHERE message_id = ANY($1) ORDER BY message_id, created_at, user_id`
+	rows, err := rs.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reaction Reaction
+		err := rows.Scan(&reaction.MessageId, &reaction.UserId, &reaction.Emoji, &reaction.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reactions[reaction.MessageId] = append(reactions[reaction.MessageId], reaction)
+	}
+	return reactions, rows.Err()
+}
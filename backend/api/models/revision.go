@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Revision keeps the content a message had before it was edited at EditedAt.
+type Revision struct {
+	Id        int    `json:"id"`
+	MessageId int    `json:"messageId"`
+	Content   string `json:"content"`
+	EditedAt  string `json:"editedAt"`
+}
+
+type IRevisionStorer interface {
+	CreateInTx(tx *sql.Tx, messageId int, content string) (*Revision, error)
+	GetForMessage(messageId int) ([]Revision, error)
+	GetForMessages(messageIds []int) (map[int][]Revision, error)
+}
+
+type RevisionStorer struct {
+	DB *sql.DB
+}
+
+func NewRevisionStorer(db *sql.DB) RevisionStorer {
+	return RevisionStorer{DB: db}
+}
+
+func (rs RevisionStorer) CreateInTx(tx *sql.Tx, messageId int, content string) (*Revision, error) {
+	var revision Revision
+	query := "INSERT INTO message_revisions (message_id, content) VALUES ($1, $2) RETURNING id, message_id, content, edited_at"
+	row := tx.QueryRow(query, messageId, content)
+	err := row.Scan(&revision.Id, &revision.MessageId, &revision.Content, &revision.EditedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetForMessage returns revisions of messageId from the oldest one.
+func (rs RevisionStorer) GetForMessage(messageId int) ([]Revision, error) {
+	revisions := make([]Revision, 0)
+	query := "SELECT id, message_id, content, edited_at FROM message_revisions WHERE message_id = $1 ORDER BY edited_at, id"
+	rows, err := rs.DB.Query(query, messageId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var revision Revision
+		err := rows.Scan(&revision.Id, &revision.MessageId, &revision.Content, &revision.EditedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetForMessages returns revisions of messageIds, each from the oldest one.
+func (rs RevisionStorer) GetForMessages(messageIds []int) (map[int][]Revision, error) {
+	revisions := make(map[int][]Revision)
+	query := `SELECT id, message_id, content, edited_at FROM message_revisions
+		WHERE message_id = ANY($1) ORDER BY message_id, edited_at, id`
+	rows, err := rs.DB.Query(query, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var revision Revision
+		err := rows.Scan(&revision.Id, &revision.MessageId, &revision.Content, &revision.EditedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions[revision.MessageId] = append(revisions[revision.MessageId], revision)
+	}
+	return revisions, rows.Err()
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ScheduledMessage is a message waiting to be sent at SendAt. Messages that
+// can't be sent when due are kept with the Error that stopped them.
+type ScheduledMessage struct {
+	Id            int       `json:"id"`
+	SenderId      int       `json:"senderId"`
+	ChatId        int       `json:"chatId"`
+	Type          string    `json:"type"`
+	Content       string    `json:"content"`
+	Format        string    `json:"format,omitempty"`
+	ParentId      int       `json:"parentId,omitempty"`
+	AttachmentIds []int     `json:"attachmentIds"`
+	SendAt        time.Time `json:"sendAt"`
+	TimeZone      string    `json:"timeZone,omitempty"`
+	FailedAt      string    `json:"failedAt,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     string    `json:"createdAt"`
+}
+
+// ScheduledMessageFromRequest schedules a message. SendAt is an RFC 3339 time or,
+// with TimeZone set to an IANA name, a local time like "2023-05-01T09:00:00".
+type ScheduledMessageFromRequest struct {
+	ChatId        int    `json:"chatId"`
+	Type          string `json:"type"`
+	Content       string `json:"content"`
+	Format        string `json:"format"`
+	ParentId      int    `json:"parentId"`
+	AttachmentIds []int  `json:"attachmentIds"`
+	SendAt        string `json:"sendAt"`
+	TimeZone      string `json:"timeZone"`
+}
+
+// MessageFromRequest is the request the message is sent with when due.
+func (sm ScheduledMessage) MessageFromRequest() MessageFromRequest {
+	return MessageFromRequest{
+		ChatId:        sm.ChatId,
+		Type:          sm.Type,
+		Content:       sm.Content,
+		Format:        sm.Format,
+		ParentId:      sm.ParentId,
+		AttachmentIds: sm.AttachmentIds,
+	}
+}
+
+type IScheduledMessageStorer interface {
+	Create(msg ScheduledMessage) (*ScheduledMessage, error)
+	GetOne(id int) (*ScheduledMessage, error)
+	GetForSender(senderId, chatId int) ([]ScheduledMessage, error)
+	CountPending(senderId int) (int, error)
+	Update(msg ScheduledMessage) (*ScheduledMessage, error)
+	Delete(id int) (*ScheduledMessage, error)
+	ClaimDue(limit int, lease time.Duration) ([]ScheduledMessage, error)
+	FinishInTx(tx *sql.Tx, id int) error
+	Fail(id int, reason string) error
+}
+
+const scheduledMessageColumns = "id, sender_id, chat_id, type, content, format, parent_id, attachment_ids, send_at, time_zone, failed_at, error, created_at"
+
+// unclaimed matches the scheduled messages no worker is sending.
+const unclaimed = "(claimed_until IS NULL OR claimed_until < now())"
+
+func scanScheduledMessage(row rowScanner) (*ScheduledMessage, error) {
+	var msg ScheduledMessage
+	var parentId sql.NullInt64
+	var failedAt sql.NullString
+	attachmentIds := make([]int64, 0)
+	err := row.Scan(
+		&msg.Id,
+		&msg.SenderId,
+		&msg.ChatId,
+		&msg.Type,
+		&msg.Content,
+		&msg.Format,
+		&parentId,
+		pq.Array(&attachmentIds),
+		&msg.SendAt,
+		&msg.TimeZone,
+		&failedAt,
+		&msg.Error,
+		&msg.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	msg.ParentId = int(parentId.Int64)
+	msg.FailedAt = failedAt.String
+	msg.AttachmentIds = make([]int, len(attachmentIds))
+	for i, id := range attachmentIds {
+		msg.AttachmentIds[i] = int(id)
+	}
+	return &msg, nil
+}
+
+func scanScheduledMessages(rows *sql.Rows) ([]ScheduledMessage, error) {
+	defer rows.Close()
+	messages := make([]ScheduledMessage, 0)
+	for rows.Next() {
+		msg, err := scanScheduledMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *msg)
+	}
+	return messages, rows.Err()
+}
+
+type ScheduledMessageStorer struct {
+	DB *sql.DB
+}
+
+func NewScheduledMessageStorer(db *sql.DB) ScheduledMessageStorer {
+	return ScheduledMessageStorer{DB: db}
+}
+
+func (ss ScheduledMessageStorer) Create(msg ScheduledMessage) (*ScheduledMessage, error) {
+	query := `INSERT INTO scheduled_messages (sender_id, chat_id, type, content, format, parent_id, attachment_ids, send_at, time_zone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING ` + scheduledMessageColumns
+	row := ss.DB.QueryRow(
+		query,
+		msg.SenderId,
+		msg.ChatId,
+		msg.Type,
+		msg.Content,
+		msg.Format,
+		nullableId(msg.ParentId),
+		pq.Array(msg.AttachmentIds),
+		msg.SendAt,
+		msg.TimeZone,
+	)
+	return scanScheduledMessage(row)
+}
+
+func (ss ScheduledMessageStorer) GetOne(id int) (*ScheduledMessage, error) {
+	query := "SELECT " + scheduledMessageColumns + " FROM scheduled_messages WHERE id = $1"
+	row := ss.DB.QueryRow(query, id)
+	return scanScheduledMessage(row)
+}
+
+// GetForSender returns the messages senderId scheduled in chatId, in every chat
+// when chatId is 0, the earliest due first.
+func (ss ScheduledMessageStorer) GetForSender(senderId, chatId int) ([]ScheduledMessage, error) {
+	query := "SELECT " + scheduledMessageColumns + ` FROM scheduled_messages
+		WHERE sender_id = $1 AND ($2::integer = 0 OR chat_id = $2)
+		ORDER BY send_at, id`
+	rows, err := ss.DB.Query(query, senderId, chatId)
+	if err != nil {
+		return nil, err
+	}
+	return scanScheduledMessages(rows)
+}
+
+func (ss ScheduledMessageStorer) CountPending(senderId int) (int, error) {
+	var count int
+	query := "SELECT count(*) FROM scheduled_messages WHERE sender_id = $1 AND failed_at IS NULL"
+	err := ss.DB.QueryRow(query, senderId).Scan(&count)
+	return count, err
+}
+
+// Update changes a scheduled message no worker is sending, sql.ErrNoRows is
+// returned otherwise. Failed messages are scheduled again.
+func (ss ScheduledMessageStorer) Update(msg ScheduledMessage) (*ScheduledMessage, error) {
+	query := `UPDATE scheduled_messages SET content = $2, format = $6, attachment_ids = $3, send_at = $4, time_zone = $5, failed_at = NULL, error = ''
+		WHERE id = $1 AND ` + unclaimed + " RETURNING " + scheduledMessageColumns
+	row := ss.DB.QueryRow(query, msg.Id, msg.Content, pq.Array(msg.AttachmentIds), msg.SendAt, msg.TimeZone, msg.Format)
+	return scanScheduledMessage(row)
+}
+
+// Delete cancels a scheduled message no worker is sending, sql.ErrNoRows is returned otherwise.
+func (ss ScheduledMessageStorer) Delete(id int) (*ScheduledMessage, error) {
+	query := "DELETE FROM scheduled_messages WHERE id = $1 AND " + unclaimed + " RETURNING " + scheduledMessageColumns
+	row := ss.DB.QueryRow(query, id)
+	return scanScheduledMessage(row)
+}
+
+// ClaimDue leases up to limit due messages to the caller, the earliest due first.
+// Rows locked by another instance are skipped so each message is claimed once.
+func (ss ScheduledMessageStorer) ClaimDue(limit int, lease time.Duration) ([]ScheduledMessage, error) {
+	query := `UPDATE scheduled_messages SET claimed_until = now() + $2 * interval '1 second'
+		WHERE id IN (
+			SELECT id FROM scheduled_messages
+			WHERE send_at <= now() AND failed_at IS NULL AND ` + unclaimed + `
+			ORDER BY send_at LIMIT $1 FOR UPDATE SKIP LOCKED
+		) RETURNING ` + scheduledMessageColumns
+	rows, err := ss.DB.Query(query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return scanScheduledMessages(rows)
+}
+
+// FinishInTx forgets a scheduled message in the transaction it is sent in. It returns
+// sql.ErrNoRows when the message is already gone, sent or deleted by its sender.
+func (ss ScheduledMessageStorer) FinishInTx(tx *sql.Tx, id int) error {
+	result, err := tx.Exec("DELETE FROM scheduled_messages WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Fail keeps a message that can't be sent with the reason, it isn't retried until updated.
+func (ss ScheduledMessageStorer) Fail(id int, reason string) error {
+	query := "UPDATE scheduled_messages SET claimed_until = NULL, failed_at = now(), error = $2 WHERE id = $1"
+	_, err := ss.DB.Exec(query, id, reason)
+	return err
+}
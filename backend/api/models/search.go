@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Highlighted terms come back from ts_headline between these control characters,
+// which keeps markup out of the query and lets content be escaped around them.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// SearchQuery filters messages of the chats a user participates in.
+// Zero values don't filter.
+type SearchQuery struct {
+	Text     string
+	ChatId   int
+	SenderId int
+	Type     string
+	From     time.Time
+	To       time.Time
+	Page     int
+	Limit    int
+}
+
+type SearchResult struct {
+	Message   Message `json:"message"`
+	Rank      float64 `json:"rank"`
+	Highlight string  `json:"highlight"`
+}
+
+// Search ranks messages matching query.Text in websearch syntax. Deleted, system
+// and hidden messages are left out. Every edit or deletion rewrites the generated
+// search column, so the index always matches the current content.
+func (cs MessageStorer) Search(userId int, query SearchQuery) ([]SearchResult, error) {
+	args := []any{userId, query.Text}
+	conditions := []string{
+		"m.chat_id IN (SELECT chat_id FROM participants WHERE user_id = $1)",
+		"m.id NOT IN (SELECT message_id FROM hidden_messages WHERE user_id = $1)",
+		"m.deleted_at IS NULL",
+		"m.type <> 'system'",
+		"m.search @@ q",
+	}
+	filter := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if query.ChatId != 0 {
+		filter("m.chat_id = $%d", query.ChatId)
+	}
+	if query.SenderId != 0 {
+		filter("m.sender_id = $%d", query.SenderId)
+	}
+	if query.Type != "" {
+		filter("m.type = $%d", query.Type)
+	}
+	if !query.From.IsZero() {
+		filter("m.created_at >= $%d", query.From)
+	}
+	if !query.To.IsZero() {
+		filter("m.created_at < $%d", query.To)
+	}
+	args = append(args, query.Limit, query.Page*query.Limit)
+
+	columns := strings.ReplaceAll("m."+messageColumns, ", ", ", m.")
+	sqlQuery := fmt.Sprintf(`SELECT %s, ts_rank(m.search, q) AS rank,
+			ts_headline('simple', m.content, q, 'StartSel=%s, StopSel=%s, MaxFragments=3, MinWords=5, MaxWords=20')
+		FROM messages m, websearch_to_tsquery('simple', $2) q
+		WHERE %s
+		ORDER BY rank DESC, m.created_at DESC, m.id DESC
+		LIMIT $%d OFFSET $%d`,
+		columns, HighlightStart, HighlightStop, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := cs.DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]SearchResult, 0)
+	for rows.Next() {
+		var result SearchResult
+		var rank float64
+		var highlight string
+		message, err := scanMessage(withExtra(rows, &rank, &highlight))
+		if err != nil {
+			return nil, err
+		}
+		result.Message = *message
+		result.Rank = rank
+		result.Highlight = highlight
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// extraScanner scans columns selected after the message columns into extra.
+type extraScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func withExtra(row rowScanner, extra ...any) rowScanner {
+	return extraScanner{row: row, extra: extra}
+}
+
+func (es extraScanner) Scan(dest ...any) error {
+	return es.row.Scan(append(dest, es.extra...)...)
+}
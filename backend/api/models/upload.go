@@ -0,0 +1,155 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Upload is a resumable upload session. Its data arrives in chunks kept in
+// blob storage until the upload is complete and is turned into an attachment.
+type Upload struct {
+	Id           string    `json:"id"`
+	UploaderId   int       `json:"uploaderId"`
+	ChatId       int       `json:"chatId"`
+	FileName     string    `json:"fileName"`
+	Length       int64     `json:"length"`
+	Offset       int64     `json:"offset"`
+	Chunks       []string  `json:"-"`
+	AttachmentId int       `json:"attachmentId,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	CreatedAt    string    `json:"createdAt"`
+}
+
+type UploadDTO struct {
+	Id         string
+	UploaderId int
+	ChatId     int
+	FileName   string
+	Length     int64
+}
+
+type IUploadStorer interface {
+	Create(dto UploadDTO, ttl time.Duration) (*Upload, error)
+	GetOne(id string) (*Upload, error)
+	Append(id string, offset, size int64, chunk string, ttl time.Duration) (*Upload, error)
+	Claim(id string, ttl time.Duration) (*Upload, error)
+	Release(id string) error
+	FinishInTx(tx *sql.Tx, id string, attachmentId int) error
+	Begin() (*sql.Tx, error)
+	Delete(id string) (*Upload, error)
+	DeleteExpired() ([]Upload, error)
+}
+
+const uploadColumns = "id, uploader_id, chat_id, file_name, upload_length, upload_offset, chunks, attachment_id, expires_at, created_at"
+
+func scanUpload(row rowScanner) (*Upload, error) {
+	var upload Upload
+	var attachmentId sql.NullInt64
+	err := row.Scan(
+		&upload.Id,
+		&upload.UploaderId,
+		&upload.ChatId,
+		&upload.FileName,
+		&upload.Length,
+		&upload.Offset,
+		pq.Array(&upload.Chunks),
+		&attachmentId,
+		&upload.ExpiresAt,
+		&upload.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	upload.AttachmentId = int(attachmentId.Int64)
+	return &upload, nil
+}
+
+type UploadStorer struct {
+	DB *sql.DB
+}
+
+func NewUploadStorer(db *sql.DB) UploadStorer {
+	return UploadStorer{DB: db}
+}
+
+func (us UploadStorer) Create(dto UploadDTO, ttl time.Duration) (*Upload, error) {
+	query := `INSERT INTO uploads (id, uploader_id, chat_id, file_name, upload_length, expires_at)
+		VALUES ($1, $2, $3, $4, $5, now() + $6 * interval '1 second') RETURNING ` + uploadColumns
+	row := us.DB.QueryRow(query, dto.Id, dto.UploaderId, dto.ChatId, dto.FileName, dto.Length, ttl.Seconds())
+	return scanUpload(row)
+}
+
+func (us UploadStorer) GetOne(id string) (*Upload, error) {
+	query := "SELECT " + uploadColumns + " FROM uploads WHERE id = $1 AND expires_at > now()"
+	row := us.DB.QueryRow(query, id)
+	return scanUpload(row)
+}
+
+// Append records a chunk of size bytes received at offset and extends the
+// upload's expiry. It returns sql.ErrNoRows when the upload is no longer at
+// offset, so of two concurrent appends only one is recorded.
+func (us UploadStorer) Append(id string, offset, size int64, chunk string, ttl time.Duration) (*Upload, error) {
+	query := `UPDATE uploads SET
+			upload_offset = upload_offset + $3,
+			chunks = array_append(chunks, $4),
+			expires_at = now() + $5 * interval '1 second'
+		WHERE id = $1 AND upload_offset = $2 AND upload_offset + $3 <= upload_length
+			AND claimed_at IS NULL AND expires_at > now()
+		RETURNING ` + uploadColumns
+	row := us.DB.QueryRow(query, id, offset, size, chunk, ttl.Seconds())
+	return scanUpload(row)
+}
+
+// Claim marks a fully received upload as being turned into an attachment,
+// it returns sql.ErrNoRows when the upload is incomplete or already claimed.
+func (us UploadStorer) Claim(id string, ttl time.Duration) (*Upload, error) {
+	query := `UPDATE uploads SET claimed_at = now(), expires_at = now() + $2 * interval '1 second'
+		WHERE id = $1 AND claimed_at IS NULL AND upload_offset = upload_length AND expires_at > now()
+		RETURNING ` + uploadColumns
+	row := us.DB.QueryRow(query, id, ttl.Seconds())
+	return scanUpload(row)
+}
+
+func (us UploadStorer) Release(id string) error {
+	_, err := us.DB.Exec("UPDATE uploads SET claimed_at = NULL WHERE id = $1 AND attachment_id IS NULL", id)
+	return err
+}
+
+// FinishInTx links a claimed upload to the attachment made of it in the transaction the
+// attachment is created in, the chunks are forgotten as the attachment has its own copy.
+func (us UploadStorer) FinishInTx(tx *sql.Tx, id string, attachmentId int) error {
+	_, err := tx.Exec("UPDATE uploads SET attachment_id = $2, chunks = '{}' WHERE id = $1", id, attachmentId)
+	return err
+}
+
+func (us UploadStorer) Begin() (*sql.Tx, error) {
+	return us.DB.Begin()
+}
+
+func (us UploadStorer) Delete(id string) (*Upload, error) {
+	query := "DELETE FROM uploads WHERE id = $1 AND claimed_at IS NULL RETURNING " + uploadColumns
+	row := us.DB.QueryRow(query, id)
+	return scanUpload(row)
+}
+
+// DeleteExpired removes the uploads that weren't touched in time and returns them
+// so their chunks can be deleted. Each upload is returned by one call only.
+func (us UploadStorer) DeleteExpired() ([]Upload, error) {
+	query := "DELETE FROM uploads WHERE expires_at <= now() RETURNING " + uploadColumns
+	rows, err := us.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	uploads := make([]Upload, 0)
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, *upload)
+	}
+	return uploads, rows.Err()
+}
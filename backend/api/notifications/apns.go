@@ -0,0 +1,148 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	APNsProductionURL = "https://api.push.apple.com"
+	APNsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// apple rejects provider tokens older than an hour and
+	// throttles the ones refreshed more often than every 20 minutes
+	apnsTokenLifetime = 40 * time.Minute
+)
+
+var apnsInvalidTokenReasons = []string{"BadDeviceToken", "Unregistered", "DeviceTokenNotForTopic"}
+
+// APNsProvider sends notifications to Apple devices through the
+// HTTP/2 provider API, authenticating with a token signing key.
+type APNsProvider struct {
+	Client   *http.Client
+	BaseURL  string
+	topic    string
+	teamId   string
+	keyId    string
+	key      *ecdsa.PrivateKey
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNsProvider takes the PEM encoded .p8 signing key, its key id,
+// the developer team id and the app bundle id used as the push topic.
+func NewAPNsProvider(keyPEM []byte, keyId, teamId, topic, baseURL string) (*APNsProvider, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("bad APNs signing key: %w", err)
+	}
+	return &APNsProvider{
+		Client:  http.DefaultClient,
+		BaseURL: baseURL,
+		topic:   topic,
+		teamId:  teamId,
+		keyId:   keyId,
+		key:     key,
+	}, nil
+}
+
+func (p *APNsProvider) Platform() string {
+	return "apns"
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type apnsAps struct {
+	Alert    apnsAlert `json:"alert"`
+	Sound    string    `json:"sound"`
+	ThreadId string    `json:"thread-id"`
+}
+
+type apnsPayload struct {
+	Aps       apnsAps `json:"aps"`
+	ChatId    int     `json:"chatId"`
+	MessageId int     `json:"messageId"`
+}
+
+type apnsError struct {
+	Reason string `json:"reason"`
+}
+
+func (p *APNsProvider) Send(token string, notification Notification) error {
+	payload := apnsPayload{
+		Aps: apnsAps{
+			Alert:    apnsAlert{Title: notification.Title, Body: notification.Body},
+			Sound:    "default",
+			ThreadId: fmt.Sprintf("chat-%d", notification.ChatId),
+		},
+		ChatId:    notification.ChatId,
+		MessageId: notification.MessageId,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	providerToken, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/3/device/%s", p.BaseURL, token)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var apnsErr apnsError
+	_ = json.NewDecoder(resp.Body).Decode(&apnsErr)
+	if resp.StatusCode == http.StatusGone || slices.Contains(apnsInvalidTokenReasons, apnsErr.Reason) {
+		return ErrInvalidToken
+	}
+	return fmt.Errorf("apns: %s %s", resp.Status, apnsErr.Reason)
+}
+
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenLifetime {
+		return p.token, nil
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.teamId,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.keyId
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	p.token, p.issuedAt = signed, now
+	return signed, nil
+}
@@ -0,0 +1,138 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+const QueueSize = 1024
+
+// Dispatcher notifies chat participants that have no live socket
+// about new messages through push providers of their devices.
+type Dispatcher struct {
+	ChatStorer        models.IChatStorer
+	ParticipantStorer models.IParticipantStorer
+	DeviceStorer      models.IDeviceStorer
+	IsOnline          func(userId int) bool
+	providers         map[string]Provider
+	queue             chan models.Message
+}
+
+func NewDispatcher(chatStorer models.IChatStorer, participantStorer models.IParticipantStorer, deviceStorer models.IDeviceStorer, isOnline func(userId int) bool, providers ...Provider) *Dispatcher {
+	d := &Dispatcher{
+		ChatStorer:        chatStorer,
+		ParticipantStorer: participantStorer,
+		DeviceStorer:      deviceStorer,
+		IsOnline:          isOnline,
+		providers:         make(map[string]Provider),
+		queue:             make(chan models.Message, QueueSize),
+	}
+	for _, provider := range providers {
+		d.providers[provider.Platform()] = provider
+	}
+	return d
+}
+
+// Start launches workers that dispatch messages queued by Notify.
+func (d *Dispatcher) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for msg := range d.queue {
+				if err := d.Dispatch(msg); err != nil {
+					log.Printf("error while dispatching notifications for message %d: %v\n", msg.Id, err)
+				}
+			}
+		}()
+	}
+}
+
+// Notify queues msg for dispatching without blocking the caller.
+func (d *Dispatcher) Notify(msg models.Message) {
+	select {
+	case d.queue <- msg:
+	default:
+		log.Printf("notification queue is full, dropping notifications for message %d\n", msg.Id)
+	}
+}
+
+func (d *Dispatcher) Dispatch(msg models.Message) error {
+	if msg.Type == "system" {
+		return nil
+	}
+
+	chat, err := d.ChatStorer.GetOne(msg.ChatId)
+	if err != nil {
+		return err
+	}
+
+	chatUsers, err := d.ParticipantStorer.GetChatUsers(msg.ChatId)
+	if err != nil {
+		return err
+	}
+
+	senderName := ""
+	for _, chatUser := range chatUsers {
+		if chatUser.UserId == msg.SenderId {
+			senderName = chatUser.Name
+		}
+	}
+	notification := Render(*chat, senderName, msg)
+	mentionNotification := RenderMention(*chat, senderName, msg)
+
+	for _, chatUser := range chatUsers {
+		if chatUser.UserId == msg.SenderId || d.IsOnline(chatUser.UserId) {
+			continue
+		}
+		// mentions get through even if the chat is muted
+		var err error
+		switch {
+		case isMentioned(msg, chatUser.UserId):
+			err = d.Deliver(chatUser.UserId, mentionNotification)
+		case !chatUser.Muted:
+			err = d.Deliver(chatUser.UserId, notification)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return nil
+}
+
+func isMentioned(msg models.Message, userId int) bool {
+	for _, mention := range msg.Mentions {
+		if mention.All || mention.UserId == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// Deliver sends notification to every device of userId that has a provider.
+// Devices whose tokens got rejected by their provider are unregistered.
+func (d *Dispatcher) Deliver(userId int, notification Notification) error {
+	devices, err := d.DeviceStorer.GetUserDevices(userId)
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		provider, ok := d.providers[device.Platform]
+		if !ok {
+			continue
+		}
+		err := provider.Send(device.Token, notification)
+		if errors.Is(err, ErrInvalidToken) {
+			if _, err := d.DeviceStorer.Delete(device.Id); err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		if err != nil {
+			log.Println(fmt.Errorf("error while sending notification to device %d of user %d: %w", device.Id, userId, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package notifications_test
+
+import (
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/notifications"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchNotifiesOfflineUnmutedUsers(t *testing.T) {
+	//Arrange
+	chat := models.Chat{Id: 1, Title: "test-chat", CreatorId: 1, CreatedAt: "2023-06-27"}
+	msg := models.Message{Id: 5, SenderId: 1, ChatId: chat.Id, Type: "text", Content: "hello"}
+	chatUsers := []models.ChatUser{
+		{Participant: models.Participant{UserId: 1, ChatId: chat.Id, Role: "admin"}, Name: "sender"},
+		{Participant: models.Participant{UserId: 2, ChatId: chat.Id, Role: "member"}, Name: "offline"},
+		{Participant: models.Participant{UserId: 3, ChatId: chat.Id, Role: "member"}, Name: "online"},
+		{Participant: models.Participant{UserId: 4, ChatId: chat.Id, Role: "member", Muted: true}, Name: "muted"},
+	}
+	devices := []models.Device{
+		{Id: 1, UserId: 2, Platform: "fcm", Token: "fcm-token"},
+		{Id: 2, UserId: 2, Platform: "apns", Token: "apns-token"},
+	}
+	expectedNotification := notifications.Notification{
+		Title:     chat.Title,
+		Body:      "sender: hello",
+		ChatId:    chat.Id,
+		MessageId: msg.Id,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChatStorer := models_mocks.NewMockIChatStorer(ctrl)
+	mockChatStorer.EXPECT().GetOne(chat.Id).Return(&chat, nil)
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.EXPECT().GetChatUsers(chat.Id).Return(chatUsers, nil)
+
+	mockDeviceStorer := models_mocks.NewMockIDeviceStorer(ctrl)
+	mockDeviceStorer.EXPECT().GetUserDevices(2).Return(devices, nil)
+
+	fcm := notifications.NewFakeProvider("fcm")
+	isOnline := func(userId int) bool { return userId == 3 }
+	dispatcher := notifications.NewDispatcher(mockChatStorer, mockParticipantStorer, mockDeviceStorer, isOnline, fcm)
+
+	//Act
+	err := dispatcher.Dispatch(msg)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []notifications.FakeDelivery{{Token: "fcm-token", Notification: expectedNotification}}, fcm.Deliveries())
+}
+
+func TestDeliverUnregistersInvalidTokens(t *testing.T) {
+	//Arrange
+	userId := 2
+	devices := []models.Device{{Id: 7, UserId: userId, Platform: "webpush", Token: "stale"}}
+	notification := notifications.Notification{Title: "test-chat", Body: "sender: hello", ChatId: 1, MessageId: 5}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDeviceStorer := models_mocks.NewMockIDeviceStorer(ctrl)
+	mockDeviceStorer.EXPECT().GetUserDevices(userId).Return(devices, nil)
+	mockDeviceStorer.EXPECT().Delete(devices[0].Id).Return(&devices[0], nil)
+
+	webpush := notifications.NewFakeProvider("webpush")
+	webpush.InvalidTokens = []string{"stale"}
+	dispatcher := notifications.NewDispatcher(nil, nil, mockDeviceStorer, nil, webpush)
+
+	//Act
+	err := dispatcher.Deliver(userId, notification)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Empty(t, webpush.Deliveries())
+}
+
+func TestDispatchSkipsSystemMessages(t *testing.T) {
+	//Arrange
+	msg := models.Message{Id: 5, SenderId: 1, ChatId: 1, Type: "system", Content: "{}"}
+	dispatcher := notifications.NewDispatcher(nil, nil, nil, nil)
+
+	//Act
+	err := dispatcher.Dispatch(msg)
+
+	//Assert
+	assert.Nil(t, err)
+}
+
+func TestDispatchMentionBypassesMute(t *testing.T) {
+	//Arrange
+	chat := models.Chat{Id: 1, Title: "test-chat", CreatorId: 1, CreatedAt: "2023-06-27"}
+	msg := models.Message{
+		Id:       5,
+		SenderId: 1,
+		ChatId:   chat.Id,
+		Type:     "text",
+		Content:  "@muted look",
+		Mentions: []models.Mention{{UserId: 2, Offset: 0, Length: 6}},
+	}
+	chatUsers := []models.ChatUser{
+		{Participant: models.Participant{UserId: 1, ChatId: chat.Id, Role: "admin"}, Name: "sender"},
+		{Participant: models.Participant{UserId: 2, ChatId: chat.Id, Role: "member", Muted: true}, Name: "muted", Tag: "muted"},
+	}
+	devices := []models.Device{{Id: 1, UserId: 2, Platform: "apns", Token: "apns-token"}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChatStorer := models_mocks.NewMockIChatStorer(ctrl)
+	mockChatStorer.EXPECT().GetOne(chat.Id).Return(&chat, nil)
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.EXPECT().GetChatUsers(chat.Id).Return(chatUsers, nil)
+
+	mockDeviceStorer := models_mocks.NewMockIDeviceStorer(ctrl)
+	mockDeviceStorer.EXPECT().GetUserDevices(2).Return(devices, nil)
+
+	apns := notifications.NewFakeProvider("apns")
+	isOnline := func(userId int) bool { return false }
+	dispatcher := notifications.NewDispatcher(mockChatStorer, mockParticipantStorer, mockDeviceStorer, isOnline, apns)
+
+	//Act
+	err := dispatcher.Dispatch(msg)
+
+	//Assert
+	assert.Nil(t, err)
+	if assert.Len(t, apns.Deliveries(), 1) {
+		assert.Equal(t, "sender mentioned you: @muted look", apns.Deliveries()[0].Notification.Body)
+	}
+}
@@ -0,0 +1,45 @@
+package notifications
+
+import (
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+type FakeDelivery struct {
+	Token        string
+	Notification Notification
+}
+
+// FakeProvider keeps notifications in memory instead of delivering them.
+// Tokens listed in InvalidTokens are rejected with ErrInvalidToken.
+type FakeProvider struct {
+	mu            sync.Mutex
+	platform      string
+	deliveries    []FakeDelivery
+	InvalidTokens []string
+}
+
+func NewFakeProvider(platform string) *FakeProvider {
+	return &FakeProvider{platform: platform}
+}
+
+func (p *FakeProvider) Platform() string {
+	return p.platform
+}
+
+func (p *FakeProvider) Send(token string, notification Notification) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if slices.Contains(p.InvalidTokens, token) {
+		return ErrInvalidToken
+	}
+	p.deliveries = append(p.deliveries, FakeDelivery{token, notification})
+	return nil
+}
+
+func (p *FakeProvider) Deliveries() []FakeDelivery {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.deliveries)
+}
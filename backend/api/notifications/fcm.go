@@ -0,0 +1,183 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	FCMURL   = "https://fcm.googleapis.com"
+	fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FCMServiceAccount is the part of a Google service account key file FCM needs.
+type FCMServiceAccount struct {
+	ProjectId   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider sends notifications through the Firebase Cloud Messaging
+// HTTP v1 API, trading service account assertions for access tokens.
+type FCMProvider struct {
+	Client      *http.Client
+	BaseURL     string
+	account     FCMServiceAccount
+	key         *rsa.PrivateKey
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCMProvider(serviceAccountJSON []byte, baseURL string) (*FCMProvider, error) {
+	var account FCMServiceAccount
+	if err := json.Unmarshal(serviceAccountJSON, &account); err != nil {
+		return nil, fmt.Errorf("bad FCM service account: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("bad FCM service account key: %w", err)
+	}
+	return &FCMProvider{
+		Client:  http.DefaultClient,
+		BaseURL: baseURL,
+		account: account,
+		key:     key,
+	}, nil
+}
+
+func (p *FCMProvider) Platform() string {
+	return "fcm"
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data"`
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (p *FCMProvider) Send(token string, notification Notification) error {
+	fcmReq := fcmRequest{
+		Message: fcmMessage{
+			Token:        token,
+			Notification: fcmNotification{Title: notification.Title, Body: notification.Body},
+			Data: map[string]string{
+				"chatId":    strconv.Itoa(notification.ChatId),
+				"messageId": strconv.Itoa(notification.MessageId),
+			},
+		},
+	}
+	body, err := json.Marshal(fcmReq)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := p.getAccessToken()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.BaseURL, p.account.ProjectId)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var errResp fcmError
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrInvalidToken
+	}
+	for _, detail := range errResp.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return ErrInvalidToken
+		}
+	}
+	return fmt.Errorf("fcm: %s %s", resp.Status, errResp.Error.Status)
+}
+
+type oauthToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (p *FCMProvider) getAccessToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	resp, err := p.Client.PostForm(p.account.TokenURI, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm: token endpoint responded with %s", resp.Status)
+	}
+
+	var token oauthToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	// refresh a minute early so requests never race the expiry
+	p.accessToken = token.AccessToken
+	p.expiresAt = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return p.accessToken, nil
+}
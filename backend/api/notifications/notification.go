@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+const maxBodyLength = 200
+
+// ErrInvalidToken is returned by providers when a device token was
+// rejected for good, so the device must be forgotten.
+var ErrInvalidToken = errors.New("device token is no longer valid")
+
+type Notification struct {
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	ChatId    int    `json:"chatId"`
+	MessageId int    `json:"messageId"`
+}
+
+// Provider delivers notifications to devices of a single platform.
+type Provider interface {
+	Platform() string
+	Send(token string, notification Notification) error
+}
+
+func Render(chat models.Chat, senderName string, msg models.Message) Notification {
+	var body string
+	switch msg.Type {
+	case "image":
+		body = fmt.Sprintf("%s sent an image", senderName)
+	case "video":
+		body = fmt.Sprintf("%s sent a video", senderName)
+	case "audio":
+		body = fmt.Sprintf("%s sent a voice message", senderName)
+	default:
+		body = fmt.Sprintf("%s: %s", senderName, msg.Content)
+	}
+	return Notification{
+		Title:     chat.Title,
+		Body:      truncate(body, maxBodyLength),
+		ChatId:    msg.ChatId,
+		MessageId: msg.Id,
+	}
+}
+
+// RenderMention is like Render, but tells the user they were mentioned.
+func RenderMention(chat models.Chat, senderName string, msg models.Message) Notification {
+	notification := Render(chat, senderName, msg)
+	notification.Body = truncate(fmt.Sprintf("%s mentioned you: %s", senderName, msg.Content), maxBodyLength)
+	return notification
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length-1]) + "…"
+}
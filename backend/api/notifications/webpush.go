@@ -0,0 +1,208 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	webPushTTL        = 24 * time.Hour
+	webPushRecordSize = 4096
+)
+
+// WebPushSubscription is the PushSubscription JSON a browser hands out,
+// it is stored as the token of "webpush" devices.
+type WebPushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// WebPushProvider sends notifications through the Web Push protocol (RFC 8030)
+// with VAPID authentication (RFC 8292) and aes128gcm payload encryption (RFC 8291).
+type WebPushProvider struct {
+	Client     *http.Client
+	subscriber string
+	privateKey *ecdsa.PrivateKey
+	publicKey  []byte
+}
+
+// NewWebPushProvider takes a "mailto:" or "https:" subscriber contact and
+// the VAPID private key as base64url encoded P-256 scalar.
+func NewWebPushProvider(subscriber, privateKey string) (*WebPushProvider, error) {
+	d, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("bad VAPID private key: %w", err)
+	}
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d)
+	return &WebPushProvider{
+		Client:     http.DefaultClient,
+		subscriber: subscriber,
+		privateKey: key,
+		publicKey:  elliptic.Marshal(curve, key.PublicKey.X, key.PublicKey.Y),
+	}, nil
+}
+
+func (p *WebPushProvider) Platform() string {
+	return "webpush"
+}
+
+// PublicKey is the applicationServerKey browsers need to subscribe.
+func (p *WebPushProvider) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(p.publicKey)
+}
+
+func (p *WebPushProvider) Send(token string, notification Notification) error {
+	var sub WebPushSubscription
+	if err := json.Unmarshal([]byte(token), &sub); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" && endpoint.Scheme != "http" {
+		return fmt.Errorf("%w: bad endpoint %q", ErrInvalidToken, sub.Endpoint)
+	}
+	uaPublic, err := decodeBase64URL(sub.Keys.P256dh)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if x, _ := elliptic.Unmarshal(elliptic.P256(), uaPublic); x == nil {
+		return fmt.Errorf("%w: p256dh isn't an uncompressed P-256 point", ErrInvalidToken)
+	}
+	authSecret, err := decodeBase64URL(sub.Keys.Auth)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	body, err := encryptWebPush(uaPublic, authSecret, payload)
+	if err != nil {
+		return err
+	}
+
+	claims := jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": p.subscriber,
+	}
+	vapid, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(p.privateKey)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", vapid, p.PublicKey()))
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", fmt.Sprint(int(webPushTTL.Seconds())))
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrInvalidToken
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("webpush: push service responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// encryptWebPush encrypts payload for the user agent key pair as a single
+// aes128gcm record, prefixed with the header that carries our ephemeral key.
+func encryptWebPush(uaPublic, authSecret, payload []byte) ([]byte, error) {
+	curve := elliptic.P256()
+	uaX, uaY := elliptic.Unmarshal(curve, uaPublic)
+	if uaX == nil {
+		return nil, errors.New("p256dh isn't an uncompressed P-256 point")
+	}
+
+	asPrivate, asX, asY, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := elliptic.Marshal(curve, asX, asY)
+	sharedX, _ := curve.ScalarMult(uaX, uaY, asPrivate)
+	ecdhSecret := sharedX.FillBytes(make([]byte, 32))
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := hkdf(authSecret, ecdhSecret, keyInfo, 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 marks the last (and only) record, no padding follows it
+	plaintext := append(append([]byte{}, payload...), 2)
+	if len(plaintext)+gcm.Overhead() > webPushRecordSize {
+		return nil, errors.New("payload is too large for a single record")
+	}
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdf is HKDF-SHA-256 (RFC 5869) for output no longer than one hash.
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,174 @@
+package notifications
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decryptWebPush is what a user agent does with an aes128gcm push message.
+func decryptWebPush(t *testing.T, uaPrivate, uaPublic, authSecret, body []byte) []byte {
+	salt := body[:16]
+	recordSize := binary.BigEndian.Uint32(body[16:20])
+	idLen := int(body[20])
+	asPublic := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+	assert.Equal(t, uint32(webPushRecordSize), recordSize)
+
+	curve := elliptic.P256()
+	asX, asY := elliptic.Unmarshal(curve, asPublic)
+	sharedX, _ := curve.ScalarMult(asX, asY, uaPrivate)
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := hkdf(authSecret, sharedX.FillBytes(make([]byte, 32)), keyInfo, 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("an error '%s' occured while decrypting push message", err)
+	}
+	assert.Equal(t, byte(2), plaintext[len(plaintext)-1])
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestWebPushSendEncryptsPayload(t *testing.T) {
+	//Arrange
+	curve := elliptic.P256()
+	uaPrivate, uaX, uaY, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPublic := elliptic.Marshal(curve, uaX, uaY)
+	authSecret := make([]byte, 16)
+	_, _ = rand.Read(authSecret)
+
+	vapidKey := make([]byte, 32)
+	_, _ = rand.Read(vapidKey)
+	provider, err := NewWebPushProvider("mailto:admin@example.com", base64.RawURLEncoding.EncodeToString(vapidKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notification := Notification{Title: "test-chat", Body: "sender: hello", ChatId: 1, MessageId: 5}
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sub := WebPushSubscription{Endpoint: server.URL + "/push/abc"}
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(uaPublic)
+	sub.Keys.Auth = base64.URLEncoding.EncodeToString(authSecret)
+	token, _ := json.Marshal(sub)
+
+	//Act
+	err = provider.Send(string(token), notification)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "aes128gcm", header.Get("Content-Encoding"))
+	assert.True(t, strings.HasPrefix(header.Get("Authorization"), "vapid t="))
+	assert.True(t, strings.HasSuffix(header.Get("Authorization"), fmt.Sprintf(", k=%s", provider.PublicKey())))
+	var actual Notification
+	err = json.Unmarshal(decryptWebPush(t, uaPrivate, uaPublic, authSecret, body), &actual)
+	assert.Nil(t, err)
+	assert.Equal(t, notification, actual)
+}
+
+func TestWebPushSendGoneSubscription(t *testing.T) {
+	//Arrange
+	curve := elliptic.P256()
+	_, uaX, uaY, _ := elliptic.GenerateKey(curve, rand.Reader)
+	vapidKey := make([]byte, 32)
+	_, _ = rand.Read(vapidKey)
+	provider, err := NewWebPushProvider("mailto:admin@example.com", base64.RawURLEncoding.EncodeToString(vapidKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	sub := WebPushSubscription{Endpoint: server.URL}
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(elliptic.Marshal(curve, uaX, uaY))
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(make([]byte, 16))
+	token, _ := json.Marshal(sub)
+
+	//Act
+	err = provider.Send(string(token), Notification{})
+
+	//Assert
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func TestWebPushSendBadKeyIsInvalidToken(t *testing.T) {
+	//Arrange
+	vapidKey := make([]byte, 32)
+	_, _ = rand.Read(vapidKey)
+	provider, err := NewWebPushProvider("mailto:admin@example.com", base64.RawURLEncoding.EncodeToString(vapidKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := WebPushSubscription{Endpoint: "https://push.example.com/sub"}
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(make([]byte, 65))
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(make([]byte, 16))
+	token, _ := json.Marshal(sub)
+
+	//Act
+	err = provider.Send(string(token), Notification{})
+
+	//Assert
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestWebPushSendTooLargePayloadKeepsToken(t *testing.T) {
+	//Arrange
+	curve := elliptic.P256()
+	_, uaX, uaY, _ := elliptic.GenerateKey(curve, rand.Reader)
+	vapidKey := make([]byte, 32)
+	_, _ = rand.Read(vapidKey)
+	provider, err := NewWebPushProvider("mailto:admin@example.com", base64.RawURLEncoding.EncodeToString(vapidKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := WebPushSubscription{Endpoint: "https://push.example.com/sub"}
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(elliptic.Marshal(curve, uaX, uaY))
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(make([]byte, 16))
+	token, _ := json.Marshal(sub)
+
+	//Act
+	err = provider.Send(string(token), Notification{Body: strings.Repeat("a", webPushRecordSize)})
+
+	//Assert
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+}
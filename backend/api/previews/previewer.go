@@ -0,0 +1,148 @@
+// Package previews attaches previews of the pages messages link to. Pages are
+// fetched in the background and previews are cached by link, failures too.
+package previews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+const (
+	QUEUE_SIZE       = 256
+	CACHE_TTL        = 24 * time.Hour
+	FAILED_CACHE_TTL = time.Hour
+	CLEANUP_INTERVAL = time.Hour
+)
+
+// Previewer previews the first link of messages queued by Enqueue.
+type Previewer struct {
+	MessageStorer     models.IMessageStorer
+	LinkPreviewStorer models.ILinkPreviewStorer
+	Unfurler          Unfurler
+	queue             chan models.Message
+}
+
+func NewPreviewer(messageStorer models.IMessageStorer, linkPreviewStorer models.ILinkPreviewStorer, unfurler Unfurler) *Previewer {
+	return &Previewer{
+		MessageStorer:     messageStorer,
+		LinkPreviewStorer: linkPreviewStorer,
+		Unfurler:          unfurler,
+		queue:             make(chan models.Message, QUEUE_SIZE),
+	}
+}
+
+// Start runs workers previewing the queued messages, onPreviewed is called
+// with the id of a message once a preview is attached to it. Stale cached
+// previews are dropped every CLEANUP_INTERVAL.
+func (p *Previewer) Start(workers int, onPreviewed func(messageId int)) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for msg := range p.queue {
+				if p.Preview(msg) {
+					onPreviewed(msg.Id)
+				}
+			}
+		}()
+	}
+	go func() {
+		for range time.Tick(CLEANUP_INTERVAL) {
+			if _, err := p.LinkPreviewStorer.DeleteStale(CACHE_TTL); err != nil {
+				log.Printf("error while dropping stale link previews: %v\n", err)
+			}
+		}
+	}()
+}
+
+// Enqueue queues msg for previewing without blocking the caller when it has a link.
+// Previews are a nicety, messages that don't fit in the queue go without them.
+func (p *Previewer) Enqueue(msg models.Message) {
+	if FirstLink(msg) == "" {
+		return
+	}
+	select {
+	case p.queue <- msg:
+	default:
+		log.Printf("preview queue is full, message %d is left without a preview\n", msg.Id)
+	}
+}
+
+// Preview attaches a preview of the first link of msg to it and reports whether it did.
+// Nothing is attached when the message was edited or deleted in the meantime.
+func (p *Previewer) Preview(msg models.Message) bool {
+	link := FirstLink(msg)
+	if link == "" {
+		return false
+	}
+
+	preview, err := p.LinkPreviewStorer.Get(link, CACHE_TTL, FAILED_CACHE_TTL)
+	if errors.Is(err, sql.ErrNoRows) {
+		preview, err = p.unfurl(link)
+	}
+	if err != nil {
+		log.Printf("error while previewing %s: %v\n", link, err)
+		return false
+	}
+	if preview == nil {
+		return false
+	}
+
+	updated, err := p.MessageStorer.SetPreview(msg.Id, msg.Content, *preview)
+	if err != nil {
+		log.Printf("error while attaching a preview to message %d: %v\n", msg.Id, err)
+		return false
+	}
+	return updated
+}
+
+// unfurl fetches a preview of link and caches it, pages that can't be
+// previewed are cached as nil so that they aren't fetched over and over.
+func (p *Previewer) unfurl(link string) (*models.LinkPreview, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), FETCH_TIMEOUT)
+	defer cancel()
+	preview, err := p.Unfurler.Unfurl(ctx, link)
+	if err != nil {
+		log.Printf("%s can't be previewed: %v\n", link, err)
+		preview = nil
+	}
+	return preview, p.LinkPreviewStorer.Save(link, preview)
+}
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// FirstLink returns the first http or https link of msg, links of its entities
+// first, or "" when it has none. Trailing punctuation isn't taken as a part of
+// links written in the text.
+func FirstLink(msg models.Message) string {
+	if msg.Type == "system" || msg.DeletedAt != "" {
+		return ""
+	}
+	for _, entity := range msg.Entities {
+		if entity.Type == models.EntityLink && isWebLink(entity.URL) {
+			return entity.URL
+		}
+	}
+	for _, match := range linkPattern.FindAllString(msg.Content, -1) {
+		link := strings.TrimRight(match, ".,;:!?)]}'")
+		if isWebLink(link) {
+			return link
+		}
+	}
+	return ""
+}
+
+func isWebLink(link string) bool {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	return scheme == "http" || scheme == "https"
+}
@@ -0,0 +1,181 @@
+package previews_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/previews"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const page = `<!DOCTYPE html>
+<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="Real-time chat &amp; friends">
+<meta property='og:description' content='  Talk
+  to everyone '>
+<meta name="og:image" content="/images/cover.png">
+<meta property="og:site_name" content="Chat">
+</head><body></body></html>`
+
+type stubUnfurler struct {
+	preview *models.LinkPreview
+	err     error
+	links   []string
+}
+
+func (su *stubUnfurler) Unfurl(ctx context.Context, link string) (*models.LinkPreview, error) {
+	su.links = append(su.links, link)
+	return su.preview, su.err
+}
+
+func TestUnfurlReadsOpenGraph(t *testing.T) {
+	//Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+	unfurler := previews.HTTPUnfurler{Client: server.Client()}
+	expectedPreview := &models.LinkPreview{
+		URL:         server.URL + "/post",
+		Title:       "Real-time chat & friends",
+		Description: "Talk to everyone",
+		ImageURL:    server.URL + "/images/cover.png",
+		SiteName:    "Chat",
+	}
+
+	//Act
+	actualPreview, err := unfurler.Unfurl(context.Background(), server.URL+"/post")
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, expectedPreview, actualPreview)
+}
+
+func TestUnfurlRefusesPrivateAddresses(t *testing.T) {
+	//Arrange
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+	unfurler := previews.NewHTTPUnfurler()
+
+	//Act
+	actualPreview, err := unfurler.Unfurl(context.Background(), server.URL)
+
+	//Assert
+	assert.Nil(t, actualPreview)
+	assert.True(t, errors.Is(err, previews.ErrForbiddenAddress))
+	assert.False(t, requested)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	//Arrange
+	addresses := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	}
+
+	for address, expected := range addresses {
+		//Act
+		actual := previews.IsPublicIP(net.ParseIP(address))
+
+		//Assert
+		assert.Equal(t, expected, actual, address)
+	}
+}
+
+func TestFirstLink(t *testing.T) {
+	//Arrange
+	messages := map[string]models.Message{
+		"https://example.com/a": {Type: "text", Content: "see (https://example.com/a), or http://other.org."},
+		"https://example.com/entity": {
+			Type:     "text",
+			Content:  "here http://other.org",
+			Entities: []models.Entity{{Type: models.EntityLink, URL: "https://example.com/entity"}},
+		},
+		"": {Type: "text", Content: "ftp://example.com and example.com"},
+	}
+
+	for expected, msg := range messages {
+		//Act
+		actual := previews.FirstLink(msg)
+
+		//Assert
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestPreviewCachesAndAttaches(t *testing.T) {
+	//Arrange
+	msg := models.Message{Id: 3, Type: "text", Content: "look https://example.com"}
+	preview := &models.LinkPreview{URL: "https://example.com", Title: "Example"}
+	unfurler := &stubUnfurler{preview: preview}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLinkPreviewStorer := models_mocks.NewMockILinkPreviewStorer(ctrl)
+	mockLinkPreviewStorer.
+		EXPECT().
+		Get("https://example.com", previews.CACHE_TTL, previews.FAILED_CACHE_TTL).
+		Return(nil, sql.ErrNoRows)
+	mockLinkPreviewStorer.EXPECT().Save("https://example.com", preview).Return(nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().SetPreview(msg.Id, msg.Content, *preview).Return(true, nil)
+
+	previewer := previews.NewPreviewer(mockMessageStorer, mockLinkPreviewStorer, unfurler)
+
+	//Act
+	attached := previewer.Preview(msg)
+
+	//Assert
+	assert.True(t, attached)
+	assert.Equal(t, []string{"https://example.com"}, unfurler.links)
+}
+
+func TestPreviewSkipsCachedFailure(t *testing.T) {
+	//Arrange
+	msg := models.Message{Id: 3, Type: "text", Content: "look https://example.com"}
+	unfurler := &stubUnfurler{}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLinkPreviewStorer := models_mocks.NewMockILinkPreviewStorer(ctrl)
+	mockLinkPreviewStorer.
+		EXPECT().
+		Get("https://example.com", previews.CACHE_TTL, previews.FAILED_CACHE_TTL).
+		Return(nil, nil)
+
+	previewer := previews.NewPreviewer(nil, mockLinkPreviewStorer, unfurler)
+
+	//Act
+	attached := previewer.Preview(msg)
+
+	//Assert
+	assert.False(t, attached)
+	assert.Empty(t, unfurler.links)
+}
@@ -0,0 +1,219 @@
+package previews
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+const (
+	FETCH_TIMEOUT          = 5 * time.Second
+	MAX_PAGE_SIZE          = 512 << 10
+	MAX_REDIRECTS          = 3
+	MAX_TITLE_LENGTH       = 256
+	MAX_DESCRIPTION_LENGTH = 512
+	USER_AGENT             = "real-time-chat-previews/1.0"
+)
+
+var (
+	ErrForbiddenAddress = errors.New("address isn't public")
+	ErrNotHTML          = errors.New("page isn't HTML")
+	ErrNoPreview        = errors.New("page has no title")
+)
+
+// Unfurler makes a preview of the page at a link.
+type Unfurler interface {
+	Unfurl(ctx context.Context, link string) (*models.LinkPreview, error)
+}
+
+// HTTPUnfurler reads Open Graph tags of HTML pages, falling back to their
+// title and description.
+type HTTPUnfurler struct {
+	Client *http.Client
+}
+
+// NewHTTPUnfurler returns an unfurler that only connects to public addresses,
+// which is checked for every connection after the name is resolved, so neither
+// redirects nor DNS records can point it at the internal network.
+func NewHTTPUnfurler() HTTPUnfurler {
+	dialer := &net.Dialer{
+		Timeout: FETCH_TIMEOUT,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   FETCH_TIMEOUT,
+		ResponseHeaderTimeout: FETCH_TIMEOUT,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       time.Minute,
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   FETCH_TIMEOUT,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > MAX_REDIRECTS {
+				return fmt.Errorf("more than %d redirects", MAX_REDIRECTS)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %s isn't followed", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	return HTTPUnfurler{Client: client}
+}
+
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"2001:db8::/32",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsPublicIP reports whether ip is routable on the internet, as opposed to
+// loopback, private, link-local, multicast and reserved addresses.
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func (u HTTPUnfurler) Unfurl(ctx context.Context, link string) (*models.LinkPreview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", USER_AGENT)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("page responded with %s", res.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	// the head is at the start, a page cut at the limit still has it
+	page, err := io.ReadAll(io.LimitReader(res.Body, MAX_PAGE_SIZE))
+	if err != nil {
+		return nil, err
+	}
+	return ParsePage(res.Request.URL, string(page))
+}
+
+var (
+	metaTag    = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	titleTag   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	attributes = regexp.MustCompile(`(?s)([a-zA-Z:_-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// ParsePage makes a preview of page, pageURL is where it was read from
+// and relative image links are resolved against it.
+func ParsePage(pageURL *url.URL, page string) (*models.LinkPreview, error) {
+	meta := make(map[string]string)
+	for _, tag := range metaTag.FindAllString(page, -1) {
+		attrs := make(map[string]string)
+		for _, attr := range attributes.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(attr[1])] = attr[2] + attr[3] + attr[4]
+		}
+		name := attrs["property"]
+		if name == "" {
+			name = attrs["name"]
+		}
+		name = strings.ToLower(name)
+		if _, ok := meta[name]; name != "" && !ok {
+			meta[name] = attrs["content"]
+		}
+	}
+
+	preview := models.LinkPreview{
+		URL:         pageURL.String(),
+		Title:       clean(meta["og:title"], MAX_TITLE_LENGTH),
+		Description: clean(meta["og:description"], MAX_DESCRIPTION_LENGTH),
+		SiteName:    clean(meta["og:site_name"], MAX_TITLE_LENGTH),
+	}
+	if preview.Title == "" {
+		if match := titleTag.FindStringSubmatch(page); match != nil {
+			preview.Title = clean(match[1], MAX_TITLE_LENGTH)
+		}
+	}
+	if preview.Description == "" {
+		preview.Description = clean(meta["description"], MAX_DESCRIPTION_LENGTH)
+	}
+	if preview.Title == "" {
+		return nil, ErrNoPreview
+	}
+
+	if image := strings.TrimSpace(html.UnescapeString(meta["og:image"])); image != "" {
+		if imageURL, err := pageURL.Parse(image); err == nil && (imageURL.Scheme == "http" || imageURL.Scheme == "https") {
+			preview.ImageURL = imageURL.String()
+		}
+	}
+	return &preview, nil
+}
+
+// clean unescapes text, collapses its whitespace and cuts it to length runes.
+func clean(text string, length int) string {
+	text = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	return string([]rune(text)[:length-1]) + "…"
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/media"
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	MAX_ATTACHMENT_SIZE         int64 = 25 << 20
+	MAX_ATTACHMENTS_PER_MESSAGE       = 10
+	ATTACHMENT_URL_TTL                = 15 * time.Minute
+)
+
+// AttachmentMimeTypes are the types uploads are accepted with, they are
+// sniffed from the content rather than trusted from the client.
+var AttachmentMimeTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"video/mp4",
+	"video/webm",
+	"audio/ogg",
+	"audio/mp4",
+	"application/pdf",
+}
+
+// MediaProcessor prepares the images sent with a message in the background.
+type MediaProcessor interface {
+	Enqueue(msg models.Message)
+}
+
+// LinkPreviewer previews the links of a message in the background.
+type LinkPreviewer interface {
+	Enqueue(msg models.Message)
+}
+
+type IAttachmentService interface {
+	Upload(userId, chatId int, fileName string, r io.Reader) (*models.Attachment, utils.HttpError)
+	GetURL(userId, attachmentId int, variant string) (*models.AttachmentURL, utils.HttpError)
+}
+
+type AttachmentService struct {
+	AttachmentStorer   models.IAttachmentStorer
+	MessageStorer      models.IMessageStorer
+	ParticipantService IParticipantService
+	Storage            storage.Storage
+}
+
+func NewAttachmentService(attachmentStorer models.IAttachmentStorer, messageStorer models.IMessageStorer, participantService IParticipantService, blobs storage.Storage) AttachmentService {
+	return AttachmentService{
+		AttachmentStorer:   attachmentStorer,
+		MessageStorer:      messageStorer,
+		ParticipantService: participantService,
+		Storage:            blobs,
+	}
+}
+
+// Upload stores a file for userId to send to chatId later.
+func (as AttachmentService) Upload(userId, chatId int, fileName string, r io.Reader) (*models.Attachment, utils.HttpError) {
+	userInChat, err := as.ParticipantService.UserInChat(userId, chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if !userInChat {
+		err := fmt.Errorf("user %d doesn't participate in chat %d", userId, chatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	fileName, httpErr := cleanFileName(fileName)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// the file is spooled to disk to learn its size and type before it goes to storage
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, MAX_ATTACHMENT_SIZE+1))
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if size > MAX_ATTACHMENT_SIZE {
+		err := fmt.Errorf("attachments can't be larger than %d bytes", MAX_ATTACHMENT_SIZE)
+		return nil, utils.NewHttpError(err, http.StatusRequestEntityTooLarge)
+	}
+	if size == 0 {
+		err := errors.New("attachment is empty")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	mimeType, httpErr := sniffMimeType(head[:n])
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	dto := models.AttachmentDTO{
+		UploaderId: userId,
+		ChatId:     chatId,
+		FileName:   fileName,
+		MimeType:   mimeType,
+		Size:       size,
+	}
+	if mayBeAudio(mimeType) {
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		if httpErr := probeAudio(&dto, data); httpErr != nil {
+			return nil, httpErr
+		}
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	dto.Key, err = storage.NewKey(fmt.Sprintf("chats/%d", chatId))
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	err = as.Storage.Put(dto.Key, tmp, size, dto.MimeType)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	attachment, err := as.AttachmentStorer.Create(dto)
+	if err != nil {
+		if err := as.Storage.Delete(dto.Key); err != nil {
+			log.Println(err)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return attachment, nil
+}
+
+// cleanFileName drops the directories clients may send with a file name.
+func cleanFileName(fileName string) (string, utils.HttpError) {
+	fileName = filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if fileName == "." || fileName == "/" || len(fileName) > 255 {
+		err := errors.New("bad file name")
+		return "", utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	return fileName, nil
+}
+
+// sniffMimeType detects the type of a file from its first 512 bytes.
+func sniffMimeType(head []byte) (string, utils.HttpError) {
+	if audio := media.SniffAudio(head); audio != "" {
+		return audio, nil
+	}
+	detected := http.DetectContentType(head)
+	mimeType, _, err := mime.ParseMediaType(detected)
+	if err != nil || !slices.Contains(AttachmentMimeTypes, mimeType) {
+		err := fmt.Errorf("files of type %s can't be attached", detected)
+		return "", utils.NewHttpError(err, http.StatusUnsupportedMediaType)
+	}
+	return mimeType, nil
+}
+
+func mayBeAudio(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "audio/") || mimeType == "video/mp4"
+}
+
+// probeAudio checks that an audio file is a valid Ogg/Opus or M4A one and adds
+// its duration and waveform to dto. MP4 files sniffed as video are audio when
+// they have no video track.
+func probeAudio(dto *models.AttachmentDTO, data []byte) utils.HttpError {
+	info, err := media.ProbeAudio(data, dto.MimeType)
+	if err != nil {
+		if dto.MimeType == "video/mp4" {
+			return nil
+		}
+		err := fmt.Errorf("%s isn't a valid Ogg/Opus or M4A file", dto.FileName)
+		return utils.NewHttpError(err, http.StatusUnsupportedMediaType)
+	}
+	dto.MimeType = info.MimeType
+	dto.Duration = int(info.Duration.Milliseconds())
+	dto.Waveform = info.Waveform
+	return nil
+}
+
+// GetURL signs a short lived download URL for a participant of the attachment's chat,
+// of one of its variants when variant is set. Uploaders can download attachments
+// before they are sent and processed, nobody can once the message they were sent
+// with is deleted.
+func (as AttachmentService) GetURL(userId, attachmentId int, variant string) (*models.AttachmentURL, utils.HttpError) {
+	attachment, err := as.AttachmentStorer.GetOne(attachmentId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("no attachment with id %d", attachmentId)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	userInChat, err := as.ParticipantService.UserInChat(userId, attachment.ChatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if !userInChat {
+		err := fmt.Errorf("user %d doesn't participate in chat %d", userId, attachment.ChatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	if attachment.MessageId == 0 && attachment.UploaderId != userId {
+		err := fmt.Errorf("no attachment with id %d", attachmentId)
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	if attachment.UploaderId != userId && (attachment.Processing || attachment.ProcessingFailed) {
+		err := fmt.Errorf("attachment %d isn't processed", attachmentId)
+		if attachment.Processing {
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	key := attachment.Key
+	if variant != "" {
+		if !slices.ContainsFunc(attachment.Variants, func(v models.AttachmentVariant) bool { return v.Name == variant }) {
+			err := fmt.Errorf("attachment %d has no %s variant", attachmentId, variant)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+		key = models.VariantKey(attachment.Key, variant)
+	}
+
+	if attachment.MessageId != 0 {
+		msg, err := as.MessageStorer.GetOne(attachment.MessageId)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		if msg.DeletedAt != "" {
+			err := fmt.Errorf("message %d is deleted", msg.Id)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+	}
+
+	url, err := as.Storage.SignedURL(key, ATTACHMENT_URL_TTL)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	expiresAt := time.Now().Add(ATTACHMENT_URL_TTL).UTC().Format(time.RFC3339)
+	return &models.AttachmentURL{Url: url, ExpiresAt: expiresAt}, nil
+}
+
+// DeleteForMessages deletes the attachments of messages that are gone together
+// with their blobs, like the ones of expired messages.
+func (as AttachmentService) DeleteForMessages(messageIds []int) {
+	attachments, err := as.AttachmentStorer.DeleteForMessages(messageIds)
+	if err != nil {
+		log.Printf("error while deleting attachments of messages %v: %v\n", messageIds, err)
+		return
+	}
+	for _, attachment := range attachments {
+		keys := []string{attachment.Key}
+		for _, variant := range attachment.Variants {
+			keys = append(keys, models.VariantKey(attachment.Key, variant.Name))
+		}
+		for _, key := range keys {
+			if err := as.Storage.Delete(key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Println(err)
+			}
+		}
+	}
+}
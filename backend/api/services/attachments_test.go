@@ -0,0 +1,115 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte("\x89PNG\x0d\x0a\x1a\x0a\x00\x00\x00\x0dIHDR")
+
+func TestUploadAttachmentSuccess(t *testing.T) {
+	//Arrange
+	userId := 1
+	chatId := 2
+	content := append(append([]byte{}, pngHeader...), make([]byte, 100)...)
+
+	blobs, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, chatId).Return(true, nil)
+
+	var stored models.AttachmentDTO
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.
+		EXPECT().
+		Create(gomock.Any()).
+		DoAndReturn(func(dto models.AttachmentDTO) (*models.Attachment, error) {
+			stored = dto
+			return &models.Attachment{Id: 1, UploaderId: dto.UploaderId, ChatId: dto.ChatId, Key: dto.Key, FileName: dto.FileName, MimeType: dto.MimeType, Size: dto.Size}, nil
+		})
+
+	attachmentService := services.NewAttachmentService(mockAttachmentStorer, nil, mockParticipantService, blobs)
+
+	//Act
+	attachment, httpErr := attachmentService.Upload(userId, chatId, `C:\photos\cat.png`, bytes.NewReader(content))
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, "cat.png", attachment.FileName)
+	assert.Equal(t, "image/png", stored.MimeType)
+	assert.Equal(t, int64(len(content)), stored.Size)
+	blob, err := blobs.Get(stored.Key)
+	if assert.Nil(t, err) {
+		defer blob.Close()
+		actual, _ := io.ReadAll(blob)
+		assert.Equal(t, content, actual)
+	}
+}
+
+func TestUploadAttachmentTypeError(t *testing.T) {
+	//Arrange
+	userId := 1
+	chatId := 2
+	expectedError := errors.New("files of type text/html; charset=utf-8 can't be attached")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusUnsupportedMediaType)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, chatId).Return(true, nil)
+
+	attachmentService := services.NewAttachmentService(nil, nil, mockParticipantService, nil)
+
+	//Act
+	attachment, httpErr := attachmentService.Upload(userId, chatId, "cat.png", strings.NewReader("<html><script>alert(1)</script>"))
+
+	//Assert
+	assert.Nil(t, attachment)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestGetAttachmentURLProcessingError(t *testing.T) {
+	//Arrange
+	userId := 2
+	attachment := models.Attachment{Id: 3, UploaderId: 1, ChatId: 4, MessageId: 5, MimeType: "image/jpeg", Processing: true}
+	expectedError := errors.New("attachment 3 isn't processed")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusConflict)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetOne(attachment.Id).Return(&attachment, nil)
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, attachment.ChatId).Return(true, nil)
+
+	attachmentService := services.NewAttachmentService(mockAttachmentStorer, nil, mockParticipantService, nil)
+
+	//Act
+	url, httpErr := attachmentService.GetURL(userId, attachment.Id, "")
+
+	//Assert
+	assert.Nil(t, url)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
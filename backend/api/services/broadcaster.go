@@ -0,0 +1,6 @@
+package services
+
+// Broadcaster delivers an event to every participant of a chat that is online.
+type Broadcaster interface {
+	Broadcast(chatId int, event string, data any)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+)
+
+type IChatSettingsService interface {
+	Get(userId, chatId int) (*models.ChatSettings, utils.HttpError)
+	Update(userId int, settings models.ChatSettings) (*models.ChatSettings, utils.HttpError)
+}
+
+// MAX_MESSAGE_TTL is the longest messages can be set to live, in seconds.
+const MAX_MESSAGE_TTL = 366 * 24 * 60 * 60
+
+type ChatSettingsService struct {
+	ChatSettingsStorer models.IChatSettingsStorer
+	ParticipantStorer  models.IParticipantStorer
+	MessageService     IMessageService
+}
+
+func NewChatSettingsService(
+	chatSettingsStorer models.IChatSettingsStorer,
+	participantStorer models.IParticipantStorer,
+	messageService IMessageService,
+) ChatSettingsService {
+	return ChatSettingsService{
+		ChatSettingsStorer: chatSettingsStorer,
+		ParticipantStorer:  participantStorer,
+		MessageService:     messageService,
+	}
+}
+
+func (cs ChatSettingsService) Get(userId, chatId int) (*models.ChatSettings, utils.HttpError) {
+	_, httpErr := cs.participant(userId, chatId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	settings, err := cs.ChatSettingsStorer.Get(chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return settings, nil
+}
+
+func (cs ChatSettingsService) Update(userId int, settings models.ChatSettings) (*models.ChatSettings, utils.HttpError) {
+	user, httpErr := cs.participant(userId, settings.ChatId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if user.Role != "admin" {
+		err := fmt.Errorf("user %d doesn't have permission to change settings of chat %d", userId, settings.ChatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	if settings.EditWindow < 0 {
+		err := errors.New("edit window can't be negative")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if settings.DeleteWindow < 0 {
+		err := errors.New("delete window can't be negative")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if settings.MaxPins < 0 || settings.MaxPins > MAX_PINS {
+		err := fmt.Errorf("max pins must be between 0 and %d", MAX_PINS)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if settings.MessageTTL < 0 || settings.MessageTTL > MAX_MESSAGE_TTL {
+		err := fmt.Errorf("message ttl must be between 0 and %d seconds", MAX_MESSAGE_TTL)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	oldSettings, err := cs.ChatSettingsStorer.Get(settings.ChatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	updSettings, err := cs.ChatSettingsStorer.Upsert(settings)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	// messages sent before keep the expiry they were sent with
+	if updSettings.MessageTTL != oldSettings.MessageTTL {
+		cs.MessageService.Announce(userId, settings.ChatId, "message_ttl_changed", map[string]int{"messageTtl": updSettings.MessageTTL})
+	}
+	if changed := changedSettings(*oldSettings, *updSettings); len(changed) > 0 {
+		cs.MessageService.Announce(userId, settings.ChatId, "settings_changed", changed)
+	}
+
+	return updSettings, nil
+}
+
+// changedSettings returns the new values of the settings that changed by their JSON
+// names, the message ttl is left out as it is announced on its own.
+func changedSettings(old, upd models.ChatSettings) map[string]any {
+	changed := make(map[string]any)
+	if upd.EditWindow != old.EditWindow {
+		changed["editWindow"] = upd.EditWindow
+	}
+	if upd.DeleteWindow != old.DeleteWindow {
+		changed["deleteWindow"] = upd.DeleteWindow
+	}
+	if upd.MaxPins != old.MaxPins {
+		changed["maxPins"] = upd.MaxPins
+	}
+	if upd.MembersCanPin != old.MembersCanPin {
+		changed["membersCanPin"] = upd.MembersCanPin
+	}
+	return changed
+}
+
+func (cs ChatSettingsService) participant(userId, chatId int) (*models.Participant, utils.HttpError) {
+	participant, err := cs.ParticipantStorer.GetOne(userId, chatId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("user %d doesn't participate in chat %d", userId, chatId)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	return participant, nil
+}
@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateChatSettingsAnnouncesMessageTTL(t *testing.T) {
+	//Arrange
+	userId := 1
+	settings := models.ChatSettings{ChatId: 3, MessageTTL: 24 * 60 * 60}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.
+		EXPECT().
+		GetOne(userId, settings.ChatId).
+		Return(&models.Participant{UserId: userId, ChatId: settings.ChatId, Role: "admin"}, nil)
+
+	mockChatSettingsStorer := models_mocks.NewMockIChatSettingsStorer(ctrl)
+	mockChatSettingsStorer.EXPECT().Get(settings.ChatId).Return(&models.ChatSettings{ChatId: settings.ChatId}, nil)
+	mockChatSettingsStorer.EXPECT().Upsert(settings).Return(&settings, nil)
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.
+		EXPECT().
+		Announce(userId, settings.ChatId, "message_ttl_changed", map[string]int{"messageTtl": settings.MessageTTL})
+
+	chatSettingsService := services.NewChatSettingsService(mockChatSettingsStorer, mockParticipantStorer, mockMessageService)
+
+	//Act
+	actualSettings, httpErr := chatSettingsService.Update(userId, settings)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, settings, *actualSettings)
+}
+
+func TestUpdateChatSettingsAnnouncesChangedSettings(t *testing.T) {
+	//Arrange
+	userId := 1
+	oldSettings := models.ChatSettings{ChatId: 3, EditWindow: 60, MaxPins: 5}
+	settings := models.ChatSettings{ChatId: 3, EditWindow: 60, MaxPins: 10, MembersCanPin: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.
+		EXPECT().
+		GetOne(userId, settings.ChatId).
+		Return(&models.Participant{UserId: userId, ChatId: settings.ChatId, Role: "admin"}, nil)
+
+	mockChatSettingsStorer := models_mocks.NewMockIChatSettingsStorer(ctrl)
+	mockChatSettingsStorer.EXPECT().Get(settings.ChatId).Return(&oldSettings, nil)
+	mockChatSettingsStorer.EXPECT().Upsert(settings).Return(&settings, nil)
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.
+		EXPECT().
+		Announce(userId, settings.ChatId, "settings_changed", map[string]any{"maxPins": 10, "membersCanPin": true})
+
+	chatSettingsService := services.NewChatSettingsService(mockChatSettingsStorer, mockParticipantStorer, mockMessageService)
+
+	//Act
+	actualSettings, httpErr := chatSettingsService.Update(userId, settings)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, settings, *actualSettings)
+}
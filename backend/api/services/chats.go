@@ -24,14 +24,22 @@ type ChatService struct {
 	ParticipantStorer  models.IParticipantStorer
 	MessageStorer      models.IMessageStorer
 	ParticipantService IParticipantService
+	Broadcaster        Broadcaster
 }
 
-func NewChatService(chatStorer models.IChatStorer, participantStorer models.IParticipantStorer, messageStorer models.IMessageStorer, participantService ParticipantService) ChatService {
+func NewChatService(
+	chatStorer models.IChatStorer,
+	participantStorer models.IParticipantStorer,
+	messageStorer models.IMessageStorer,
+	participantService ParticipantService,
+	broadcaster Broadcaster,
+) ChatService {
 	return ChatService{
 		ChatStorer:         chatStorer,
 		ParticipantStorer:  participantStorer,
 		MessageStorer:      messageStorer,
 		ParticipantService: participantService,
+		Broadcaster:        broadcaster,
 	}
 }
 
@@ -68,6 +76,16 @@ func (cs ChatService) Create(userId int, chatWithTitle models.ChatFromRequest) (
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
 
+	// nobody can be in the chat yet, its history just starts with the event
+	created, err := systemMessage(userId, chat.Id, "chat_created", map[string]string{"title": chat.Title})
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	_, err = cs.MessageStorer.CreateInTx(tx, created)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
 	return chat, nil
 }
 
@@ -123,10 +141,21 @@ func (cs ChatService) Update(userId int, chat models.Chat) (*models.Chat, utils.
 		return nil, utils.NewHttpError(err, http.StatusForbidden)
 	}
 
+	oldChat, err := cs.ChatStorer.GetOne(chat.Id)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
 	updChat, err := cs.ChatStorer.Update(chat)
 	if err != nil {
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
+	updChat.MessageTTL = oldChat.MessageTTL
+
+	if updChat.Title != oldChat.Title {
+		data := map[string]string{"title": updChat.Title, "oldTitle": oldChat.Title}
+		announce(cs.MessageStorer, cs.Broadcaster, userId, chat.Id, "chat_renamed", data)
+	}
 	return updChat, nil
 }
 
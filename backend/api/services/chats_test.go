@@ -66,9 +66,22 @@ func TestCreateChatSuccess(t *testing.T) {
 		CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), expectedParticipant).
 		Return(&expectedParticipant, nil)
 
+	expectedSystemDTO := models.MessageDTO{
+		SenderId: creatorId,
+		ChatId:   expectedChat.Id,
+		Type:     "system",
+		Content:  `{"event":"chat_created","data":{"title":"test-chat"}}`,
+	}
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.
+		EXPECT().
+		CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), expectedSystemDTO).
+		Return(&models.Message{Id: 1}, nil)
+
 	chatsService := services.ChatService{
 		ChatStorer:        mockChatStorer,
 		ParticipantStorer: mockParticipantStorer,
+		MessageStorer:     mockMessageStorer,
 	}
 
 	//Act
@@ -340,16 +353,42 @@ func TestUpdateChatSuccess(t *testing.T) {
 		GetOne(userId, expectedChat.Id).
 		Return(&expectedParticipant, nil)
 
+	oldChat := expectedChat
+	oldChat.Title = "old-chat"
 	mockChatStorer := models_mocks.NewMockIChatStorer(ctrl)
+	mockChatStorer.
+		EXPECT().
+		GetOne(expectedChat.Id).
+		Return(&oldChat, nil)
 	mockChatStorer.
 		EXPECT().
 		Update(expectedChat).
 		Return(&expectedChat, nil)
 
+	expectedSystemDTO := models.MessageDTO{
+		SenderId: userId,
+		ChatId:   expectedChat.Id,
+		Type:     "system",
+		Content:  `{"event":"chat_renamed","data":{"oldTitle":"old-chat","title":"test-chat"}}`,
+	}
+	systemMessage := models.Message{Id: 5, SenderId: userId, ChatId: expectedChat.Id, Type: "system", Content: expectedSystemDTO.Content}
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.
+		EXPECT().
+		Create(expectedSystemDTO).
+		Return(&systemMessage, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.
+		EXPECT().
+		Broadcast(expectedChat.Id, "message", gomock.AssignableToTypeOf(&models.Message{}))
+
 	chatsService := services.ChatService{
 		ChatStorer:         mockChatStorer,
 		ParticipantStorer:  mockParticipantStorer,
+		MessageStorer:      mockMessageStorer,
 		ParticipantService: mockParticipantService,
+		Broadcaster:        mockBroadcaster,
 	}
 
 	//Act
@@ -479,6 +518,10 @@ func TestUpdateChatInternalError(t *testing.T) {
 		Return(&expectedParticipant, nil)
 
 	mockChatStorer := models_mocks.NewMockIChatStorer(ctrl)
+	mockChatStorer.
+		EXPECT().
+		GetOne(expectedChat.Id).
+		Return(&expectedChat, nil)
 	mockChatStorer.
 		EXPECT().
 		Update(expectedChat).
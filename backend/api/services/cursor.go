@@ -0,0 +1,37 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	CURSOR_BEFORE = "before"
+	CURSOR_AFTER  = "after"
+)
+
+var ErrBadCursor = errors.New("bad cursor")
+
+// EncodeCursor makes an opaque cursor to the messages sent before or after messageId.
+func EncodeCursor(direction string, messageId int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", direction, messageId)))
+}
+
+func DecodeCursor(cursor string) (direction string, messageId int, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrBadCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || parts[0] != CURSOR_BEFORE && parts[0] != CURSOR_AFTER {
+		return "", 0, ErrBadCursor
+	}
+	messageId, err = strconv.Atoi(parts[1])
+	if err != nil || messageId <= 0 {
+		return "", 0, ErrBadCursor
+	}
+	return parts[0], messageId, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"golang.org/x/exp/slices"
+)
+
+var DevicePlatforms = []string{"webpush", "apns", "fcm"}
+
+type IDeviceService interface {
+	Register(userId int, device models.DeviceFromRequest) (*models.Device, utils.HttpError)
+	GetUserDevices(userId int) ([]models.Device, utils.HttpError)
+	Unregister(userId, deviceId int) (*models.Device, utils.HttpError)
+}
+
+type DeviceService struct {
+	DeviceStorer models.IDeviceStorer
+}
+
+func NewDeviceService(deviceStorer models.IDeviceStorer) DeviceService {
+	return DeviceService{DeviceStorer: deviceStorer}
+}
+
+func (ds DeviceService) Register(userId int, fromRequest models.DeviceFromRequest) (*models.Device, utils.HttpError) {
+	if !slices.Contains(DevicePlatforms, fromRequest.Platform) {
+		err := fmt.Errorf("unknown device platform %q", fromRequest.Platform)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if fromRequest.Token == "" {
+		err := errors.New("device token can't be empty")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	dto := models.DeviceDTO{
+		UserId:   userId,
+		Platform: fromRequest.Platform,
+		Token:    fromRequest.Token,
+	}
+
+	device, err := ds.DeviceStorer.Create(dto)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return device, nil
+}
+
+func (ds DeviceService) GetUserDevices(userId int) ([]models.Device, utils.HttpError) {
+	devices, err := ds.DeviceStorer.GetUserDevices(userId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	return devices, nil
+}
+
+func (ds DeviceService) Unregister(userId, deviceId int) (*models.Device, utils.HttpError) {
+	devices, httpErr := ds.GetUserDevices(userId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if !slices.ContainsFunc(devices, func(d models.Device) bool { return d.Id == deviceId }) {
+		err := fmt.Errorf("user %d has no device with id %d", userId, deviceId)
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	device, err := ds.DeviceStorer.Delete(deviceId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("user %d has no device with id %d", userId, deviceId)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return device, nil
+}
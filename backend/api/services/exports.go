@@ -0,0 +1,194 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+)
+
+const (
+	MAX_STREAMED_MESSAGES = 5000
+	MAX_PENDING_EXPORTS   = 3
+	EXPORT_URL_TTL        = 15 * time.Minute
+)
+
+// ChatExporter writes the history of a chat in one of models.ExportFormats.
+type ChatExporter interface {
+	Write(w io.Writer, chatId int, format string, progress func(messages int)) error
+}
+
+// IExportService exports the history of chats to their participants. Chats of up to
+// MAX_STREAMED_MESSAGES messages can be streamed, exports of any size are made in
+// the background and downloaded once ready. Participants see their own exports,
+// admins of the chat see all of them.
+type IExportService interface {
+	Stream(userId, chatId int, format string) (func(w io.Writer) error, utils.HttpError)
+	Create(userId, chatId int, format string) (*models.Export, utils.HttpError)
+	GetAll(userId, chatId int) ([]models.Export, utils.HttpError)
+	GetOne(userId, chatId, exportId int) (*models.Export, utils.HttpError)
+	GetURL(userId, chatId, exportId int) (*models.AttachmentURL, utils.HttpError)
+}
+
+type ExportService struct {
+	ExportStorer       models.IExportStorer
+	MessageStorer      models.IMessageStorer
+	ParticipantService IParticipantService
+	Exporter           ChatExporter
+	Storage            storage.Storage
+}
+
+func NewExportService(
+	exportStorer models.IExportStorer,
+	messageStorer models.IMessageStorer,
+	participantService IParticipantService,
+	exporter ChatExporter,
+	blobs storage.Storage,
+) ExportService {
+	return ExportService{
+		ExportStorer:       exportStorer,
+		MessageStorer:      messageStorer,
+		ParticipantService: participantService,
+		Exporter:           exporter,
+		Storage:            blobs,
+	}
+}
+
+// Stream returns a function writing the history of a small chat right away,
+// larger chats have to be exported with Create.
+func (es ExportService) Stream(userId, chatId int, format string) (func(w io.Writer) error, utils.HttpError) {
+	if _, httpErr := es.authorize(userId, chatId); httpErr != nil {
+		return nil, httpErr
+	}
+	if httpErr := checkExportFormat(format); httpErr != nil {
+		return nil, httpErr
+	}
+
+	count, err := es.MessageStorer.CountChatMessages(chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if count > MAX_STREAMED_MESSAGES {
+		err := fmt.Errorf("chat %d has more than %d messages, it can only be exported in the background", chatId, MAX_STREAMED_MESSAGES)
+		return nil, utils.NewHttpError(err, http.StatusRequestEntityTooLarge)
+	}
+
+	return func(w io.Writer) error {
+		return es.Exporter.Write(w, chatId, format, func(int) {})
+	}, nil
+}
+
+// Create queues an export of a chat, a participant can have up to MAX_PENDING_EXPORTS
+// exports waiting to be made.
+func (es ExportService) Create(userId, chatId int, format string) (*models.Export, utils.HttpError) {
+	if _, httpErr := es.authorize(userId, chatId); httpErr != nil {
+		return nil, httpErr
+	}
+	if httpErr := checkExportFormat(format); httpErr != nil {
+		return nil, httpErr
+	}
+
+	pending, err := es.ExportStorer.CountPending(userId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if pending >= MAX_PENDING_EXPORTS {
+		err := fmt.Errorf("user %d can't have more than %d exports in progress", userId, MAX_PENDING_EXPORTS)
+		return nil, utils.NewHttpError(err, http.StatusTooManyRequests)
+	}
+
+	export, err := es.ExportStorer.Create(chatId, userId, format)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return export, nil
+}
+
+func (es ExportService) GetAll(userId, chatId int) ([]models.Export, utils.HttpError) {
+	isAdmin, httpErr := es.authorize(userId, chatId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	requestedBy := userId
+	if isAdmin {
+		requestedBy = 0
+	}
+	exports, err := es.ExportStorer.GetForChat(chatId, requestedBy)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return exports, nil
+}
+
+// GetOne returns an export of a chat userId can see, others aren't found.
+func (es ExportService) GetOne(userId, chatId, exportId int) (*models.Export, utils.HttpError) {
+	isAdmin, httpErr := es.authorize(userId, chatId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	export, err := es.ExportStorer.GetOne(exportId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if err != nil || export.ChatId != chatId || !isAdmin && export.RequestedBy != userId {
+		err := fmt.Errorf("no export with id %d in chat %d", exportId, chatId)
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	return export, nil
+}
+
+// GetURL returns a short-lived URL the file of a ready export can be downloaded from.
+func (es ExportService) GetURL(userId, chatId, exportId int) (*models.AttachmentURL, utils.HttpError) {
+	export, httpErr := es.GetOne(userId, chatId, exportId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if export.Status != "ready" {
+		err := fmt.Errorf("export %d is %s", exportId, export.Status)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	url, err := es.Storage.SignedURL(export.Key, EXPORT_URL_TTL)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	expiresAt := time.Now().Add(EXPORT_URL_TTL).UTC().Format(time.RFC3339)
+	return &models.AttachmentURL{Url: url, ExpiresAt: expiresAt}, nil
+}
+
+// authorize checks that userId participates in a chat and reports whether they are its admin.
+func (es ExportService) authorize(userId, chatId int) (bool, utils.HttpError) {
+	chatUsers, httpErr := es.ParticipantService.GetChatUsers(userId, chatId)
+	if httpErr != nil {
+		return false, httpErr
+	}
+
+	for _, chatUser := range chatUsers {
+		if chatUser.UserId == userId {
+			return chatUser.Role == "admin", nil
+		}
+	}
+	return false, nil
+}
+
+func checkExportFormat(format string) utils.HttpError {
+	if _, ok := models.ExportFormats[format]; !ok {
+		err := fmt.Errorf("chats can't be exported as %q", format)
+		return utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	return nil
+}
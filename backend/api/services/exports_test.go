@@ -0,0 +1,122 @@
+package services_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func chatUsers(chatId, userId int, role string) []models.ChatUser {
+	return []models.ChatUser{
+		{Participant: models.Participant{UserId: userId, ChatId: chatId, Role: role}},
+		{Participant: models.Participant{UserId: userId + 1, ChatId: chatId, Role: "member"}},
+	}
+}
+
+func TestStreamExportLargeChatError(t *testing.T) {
+	//Arrange
+	userId, chatId := 1, 3
+	expectedError := fmt.Errorf("chat %d has more than %d messages, it can only be exported in the background", chatId, services.MAX_STREAMED_MESSAGES)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusRequestEntityTooLarge)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().GetChatUsers(userId, chatId).Return(chatUsers(chatId, userId, "member"), nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().CountChatMessages(chatId).Return(services.MAX_STREAMED_MESSAGES+1, nil)
+
+	exportService := services.NewExportService(nil, mockMessageStorer, mockParticipantService, nil, nil)
+
+	//Act
+	write, httpErr := exportService.Stream(userId, chatId, "html")
+
+	//Assert
+	assert.Nil(t, write)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestCreateExportSuccess(t *testing.T) {
+	//Arrange
+	userId, chatId := 1, 3
+	expectedExport := models.Export{Id: 9, ChatId: chatId, RequestedBy: userId, Format: "jsonl", Status: "pending"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().GetChatUsers(userId, chatId).Return(chatUsers(chatId, userId, "member"), nil)
+
+	mockExportStorer := models_mocks.NewMockIExportStorer(ctrl)
+	mockExportStorer.EXPECT().CountPending(userId).Return(services.MAX_PENDING_EXPORTS-1, nil)
+	mockExportStorer.EXPECT().Create(chatId, userId, "jsonl").Return(&expectedExport, nil)
+
+	exportService := services.NewExportService(mockExportStorer, nil, mockParticipantService, nil, nil)
+
+	//Act
+	actualExport, httpErr := exportService.Create(userId, chatId, "jsonl")
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, expectedExport, *actualExport)
+}
+
+func TestGetExportOfOtherMemberNotFound(t *testing.T) {
+	//Arrange
+	userId, chatId := 1, 3
+	export := models.Export{Id: 9, ChatId: chatId, RequestedBy: userId + 1, Format: "text", Status: "ready", Key: "exports/3/abc"}
+	expectedError := fmt.Errorf("no export with id %d in chat %d", export.Id, chatId)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusNotFound)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().GetChatUsers(userId, chatId).Return(chatUsers(chatId, userId, "member"), nil)
+
+	mockExportStorer := models_mocks.NewMockIExportStorer(ctrl)
+	mockExportStorer.EXPECT().GetOne(export.Id).Return(&export, nil)
+
+	exportService := services.NewExportService(mockExportStorer, nil, mockParticipantService, nil, nil)
+
+	//Act
+	actualURL, httpErr := exportService.GetURL(userId, chatId, export.Id)
+
+	//Assert
+	assert.Nil(t, actualURL)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestGetExportsAsAdminSeesAll(t *testing.T) {
+	//Arrange
+	userId, chatId := 1, 3
+	expectedExports := []models.Export{{Id: 9, ChatId: chatId, RequestedBy: userId + 1, Format: "text", Status: "running"}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().GetChatUsers(userId, chatId).Return(chatUsers(chatId, userId, "admin"), nil)
+
+	mockExportStorer := models_mocks.NewMockIExportStorer(ctrl)
+	mockExportStorer.EXPECT().GetForChat(chatId, 0).Return(expectedExports, nil)
+
+	exportService := services.NewExportService(mockExportStorer, nil, mockParticipantService, nil, nil)
+
+	//Act
+	actualExports, httpErr := exportService.GetAll(userId, chatId)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, expectedExports, actualExports)
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	MAX_CONTENT_LENGTH  = 1024
+	MAX_MARKDOWN_LENGTH = 4096
+	MAX_ENTITIES        = 100
+	MAX_LANGUAGE_LENGTH = 32
+)
+
+// LinkSchemes are the schemes links written in markdown can point to.
+var LinkSchemes = []string{"http", "https", "mailto"}
+
+// markdownEscapable are the characters a backslash before them keeps as they are.
+const markdownEscapable = "\\*_`[]()>!"
+
+// renderContent turns content written in format into the text and entities
+// a message is stored with, entities are nil for plain text. The length
+// limit applies to the text, not to the markup it was written with.
+func renderContent(content, format string) (string, []models.Entity, utils.HttpError) {
+	text := content
+	var entities []models.Entity
+	switch format {
+	case "", "plain":
+	case "markdown":
+		if utf8.RuneCountInString(content) > MAX_MARKDOWN_LENGTH {
+			err := fmt.Errorf("markdown can't be longer than %d characters", MAX_MARKDOWN_LENGTH)
+			return "", nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		var err error
+		text, entities, err = ParseMarkdown(content)
+		if err != nil {
+			return "", nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+	default:
+		err := fmt.Errorf("unknown format %q", format)
+		return "", nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(text) > MAX_CONTENT_LENGTH {
+		err := fmt.Errorf("content can't be longer than %d characters", MAX_CONTENT_LENGTH)
+		return "", nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	return text, entities, nil
+}
+
+// ParseMarkdown renders the markdown dialect messages can be written in into text and
+// the entities formatting it: **bold**, _italic_, `code`, ```language code blocks```,
+// [links](https://example.com) and lines of > quotes. Markers that aren't closed and
+// any other markup, like headings or images, stay in the text as they are. Links
+// with schemes other than LinkSchemes are rejected.
+func ParseMarkdown(source string) (string, []models.Entity, error) {
+	r := markdownRenderer{text: make([]rune, 0, len(source)), entities: make([]models.Entity, 0)}
+	lines := strings.Split(source, "\n")
+	for i := 0; i < len(lines); i++ {
+		if i > 0 {
+			r.text = append(r.text, '\n')
+		}
+
+		if language, ok := codeFence(lines[i]); ok {
+			end := i + 1
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "```" {
+				end++
+			}
+			if end < len(lines) {
+				start := len(r.text)
+				r.text = append(r.text, []rune(strings.Join(lines[i+1:end], "\n"))...)
+				r.add(models.EntityPre, start, "", language)
+				i = end
+				continue
+			}
+		}
+
+		if isQuote(lines[i]) {
+			start := len(r.text)
+			end := i
+			for ; end < len(lines) && isQuote(lines[end]); end++ {
+				if end > i {
+					r.text = append(r.text, '\n')
+				}
+				quoted := strings.TrimPrefix(lines[end][1:], " ")
+				if err := r.inline([]rune(quoted), nil); err != nil {
+					return "", nil, err
+				}
+			}
+			r.add(models.EntityQuote, start, "", "")
+			i = end - 1
+			continue
+		}
+
+		if err := r.inline([]rune(lines[i]), nil); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if len(r.entities) > MAX_ENTITIES {
+		return "", nil, fmt.Errorf("a message can't have more than %d formatted parts", MAX_ENTITIES)
+	}
+
+	// entities are collected in runes, clients index strings in UTF-16 code units
+	offsets := make([]int, len(r.text)+1)
+	for i, c := range r.text {
+		offsets[i+1] = offsets[i] + utf16Len(c)
+	}
+	for i := range r.entities {
+		end := r.entities[i].Offset + r.entities[i].Length
+		r.entities[i].Offset = offsets[r.entities[i].Offset]
+		r.entities[i].Length = offsets[end] - r.entities[i].Offset
+	}
+	slices.SortStableFunc(r.entities, func(a, b models.Entity) bool {
+		return a.Offset < b.Offset || a.Offset == b.Offset && a.Length > b.Length
+	})
+
+	return string(r.text), r.entities, nil
+}
+
+type markdownRenderer struct {
+	text     []rune
+	entities []models.Entity
+}
+
+// add marks the text rendered since start with an entity, unless there is none.
+func (r *markdownRenderer) add(entityType string, start int, link, language string) {
+	if len(r.text) == start {
+		return
+	}
+	r.entities = append(r.entities, models.Entity{
+		Type:     entityType,
+		Offset:   start,
+		Length:   len(r.text) - start,
+		URL:      link,
+		Language: language,
+	})
+}
+
+// inline renders a line, entities of excluded types are taken as text
+// so that bold isn't nested in bold or links in links.
+func (r *markdownRenderer) inline(src []rune, excluded []string) error {
+	for i := 0; i < len(src); i++ {
+		switch {
+		case src[i] == '\\' && i+1 < len(src) && strings.ContainsRune(markdownEscapable, src[i+1]):
+			i++
+		case src[i] == '`':
+			if end := indexRune(src, '`', i+1); end > i+1 {
+				start := len(r.text)
+				r.text = append(r.text, src[i+1:end]...)
+				r.add(models.EntityCode, start, "", "")
+				i = end
+				continue
+			}
+		case src[i] == '*' && i+1 < len(src) && src[i+1] == '*' && !slices.Contains(excluded, models.EntityBold):
+			if end := findCloser(src, i+2, closesBold); end > i+2 {
+				if err := r.span(models.EntityBold, src[i+2:end], excluded, ""); err != nil {
+					return err
+				}
+				i = end + 1
+				continue
+			}
+		case src[i] == '_' && opensItalic(src, i) && !slices.Contains(excluded, models.EntityItalic):
+			if end := findCloser(src, i+1, closesItalic); end > i+1 {
+				if err := r.span(models.EntityItalic, src[i+1:end], excluded, ""); err != nil {
+					return err
+				}
+				i = end
+				continue
+			}
+		case src[i] == '!' && i+1 < len(src) && src[i+1] == '[':
+			// images aren't supported, their markup is kept as text
+			r.text = append(r.text, src[i])
+			i++
+		case src[i] == '[' && !slices.Contains(excluded, models.EntityLink):
+			if end, link, next := findLink(src, i); next > 0 {
+				if err := checkLink(link); err != nil {
+					return err
+				}
+				if err := r.span(models.EntityLink, src[i+1:end], excluded, link); err != nil {
+					return err
+				}
+				i = next
+				continue
+			}
+		}
+		r.text = append(r.text, src[i])
+	}
+	return nil
+}
+
+func (r *markdownRenderer) span(entityType string, src []rune, excluded []string, link string) error {
+	start := len(r.text)
+	excluded = append(append(make([]string, 0, len(excluded)+1), excluded...), entityType)
+	if err := r.inline(src, excluded); err != nil {
+		return err
+	}
+	r.add(entityType, start, link, "")
+	return nil
+}
+
+// codeFence reports whether line opens a code block and the language it names.
+func codeFence(line string) (string, bool) {
+	if !strings.HasPrefix(line, "```") {
+		return "", false
+	}
+	language := strings.TrimSpace(line[3:])
+	if len(language) > MAX_LANGUAGE_LENGTH {
+		return "", false
+	}
+	for _, c := range language {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("+#-._", c) {
+			return "", false
+		}
+	}
+	return language, true
+}
+
+func isQuote(line string) bool {
+	return strings.HasPrefix(line, ">")
+}
+
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// italics open and close at word boundaries, which keeps snake_case as it is
+func opensItalic(src []rune, i int) bool {
+	return (i == 0 || !isWordRune(src[i-1])) && i+1 < len(src) && !unicode.IsSpace(src[i+1])
+}
+
+func closesItalic(src []rune, i int) bool {
+	return src[i] == '_' && !unicode.IsSpace(src[i-1]) && (i+1 == len(src) || !isWordRune(src[i+1]))
+}
+
+func closesBold(src []rune, i int) bool {
+	return src[i] == '*' && i+1 < len(src) && src[i+1] == '*'
+}
+
+func closesBracket(src []rune, i int) bool {
+	return src[i] == ']'
+}
+
+// findCloser returns the index of the first closing marker from the index
+// from on, skipping escaped characters and code, or -1 when there is none.
+func findCloser(src []rune, from int, closes func(src []rune, i int) bool) int {
+	for i := from; i < len(src); i++ {
+		if src[i] == '\\' && i+1 < len(src) && strings.ContainsRune(markdownEscapable, src[i+1]) {
+			i++
+			continue
+		}
+		if src[i] == '`' {
+			if end := indexRune(src, '`', i+1); end > i+1 {
+				i = end
+				continue
+			}
+		}
+		if closes(src, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findLink reads [text](link) starting at the index start. It returns the index of
+// the closing bracket, the link and the index of the closing parenthesis, which is 0
+// when there is no link there.
+func findLink(src []rune, start int) (int, string, int) {
+	end := findCloser(src, start+1, closesBracket)
+	if end <= start+1 || end+1 >= len(src) || src[end+1] != '(' {
+		return 0, "", 0
+	}
+	for next := end + 2; next < len(src); next++ {
+		if unicode.IsSpace(src[next]) {
+			return 0, "", 0
+		}
+		if src[next] == ')' {
+			if next == end+2 {
+				return 0, "", 0
+			}
+			return end, string(src[end+2 : next]), next
+		}
+	}
+	return 0, "", 0
+}
+
+func checkLink(link string) error {
+	parsed, err := url.Parse(link)
+	if err != nil || !slices.Contains(LinkSchemes, strings.ToLower(parsed.Scheme)) {
+		return fmt.Errorf("links to %q aren't allowed", link)
+	}
+	if !strings.EqualFold(parsed.Scheme, "mailto") && parsed.Host == "" {
+		return fmt.Errorf("link %q has no host", link)
+	}
+	return nil
+}
+
+func indexRune(src []rune, c rune, from int) int {
+	for i := from; i < len(src); i++ {
+		if src[i] == c {
+			return i
+		}
+	}
+	return -1
+}
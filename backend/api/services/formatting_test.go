@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMarkdownInline(t *testing.T) {
+	//Arrange
+	source := "**bold _and italic_** `x*y` snake_case_name [😀 site](https://example.com) \\*not bold\\*"
+	expectedText := "bold and italic x*y snake_case_name 😀 site *not bold*"
+	expectedEntities := []models.Entity{
+		{Type: models.EntityBold, Offset: 0, Length: 15},
+		{Type: models.EntityItalic, Offset: 5, Length: 10},
+		{Type: models.EntityCode, Offset: 16, Length: 3},
+		{Type: models.EntityLink, Offset: 36, Length: 7, URL: "https://example.com"},
+	}
+
+	//Act
+	actualText, actualEntities, err := services.ParseMarkdown(source)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, expectedText, actualText)
+	assert.Equal(t, expectedEntities, actualEntities)
+}
+
+func TestParseMarkdownBlocks(t *testing.T) {
+	//Arrange
+	source := "> quoted\n> **twice**\n```go\nfmt.Println(\"**\")\n```\n# not a heading ![image](https://example.com/a.png) **open"
+	expectedText := "quoted\ntwice\nfmt.Println(\"**\")\n# not a heading ![image](https://example.com/a.png) **open"
+	expectedEntities := []models.Entity{
+		{Type: models.EntityQuote, Offset: 0, Length: 12},
+		{Type: models.EntityBold, Offset: 7, Length: 5},
+		{Type: models.EntityPre, Offset: 13, Length: 17, Language: "go"},
+	}
+
+	//Act
+	actualText, actualEntities, err := services.ParseMarkdown(source)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, expectedText, actualText)
+	assert.Equal(t, expectedEntities, actualEntities)
+}
+
+func TestParseMarkdownUnsafeLinkError(t *testing.T) {
+	//Arrange
+	source := "[click](javascript:alert)"
+	expectedError := errors.New(`links to "javascript:alert" aren't allowed`)
+
+	//Act
+	actualText, actualEntities, err := services.ParseMarkdown(source)
+
+	//Assert
+	assert.Equal(t, "", actualText)
+	assert.Nil(t, actualEntities)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestCreateMessageRenderedTooLongError(t *testing.T) {
+	//Arrange
+	userId := 1
+	content := make([]byte, services.MAX_CONTENT_LENGTH+1)
+	for i := range content {
+		content[i] = 'a'
+	}
+	fromRequest := models.MessageFromRequest{ChatId: 2, Type: "text", Content: "**" + string(content) + "**", Format: "markdown"}
+	expectedError := errors.New("content can't be longer than 1024 characters")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+
+	messageService := services.MessageService{ParticipantService: mockParticipantService}
+
+	//Act
+	actualMessage, httpErr := messageService.Create(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, actualMessage)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	MAX_IMPORT_SIZE     = 512 << 20
+	MAX_PENDING_IMPORTS = 2
+	MAX_MAPPED_USERS    = 1000
+	// MAX_IMPORT_TITLE_LENGTH is what the chats table holds
+	MAX_IMPORT_TITLE_LENGTH = 32
+)
+
+var DateOrders = []string{"dmy", "mdy", "ymd"}
+
+// IImportService imports the exports of other messengers users upload, the
+// import runs in the background and users follow it by polling it.
+type IImportService interface {
+	Create(userId int, source string, options models.ImportOptions, r io.Reader) (*models.Import, utils.HttpError)
+	GetAll(userId int) ([]models.Import, utils.HttpError)
+	GetOne(userId, importId int) (*models.Import, utils.HttpError)
+}
+
+type ImportService struct {
+	ImportStorer models.IImportStorer
+	Storage      storage.Storage
+}
+
+func NewImportService(importStorer models.IImportStorer, blobs storage.Storage) ImportService {
+	return ImportService{
+		ImportStorer: importStorer,
+		Storage:      blobs,
+	}
+}
+
+// Create stores an export read from r and queues its import, a user can have up
+// to MAX_PENDING_IMPORTS imports waiting to be run.
+func (is ImportService) Create(userId int, source string, options models.ImportOptions, r io.Reader) (*models.Import, utils.HttpError) {
+	if !slices.Contains(models.ImportSources, source) {
+		err := fmt.Errorf("exports of %q can't be imported", source)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if httpErr := checkImportOptions(userId, options); httpErr != nil {
+		return nil, httpErr
+	}
+
+	pending, err := is.ImportStorer.CountPending(userId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if pending >= MAX_PENDING_IMPORTS {
+		err := fmt.Errorf("user %d can't have more than %d imports in progress", userId, MAX_PENDING_IMPORTS)
+		return nil, utils.NewHttpError(err, http.StatusTooManyRequests)
+	}
+
+	// the export is spooled to disk to learn its size before it goes to storage
+	tmp, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, MAX_IMPORT_SIZE+1))
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if size > MAX_IMPORT_SIZE {
+		err := fmt.Errorf("exports can't be larger than %d bytes", MAX_IMPORT_SIZE)
+		return nil, utils.NewHttpError(err, http.StatusRequestEntityTooLarge)
+	}
+	if size == 0 {
+		err := errors.New("export is empty")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	key, err := storage.NewKey(fmt.Sprintf("imports/%d", userId))
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if err := is.Storage.Put(key, tmp, size, "application/octet-stream"); err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	imp, err := is.ImportStorer.Create(userId, source, options, key)
+	if err != nil {
+		if err := is.Storage.Delete(key); err != nil {
+			log.Println(err)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return imp, nil
+}
+
+func (is ImportService) GetAll(userId int) ([]models.Import, utils.HttpError) {
+	imports, err := is.ImportStorer.GetForUser(userId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	return imports, nil
+}
+
+// GetOne returns an import of userId, imports of others aren't found.
+func (is ImportService) GetOne(userId, importId int) (*models.Import, utils.HttpError) {
+	imp, err := is.ImportStorer.GetOne(importId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if err != nil || imp.UserId != userId {
+		err := fmt.Errorf("no import with id %d", importId)
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	return imp, nil
+}
+
+// checkImportOptions checks options of an import of userId. Users of the export can
+// only be mapped to userId, mapping them to others would let anyone post as them.
+func checkImportOptions(userId int, options models.ImportOptions) utils.HttpError {
+	if options.DateOrder != "" && !slices.Contains(DateOrders, options.DateOrder) {
+		err := fmt.Errorf("date order has to be one of %v", DateOrders)
+		return utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if options.TimeZone != "" {
+		if _, err := time.LoadLocation(options.TimeZone); err != nil {
+			return utils.NewHttpError(err, http.StatusBadRequest)
+		}
+	}
+	if utf8.RuneCountInString(options.Title) > MAX_IMPORT_TITLE_LENGTH {
+		err := fmt.Errorf("title can't be longer than %d characters", MAX_IMPORT_TITLE_LENGTH)
+		return utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if len(options.UserMap) > MAX_MAPPED_USERS {
+		err := fmt.Errorf("no more than %d users can be mapped", MAX_MAPPED_USERS)
+		return utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	for id, mappedId := range options.UserMap {
+		if mappedId != userId {
+			err := fmt.Errorf("%s can't be mapped to user %d, users can only be mapped to yourself", id, mappedId)
+			return utils.NewHttpError(err, http.StatusForbidden)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package services_test
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateImportSuccess(t *testing.T) {
+	//Arrange
+	userId := 1
+	options := models.ImportOptions{Title: "Friends", DateOrder: "mdy", TimeZone: "Europe/Kyiv"}
+	blobs, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files", "secret")
+	assert.Nil(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImportStorer := models_mocks.NewMockIImportStorer(ctrl)
+	mockImportStorer.EXPECT().CountPending(userId).Return(services.MAX_PENDING_IMPORTS-1, nil)
+	mockImportStorer.
+		EXPECT().
+		Create(userId, "whatsapp", options, gomock.Any()).
+		DoAndReturn(func(userId int, source string, options models.ImportOptions, key string) (*models.Import, error) {
+			return &models.Import{Id: 9, UserId: userId, Source: source, Options: options, Key: key, Status: "pending"}, nil
+		})
+
+	importService := services.NewImportService(mockImportStorer, blobs)
+
+	//Act
+	imp, httpErr := importService.Create(userId, "whatsapp", options, strings.NewReader("1/1/22, 12:01 AM - Bob: hi\n"))
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, "pending", imp.Status)
+	assert.True(t, strings.HasPrefix(imp.Key, "imports/1/"))
+	blob, err := blobs.Get(imp.Key)
+	assert.Nil(t, err)
+	defer blob.Close()
+	stored, _ := io.ReadAll(blob)
+	assert.Equal(t, "1/1/22, 12:01 AM - Bob: hi\n", string(stored))
+}
+
+func TestCreateImportInvalidOptionsError(t *testing.T) {
+	//Arrange
+	expectedError := fmt.Errorf("date order has to be one of %v", services.DateOrders)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	importService := services.NewImportService(nil, nil)
+
+	//Act
+	imp, httpErr := importService.Create(1, "slack", models.ImportOptions{DateOrder: "dym"}, strings.NewReader("zip"))
+
+	//Assert
+	assert.Nil(t, imp)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestCreateImportMappingOtherUsersError(t *testing.T) {
+	//Arrange
+	userId := 1
+	expectedError := fmt.Errorf("%s can't be mapped to user %d, users can only be mapped to yourself", "U2", 2)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusForbidden)
+
+	importService := services.NewImportService(nil, nil)
+
+	//Act
+	imp, httpErr := importService.Create(userId, "slack", models.ImportOptions{UserMap: map[string]int{"U2": 2}}, strings.NewReader("zip"))
+
+	//Assert
+	assert.Nil(t, imp)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestCreateImportTooManyPendingError(t *testing.T) {
+	//Arrange
+	userId := 1
+	expectedError := fmt.Errorf("user %d can't have more than %d imports in progress", userId, services.MAX_PENDING_IMPORTS)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusTooManyRequests)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImportStorer := models_mocks.NewMockIImportStorer(ctrl)
+	mockImportStorer.EXPECT().CountPending(userId).Return(services.MAX_PENDING_IMPORTS, nil)
+
+	importService := services.NewImportService(mockImportStorer, nil)
+
+	//Act
+	imp, httpErr := importService.Create(userId, "slack", models.ImportOptions{}, strings.NewReader("zip"))
+
+	//Assert
+	assert.Nil(t, imp)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestGetImportOfAnotherUserNotFound(t *testing.T) {
+	//Arrange
+	userId, importId := 1, 9
+	expectedError := fmt.Errorf("no import with id %d", importId)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusNotFound)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImportStorer := models_mocks.NewMockIImportStorer(ctrl)
+	mockImportStorer.EXPECT().GetOne(importId).Return(&models.Import{Id: importId, UserId: userId + 1}, nil)
+	mockImportStorer.EXPECT().GetOne(importId+1).Return(nil, sql.ErrNoRows)
+
+	importService := services.NewImportService(mockImportStorer, nil)
+
+	//Act
+	imp, httpErr := importService.GetOne(userId, importId)
+	_, missingErr := importService.GetOne(userId, importId+1)
+
+	//Assert
+	assert.Nil(t, imp)
+	assert.Equal(t, expectedHTTPError, httpErr)
+	assert.Equal(t, http.StatusNotFound, missingErr.Status())
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// ParseMentions finds @tag mentions of chatUsers in content. A mention starts
+// with '@' that doesn't follow a tag character, trailing dots and dashes are
+// treated as punctuation. @all is only recognized when canMentionAll is set.
+func ParseMentions(content string, chatUsers []models.ChatUser, canMentionAll bool) []models.Mention {
+	mentions := make([]models.Mention, 0)
+	runes := []rune(content)
+	offset := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || i > 0 && isTagRune(runes[i-1]) {
+			offset += utf16Len(runes[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+		tag := strings.TrimRight(string(runes[i+1:end]), ".-")
+		tagRunes := []rune(tag)
+
+		mention := models.Mention{Offset: offset, Length: 1}
+		for _, r := range tagRunes {
+			mention.Length += utf16Len(r)
+		}
+
+		found := false
+		if tag == "all" && canMentionAll {
+			mention.All = true
+			found = true
+		}
+		for _, chatUser := range chatUsers {
+			if !found && tag != "" && chatUser.Tag == tag {
+				mention.UserId = chatUser.UserId
+				found = true
+			}
+		}
+		if found {
+			mentions = append(mentions, mention)
+		}
+
+		for _, r := range runes[i : i+1+len(tagRunes)] {
+			offset += utf16Len(r)
+		}
+		i += len(tagRunes)
+	}
+	return mentions
+}
@@ -4,33 +4,101 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/BogPin/real-time-chat/backend/api/models"
 	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"golang.org/x/exp/slices"
 )
 
 type IMessageService interface {
 	Create(userId int, MessageTDO models.MessageFromRequest) (*models.Message, utils.HttpError)
+	CreateWith(userId int, fromRequest models.MessageFromRequest, inTx func(tx *sql.Tx) error) (*models.Message, utils.HttpError)
+	Announce(userId, chatId int, event string, data any)
+	Forward(userId int, fromRequest models.ForwardFromRequest) ([]models.Message, utils.HttpError)
 	GetOne(userId, messageId int) (*models.Message, utils.HttpError)
-	GetChatMessages(userId, chatId, page int) ([]models.Message, utils.HttpError)
+	GetMany(userId, chatId int, messageIds []int) ([]models.Message, utils.HttpError)
+	GetChatMessages(userId int, query models.HistoryQuery) (*models.MessagePage, utils.HttpError)
+	GetUserMentions(userId, page int) ([]models.Message, utils.HttpError)
+	GetThread(userId, messageId, page int) (*models.ThreadView, utils.HttpError)
+	GetRevisions(userId, messageId int) ([]models.Revision, utils.HttpError)
+	Search(userId int, query models.SearchQuery) ([]models.SearchResult, utils.HttpError)
 	Update(userId int, message models.Message) (*models.Message, utils.HttpError)
-	Delete(userId int, message models.Message) (*models.Message, utils.HttpError)
+	Delete(userId, messageId int, forEveryone bool) (*models.Message, utils.HttpError)
+	MarkListened(userId, messageId int) (*models.ListenEvent, utils.HttpError)
 }
 
+const (
+	MAX_HISTORY_LIMIT      = 100
+	MAX_FORWARDED_MESSAGES = 100
+	EXPIRY_BATCH           = 100
+)
+
+// MediaMessageTypes are the message types made of attachments of the matching kind.
+var MediaMessageTypes = []string{"image", "video", "audio"}
+
+const (
+	MESSAGE_UPDATED_EVENT  = "message:updated"
+	MESSAGE_DELETED_EVENT  = "message:deleted"
+	MESSAGE_LISTENED_EVENT = "message:listened"
+)
+
 type MessageService struct {
 	MessageStorer      models.IMessageStorer
+	MentionStorer      models.IMentionStorer
+	ReactionStorer     models.IReactionStorer
+	RevisionStorer     models.IRevisionStorer
+	AttachmentStorer   models.IAttachmentStorer
+	ChatSettingsStorer models.IChatSettingsStorer
+	PollStorer         models.IPollStorer
 	ParticipantService IParticipantService
+	Broadcaster        Broadcaster
+	MediaProcessor     MediaProcessor
+	LinkPreviewer      LinkPreviewer
+	// DeleteAttachments deletes the attachments of messages deleted for everyone.
+	DeleteAttachments func(messageIds []int)
 }
 
-func NewMessageService(messageStorer models.IMessageStorer, participantService IParticipantService) MessageService {
+func NewMessageService(
+	messageStorer models.IMessageStorer,
+	mentionStorer models.IMentionStorer,
+	reactionStorer models.IReactionStorer,
+	revisionStorer models.IRevisionStorer,
+	attachmentStorer models.IAttachmentStorer,
+	chatSettingsStorer models.IChatSettingsStorer,
+	pollStorer models.IPollStorer,
+	participantService IParticipantService,
+	broadcaster Broadcaster,
+	mediaProcessor MediaProcessor,
+	linkPreviewer LinkPreviewer,
+	deleteAttachments func(messageIds []int),
+) MessageService {
 	return MessageService{
 		MessageStorer:      messageStorer,
+		MentionStorer:      mentionStorer,
+		ReactionStorer:     reactionStorer,
+		RevisionStorer:     revisionStorer,
+		AttachmentStorer:   attachmentStorer,
+		ChatSettingsStorer: chatSettingsStorer,
+		PollStorer:         pollStorer,
 		ParticipantService: participantService,
+		Broadcaster:        broadcaster,
+		MediaProcessor:     mediaProcessor,
+		LinkPreviewer:      linkPreviewer,
+		DeleteAttachments:  deleteAttachments,
 	}
 }
 
 func (ms MessageService) Create(userId int, fromRequest models.MessageFromRequest) (*models.Message, utils.HttpError) {
+	return ms.CreateWith(userId, fromRequest, nil)
+}
+
+// CreateWith creates a message like Create and runs inTx, when given, in the
+// transaction the message is inserted in. The message isn't sent if inTx fails.
+func (ms MessageService) CreateWith(userId int, fromRequest models.MessageFromRequest, inTx func(tx *sql.Tx) error) (*models.Message, utils.HttpError) {
 	chatId := fromRequest.ChatId
 	userInChat, err := ms.ParticipantService.UserInChat(userId, chatId)
 	if err != nil {
@@ -42,21 +110,333 @@ func (ms MessageService) Create(userId int, fromRequest models.MessageFromReques
 		return nil, utils.NewHttpError(err, http.StatusForbidden)
 	}
 
+	if fromRequest.Type == "system" {
+		err := errors.New("system messages can't be sent by users")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	content, entities, httpErr := renderContent(fromRequest.Content, fromRequest.Format)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var poll *models.PollDTO
+	if fromRequest.Type == "poll" {
+		if fromRequest.Poll == nil || len(fromRequest.AttachmentIds) > 0 {
+			err := errors.New("poll messages need a poll and no attachments")
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		poll, httpErr = newPoll(*fromRequest.Poll)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		content, entities = poll.Question, nil
+	} else if fromRequest.Poll != nil {
+		err := fmt.Errorf("%s messages can't have a poll", fromRequest.Type)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	parentId := 0
+	if fromRequest.ParentId != 0 {
+		parent, err := ms.MessageStorer.GetOne(fromRequest.ParentId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err := fmt.Errorf("no message with id %d", fromRequest.ParentId)
+				return nil, utils.NewHttpError(err, http.StatusBadRequest)
+			}
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		if parent.ChatId != chatId {
+			err := fmt.Errorf("message %d isn't in chat %d", parent.Id, chatId)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		// threads are one level deep, replying to a reply continues its thread
+		parentId = parent.Id
+		if parent.ParentId != 0 {
+			parentId = parent.ParentId
+		}
+	}
+
+	attachments, httpErr := ms.getAttachments(userId, chatId, fromRequest.Type, fromRequest.AttachmentIds)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	mentions, httpErr := ms.parseMentions(userId, chatId, content)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
 	dto := models.MessageDTO{
 		SenderId: userId,
 		ChatId:   fromRequest.ChatId,
 		Type:     fromRequest.Type,
-		Content:  fromRequest.Content,
+		Content:  content,
+		ParentId: parentId,
+		Entities: entities,
 	}
 
-	msg, err := ms.MessageStorer.Create(dto)
+	tx, err := ms.MessageStorer.Begin()
 	if err != nil {
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
 
+	var msg *models.Message
+	defer func() {
+		if err != nil {
+			err := tx.Rollback()
+			if err != nil {
+				log.Println(err)
+			}
+		} else {
+			err := tx.Commit()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			// images are processed once the message is there for the processor to see
+			if ms.MediaProcessor != nil && len(msg.Attachments) > 0 {
+				ms.MediaProcessor.Enqueue(*msg)
+			}
+			if ms.LinkPreviewer != nil {
+				ms.LinkPreviewer.Enqueue(*msg)
+			}
+		}
+	}()
+
+	msg, err = ms.MessageStorer.CreateInTx(tx, dto)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	err = ms.MentionStorer.CreateInTx(tx, msg.Id, mentions)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	msg.Mentions = mentions
+	msg.Reactions = make([]models.ReactionSummary, 0)
+
+	if poll != nil {
+		msg.Poll, err = ms.PollStorer.CreateInTx(tx, msg.Id, *poll)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+	}
+
+	if len(attachments) > 0 {
+		var attached int
+		attached, err = ms.AttachmentStorer.AttachInTx(tx, fromRequest.AttachmentIds, msg.Id, userId, chatId)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		if attached != len(attachments) {
+			err = errors.New("attachments were sent with another message")
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+	}
+	for i := range attachments {
+		attachments[i].MessageId = msg.Id
+	}
+	msg.Attachments = attachments
+
+	if inTx != nil {
+		if err = inTx(tx); err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+	}
+
 	return msg, nil
 }
 
+// getAttachments checks that userId can send attachments with ids to chatId in a message
+// of msgType: they are uploaded by userId to that chat, not sent yet and of the matching kind.
+func (ms MessageService) getAttachments(userId, chatId int, msgType string, ids []int) ([]models.Attachment, utils.HttpError) {
+	attachments := make([]models.Attachment, 0, len(ids))
+	mediaMessage := slices.Contains(MediaMessageTypes, msgType)
+	if mediaMessage && len(ids) == 0 {
+		err := fmt.Errorf("%s messages need attachments", msgType)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if msgType == "audio" && len(ids) > 1 {
+		err := errors.New("audio messages have a single attachment")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if len(ids) > MAX_ATTACHMENTS_PER_MESSAGE {
+		err := fmt.Errorf("a message can't have more than %d attachments", MAX_ATTACHMENTS_PER_MESSAGE)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	for _, id := range ids {
+		attachment, err := ms.AttachmentStorer.GetOne(id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		if err != nil || attachment.UploaderId != userId || attachment.ChatId != chatId {
+			err := fmt.Errorf("no attachment with id %d uploaded by user %d to chat %d", id, userId, chatId)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		if attachment.MessageId != 0 {
+			err := fmt.Errorf("attachment %d is already sent", id)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		if mediaMessage && !strings.HasPrefix(attachment.MimeType, msgType+"/") {
+			err := fmt.Errorf("attachment %d of type %s can't be sent in a %s message", id, attachment.MimeType, msgType)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		if slices.ContainsFunc(attachments, func(a models.Attachment) bool { return a.Id == id }) {
+			err := fmt.Errorf("attachment %d is repeated", id)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, nil
+}
+
+// Announce leaves a "system" message describing a chat event caused by userId in the
+// chat history and sends it to the chat, for events that happen outside of this service.
+func (ms MessageService) Announce(userId, chatId int, event string, data any) {
+	announce(ms.MessageStorer, ms.Broadcaster, userId, chatId, event, data)
+}
+
+// Forward sends copies of messages of one chat to another one, both chats must have
+// userId among their participants. Copies keep a reference to the original sender
+// and chat and share the attachments of the originals, they are sent in the order
+// the originals were.
+func (ms MessageService) Forward(userId int, fromRequest models.ForwardFromRequest) ([]models.Message, utils.HttpError) {
+	if len(fromRequest.MessageIds) == 0 || len(fromRequest.MessageIds) > MAX_FORWARDED_MESSAGES {
+		err := fmt.Errorf("from 1 to %d messages can be forwarded at once", MAX_FORWARDED_MESSAGES)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	userInChat, err := ms.ParticipantService.UserInChat(userId, fromRequest.ChatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if !userInChat {
+		err := fmt.Errorf("user %d doesn't participate in chat %d", userId, fromRequest.ChatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	originals, err := ms.MessageStorer.GetMany(fromRequest.MessageIds)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	// messages of chats userId isn't in aren't told apart from missing ones, so
+	// nothing is learned about them before the membership is checked
+	visible := make(map[int]bool, len(originals))
+	inChats := make(map[int]bool)
+	for _, original := range originals {
+		userInChat, checked := inChats[original.ChatId]
+		if !checked {
+			userInChat, err = ms.ParticipantService.UserInChat(userId, original.ChatId)
+			if err != nil {
+				return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+			}
+			inChats[original.ChatId] = userInChat
+		}
+		visible[original.Id] = userInChat
+	}
+	for _, id := range fromRequest.MessageIds {
+		if !visible[id] {
+			err := fmt.Errorf("no message with id %d", id)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+	}
+
+	sourceChatId := originals[0].ChatId
+	for _, original := range originals {
+		if original.ChatId != sourceChatId {
+			err := errors.New("forwarded messages must come from one chat")
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		if original.DeletedAt != "" || original.Type == "system" || original.Type == "poll" {
+			err := fmt.Errorf("message %d can't be forwarded", original.Id)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+	}
+
+	// images are shared as they are, so they have to be stripped of metadata first,
+	// the ones that failed to be still have it and are only ever served to their uploader
+	attachments, err := ms.AttachmentStorer.GetForMessages(fromRequest.MessageIds)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	for messageId, messageAttachments := range attachments {
+		for _, attachment := range messageAttachments {
+			if attachment.Processing {
+				err := fmt.Errorf("attachments of message %d aren't processed yet", messageId)
+				return nil, utils.NewHttpError(err, http.StatusConflict)
+			}
+			if attachment.ProcessingFailed {
+				err := fmt.Errorf("attachments of message %d couldn't be processed, they can't be forwarded", messageId)
+				return nil, utils.NewHttpError(err, http.StatusBadRequest)
+			}
+		}
+	}
+
+	slices.SortFunc(originals, func(a, b models.Message) bool { return a.Id < b.Id })
+
+	tx, err := ms.MessageStorer.Begin()
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	messages := make([]models.Message, 0, len(originals))
+	defer func() {
+		if err != nil {
+			err := tx.Rollback()
+			if err != nil {
+				log.Println(err)
+			}
+		} else {
+			err := tx.Commit()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if ms.LinkPreviewer != nil {
+				for _, msg := range messages {
+					ms.LinkPreviewer.Enqueue(msg)
+				}
+			}
+		}
+	}()
+
+	for _, original := range originals {
+		forwarded := models.Forward{MessageId: original.Id, SenderId: original.SenderId, ChatId: original.ChatId}
+		if original.Forwarded != nil {
+			forwarded = *original.Forwarded
+		}
+		dto := models.MessageDTO{
+			SenderId:  userId,
+			ChatId:    fromRequest.ChatId,
+			Type:      original.Type,
+			Content:   original.Content,
+			Forwarded: &forwarded,
+			Entities:  original.Entities,
+		}
+
+		var msg *models.Message
+		msg, err = ms.MessageStorer.CreateInTx(tx, dto)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+
+		msg.Mentions = make([]models.Mention, 0)
+		msg.Reactions = make([]models.ReactionSummary, 0)
+		msg.Attachments = make([]models.Attachment, 0)
+		if len(attachments[original.Id]) > 0 {
+			msg.Attachments, err = ms.AttachmentStorer.CopyInTx(tx, original.Id, msg.Id, userId, fromRequest.ChatId)
+			if err != nil {
+				return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+			}
+		}
+		messages = append(messages, *msg)
+	}
+
+	return messages, nil
+}
+
 func (ms MessageService) GetOne(userId, messageId int) (*models.Message, utils.HttpError) {
 	msg, err := ms.MessageStorer.GetOne(messageId)
 	if err != nil {
@@ -77,10 +457,55 @@ func (ms MessageService) GetOne(userId, messageId int) (*models.Message, utils.H
 		return nil, utils.NewHttpError(err, http.StatusForbidden)
 	}
 
-	return msg, nil
+	messages, httpErr := ms.populate(userId, []models.Message{*msg})
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	return &messages[0], nil
+}
+
+// GetMany returns the messages of a chat with messageIds in their order,
+// the ones that don't exist or belong to other chats are left out.
+func (ms MessageService) GetMany(userId, chatId int, messageIds []int) ([]models.Message, utils.HttpError) {
+	userInChat, err := ms.ParticipantService.UserInChat(userId, chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if !userInChat {
+		err := fmt.Errorf("user %d doesn't participate in chat %d", userId, chatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	if len(messageIds) == 0 {
+		return make([]models.Message, 0), nil
+	}
+
+	found, err := ms.MessageStorer.GetMany(messageIds)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	byId := make(map[int]models.Message, len(found))
+	for _, msg := range found {
+		if msg.ChatId == chatId {
+			byId[msg.Id] = msg
+		}
+	}
+	messages := make([]models.Message, 0, len(byId))
+	for _, id := range messageIds {
+		if msg, ok := byId[id]; ok {
+			messages = append(messages, msg)
+		}
+	}
+
+	return ms.populate(userId, messages)
 }
 
-func (ms MessageService) GetChatMessages(userId, chatId, page int) ([]models.Message, utils.HttpError) {
+// GetChatMessages returns a page of chat history selected by query, see models.HistoryQuery.
+func (ms MessageService) GetChatMessages(userId int, query models.HistoryQuery) (*models.MessagePage, utils.HttpError) {
+	chatId := query.ChatId
 	userInChat, err := ms.ParticipantService.UserInChat(userId, chatId)
 	if err != nil {
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
@@ -91,14 +516,275 @@ func (ms MessageService) GetChatMessages(userId, chatId, page int) ([]models.Mes
 		return nil, utils.NewHttpError(err, http.StatusForbidden)
 	}
 
-	messages, err := ms.MessageStorer.GetChatMessages(chatId, page)
+	limit := query.Limit
+	if limit == 0 {
+		limit = models.PAGE_SIZE
+	}
+	if limit < 0 || limit > MAX_HISTORY_LIMIT {
+		err := fmt.Errorf("limit should be between 1 and %d", MAX_HISTORY_LIMIT)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	selectors := 0
+	for _, selector := range []bool{query.Cursor != "", query.Before != 0, query.After != 0, query.Around != 0} {
+		if selector {
+			selectors++
+		}
+	}
+	if selectors > 1 {
+		err := errors.New("only one of cursor, before, after and around can be used")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if query.Cursor != "" {
+		direction, messageId, err := DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		if direction == CURSOR_BEFORE {
+			query.Before = messageId
+		} else {
+			query.After = messageId
+		}
+	}
+
+	var messages []models.Message
+	var hasOlder, hasNewer bool
+	switch {
+	case query.After != 0:
+		messages, err = ms.MessageStorer.GetChatMessagesAfter(userId, chatId, query.After, limit+1)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		hasOlder = true
+		if len(messages) > limit {
+			hasNewer = true
+			messages = messages[1:]
+		}
+	case query.Around != 0:
+		var httpErr utils.HttpError
+		messages, hasOlder, hasNewer, httpErr = ms.getAround(userId, chatId, query.Around, limit)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+	default:
+		messages, err = ms.MessageStorer.GetChatMessagesBefore(userId, chatId, query.Before, limit+1)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		hasNewer = query.Before != 0
+		if len(messages) > limit {
+			hasOlder = true
+			messages = messages[:limit]
+		}
+	}
+
+	messages, httpErr := ms.populate(userId, messages)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	page := models.MessagePage{Messages: messages}
+	if len(messages) > 0 {
+		if hasOlder {
+			page.NextCursor = EncodeCursor(CURSOR_BEFORE, messages[len(messages)-1].Id)
+		}
+		if hasNewer {
+			page.PrevCursor = EncodeCursor(CURSOR_AFTER, messages[0].Id)
+		}
+	}
+	return &page, nil
+}
+
+// getAround returns up to limit messages with messageId in the middle, newest first,
+// and whether there are older and newer messages beyond them.
+func (ms MessageService) getAround(userId, chatId, messageId, limit int) ([]models.Message, bool, bool, utils.HttpError) {
+	msg, err := ms.MessageStorer.GetOne(messageId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("no message with id %d", messageId)
+			return nil, false, false, utils.NewHttpError(err, http.StatusNotFound)
+		}
+		return nil, false, false, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if msg.ChatId != chatId || msg.ParentId != 0 {
+		err := fmt.Errorf("message %d isn't in the history of chat %d", messageId, chatId)
+		return nil, false, false, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	olderLimit := (limit - 1) / 2
+	newerLimit := limit - 1 - olderLimit
+
+	older, err := ms.MessageStorer.GetChatMessagesBefore(userId, chatId, messageId, olderLimit+1)
+	if err != nil {
+		return nil, false, false, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	hasOlder := len(older) > olderLimit
+	if hasOlder {
+		older = older[:olderLimit]
+	}
+
+	newer, err := ms.MessageStorer.GetChatMessagesAfter(userId, chatId, messageId, newerLimit+1)
+	if err != nil {
+		return nil, false, false, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	hasNewer := len(newer) > newerLimit
+	if hasNewer {
+		newer = newer[1:]
+	}
+
+	messages := make([]models.Message, 0, len(newer)+1+len(older))
+	messages = append(messages, newer...)
+	messages = append(messages, *msg)
+	messages = append(messages, older...)
+	return messages, hasOlder, hasNewer, nil
+}
+
+func (ms MessageService) GetUserMentions(userId, page int) ([]models.Message, utils.HttpError) {
+	messages, err := ms.MessageStorer.GetUserMentions(userId, page)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return ms.populate(userId, messages)
+}
+
+// GetThread returns the root of the thread messageId belongs to with a page of its replies.
+func (ms MessageService) GetThread(userId, messageId, page int) (*models.ThreadView, utils.HttpError) {
+	msg, httpErr := ms.GetOne(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	root := *msg
+	if msg.ParentId != 0 {
+		parent, httpErr := ms.GetOne(userId, msg.ParentId)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		root = *parent
+	}
+
+	replies, err := ms.MessageStorer.GetReplies(userId, root.Id, page)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	replies, httpErr = ms.populate(userId, replies)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	return &models.ThreadView{Root: root, Replies: replies}, nil
+}
+
+// populate attaches mentions and reactions, as seen by userId, attachments, listeners
+// of audio and polls, as seen by userId too, to messages and thread summaries to the
+// roots of threads.
+func (ms MessageService) populate(userId int, messages []models.Message) ([]models.Message, utils.HttpError) {
+	ids := make([]int, 0, len(messages))
+	rootIds := make([]int, 0, len(messages))
+	audioIds := make([]int, 0)
+	pollIds := make([]int, 0)
+	for _, msg := range messages {
+		ids = append(ids, msg.Id)
+		if msg.ParentId == 0 {
+			rootIds = append(rootIds, msg.Id)
+		}
+		if msg.Type == "audio" {
+			audioIds = append(audioIds, msg.Id)
+		}
+		if msg.Type == "poll" {
+			pollIds = append(pollIds, msg.Id)
+		}
+	}
+
+	mentions, err := ms.MentionStorer.GetForMessages(ids)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	reactions, err := ms.ReactionStorer.GetForMessages(ids, userId)
 	if err != nil {
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
 
+	attachments, err := ms.AttachmentStorer.GetForMessages(ids)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	listeners := make(map[int][]int)
+	if len(audioIds) > 0 {
+		listeners, err = ms.MessageStorer.GetListeners(audioIds)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+	}
+
+	polls := make(map[int]models.Poll)
+	if len(pollIds) > 0 {
+		polls, err = ms.PollStorer.GetForMessages(pollIds, userId)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+	}
+
+	threads := make(map[int]models.Thread)
+	if len(rootIds) > 0 {
+		threads, err = ms.MessageStorer.GetThreads(rootIds)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+	}
+
+	for i := range messages {
+		messages[i].Mentions = mentions[messages[i].Id]
+		if messages[i].Mentions == nil {
+			messages[i].Mentions = make([]models.Mention, 0)
+		}
+		messages[i].Reactions = reactions[messages[i].Id]
+		if messages[i].Reactions == nil {
+			messages[i].Reactions = make([]models.ReactionSummary, 0)
+		}
+		messages[i].Attachments = attachments[messages[i].Id]
+		if messages[i].Attachments == nil || messages[i].DeletedAt != "" {
+			messages[i].Attachments = make([]models.Attachment, 0)
+		}
+		messages[i].ListenedBy = listeners[messages[i].Id]
+		if poll, ok := polls[messages[i].Id]; ok {
+			messages[i].Poll = &poll
+		}
+		if thread, ok := threads[messages[i].Id]; ok {
+			messages[i].Thread = &thread
+		}
+	}
 	return messages, nil
 }
 
+// parseMentions finds mentions of chat participants in content written by userId.
+func (ms MessageService) parseMentions(userId, chatId int, content string) ([]models.Mention, utils.HttpError) {
+	if !strings.Contains(content, "@") {
+		return make([]models.Mention, 0), nil
+	}
+
+	chatUsers, httpErr := ms.ParticipantService.GetChatUsers(userId, chatId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	canMentionAll := false
+	for _, chatUser := range chatUsers {
+		if chatUser.UserId == userId {
+			canMentionAll = chatUser.Role == "admin"
+		}
+	}
+	return ParseMentions(content, chatUsers, canMentionAll), nil
+}
+
+// Update edits the content of a message, keeping the previous content as a revision.
+// Content is written in message.Format like the one of new messages. Messages
+// can only be edited by their senders within the edit window of the chat.
 func (ms MessageService) Update(userId int, message models.Message) (*models.Message, utils.HttpError) {
 	originalMsg, httpErr := ms.GetOne(userId, message.Id)
 	if httpErr != nil {
@@ -110,7 +796,94 @@ func (ms MessageService) Update(userId int, message models.Message) (*models.Mes
 		return nil, utils.NewHttpError(err, http.StatusForbidden)
 	}
 
-	msg, err := ms.MessageStorer.Update(message)
+	if originalMsg.Type == "system" {
+		err := errors.New("system messages can't be edited")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if originalMsg.Type == "poll" {
+		err := errors.New("polls can't be edited")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if originalMsg.DeletedAt != "" {
+		err := fmt.Errorf("message %d is deleted", originalMsg.Id)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	settings, err := ms.ChatSettingsStorer.Get(originalMsg.ChatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	content, entities, httpErr := renderContent(message.Content, message.Format)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	mentions, httpErr := ms.parseMentions(userId, originalMsg.ChatId, content)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	edited := models.Message{Id: originalMsg.Id, Content: content, Entities: entities}
+	msg, httpErr := ms.edit(*originalMsg, edited, mentions, settings.EditWindow)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	messages, httpErr := ms.populate(userId, []models.Message{*msg})
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	ms.broadcastUpdate(*msg)
+	if ms.LinkPreviewer != nil {
+		ms.LinkPreviewer.Enqueue(*msg)
+	}
+	return &messages[0], nil
+}
+
+func (ms MessageService) edit(original, edited models.Message, mentions []models.Mention, editWindow int) (*models.Message, utils.HttpError) {
+	tx, err := ms.MessageStorer.Begin()
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	defer func() {
+		if err != nil {
+			err := tx.Rollback()
+			if err != nil {
+				log.Println(err)
+			}
+		} else {
+			err := tx.Commit()
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
+	msg, err := ms.MessageStorer.UpdateInTx(tx, edited, editWindow)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("message %d can't be edited more than %d seconds after it was sent", original.Id, editWindow)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	_, err = ms.RevisionStorer.CreateInTx(tx, msg.Id, original.Content)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	err = ms.MentionStorer.DeleteInTx(tx, msg.Id)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	err = ms.MentionStorer.CreateInTx(tx, msg.Id, mentions)
 	if err != nil {
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
@@ -118,21 +891,189 @@ func (ms MessageService) Update(userId int, message models.Message) (*models.Mes
 	return msg, nil
 }
 
-func (ms MessageService) Delete(userId int, message models.Message) (*models.Message, utils.HttpError) {
-	originalMsg, httpErr := ms.GetOne(userId, message.Id)
+// broadcastUpdate tells the chat about a changed message. Reactions are
+// attached as seen by nobody since every participant receives the same event.
+func (ms MessageService) broadcastUpdate(msg models.Message) {
+	if ms.Broadcaster == nil {
+		return
+	}
+	messages, httpErr := ms.populate(0, []models.Message{msg})
+	if httpErr != nil {
+		log.Println(httpErr.Message())
+		return
+	}
+	ms.Broadcaster.Broadcast(msg.ChatId, MESSAGE_UPDATED_EVENT, messages[0])
+}
+
+// BroadcastUpdated tells the chat about a message changed outside of the service,
+// like an image attachment being processed.
+func (ms MessageService) BroadcastUpdated(messageId int) {
+	msg, err := ms.MessageStorer.GetOne(messageId)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	ms.broadcastUpdate(*msg)
+}
+
+func (ms MessageService) GetRevisions(userId, messageId int) ([]models.Revision, utils.HttpError) {
+	msg, httpErr := ms.GetOne(userId, messageId)
 	if httpErr != nil {
 		return nil, httpErr
 	}
 
-	if userId != originalMsg.SenderId {
-		err := fmt.Errorf("user %d didn't send message %d", userId, originalMsg.Id)
-		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	revisions, err := ms.RevisionStorer.GetForMessage(msg.Id)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return revisions, nil
+}
+
+// Delete removes a message for userId only or, with forEveryone, leaves a tombstone
+// in its place for the whole chat. Senders can delete their messages for everyone
+// within the delete window of the chat, admins can delete any message at any time.
+func (ms MessageService) Delete(userId, messageId int, forEveryone bool) (*models.Message, utils.HttpError) {
+	originalMsg, httpErr := ms.GetOne(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if !forEveryone {
+		err := ms.MessageStorer.Hide(userId, originalMsg.Id)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		return originalMsg, nil
+	}
+
+	if originalMsg.DeletedAt != "" {
+		err := fmt.Errorf("message %d is already deleted", originalMsg.Id)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
 	}
 
-	msg, err := ms.MessageStorer.Delete(message.Id)
+	isAdmin, httpErr := ms.isAdmin(userId, originalMsg.ChatId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	deleteWindow := 0
+	if !isAdmin {
+		if userId != originalMsg.SenderId || originalMsg.Type == "system" {
+			err := fmt.Errorf("user %d doesn't have permission to delete message %d", userId, originalMsg.Id)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
+
+		settings, err := ms.ChatSettingsStorer.Get(originalMsg.ChatId)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		deleteWindow = settings.DeleteWindow
+	}
+
+	msg, err := ms.MessageStorer.Delete(originalMsg.Id, userId, deleteWindow)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("message %d can't be deleted more than %d seconds after it was sent", originalMsg.Id, deleteWindow)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
+	msg.Mentions = make([]models.Mention, 0)
+	msg.Reactions = make([]models.ReactionSummary, 0)
+	msg.Attachments = make([]models.Attachment, 0)
+	msg.Thread = originalMsg.Thread
+	if ms.DeleteAttachments != nil {
+		ms.DeleteAttachments([]int{msg.Id})
+	}
 
+	if ms.Broadcaster != nil {
+		ms.Broadcaster.Broadcast(msg.ChatId, MESSAGE_DELETED_EVENT, msg)
+	}
 	return msg, nil
 }
+
+// StartExpiring runs ExpireMessages every interval. Expired messages are claimed
+// by one query so any number of instances can run it.
+func (ms MessageService) StartExpiring(interval time.Duration, onExpired func(messageIds []int)) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := ms.ExpireMessages(onExpired); err != nil {
+				log.Printf("error while expiring messages: %v\n", err)
+			}
+		}
+	}()
+}
+
+// ExpireMessages deletes the messages that outlived the TTL of their chat for
+// everyone and tells the chats with "message:deleted" events. onExpired is
+// called with the ids of each batch so their attachments can be deleted.
+func (ms MessageService) ExpireMessages(onExpired func(messageIds []int)) error {
+	for {
+		messages, err := ms.MessageStorer.DeleteExpired(EXPIRY_BATCH)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, 0, len(messages))
+		for i := range messages {
+			ids = append(ids, messages[i].Id)
+			messages[i].Mentions = make([]models.Mention, 0)
+			messages[i].Reactions = make([]models.ReactionSummary, 0)
+			messages[i].Attachments = make([]models.Attachment, 0)
+			if ms.Broadcaster != nil {
+				ms.Broadcaster.Broadcast(messages[i].ChatId, MESSAGE_DELETED_EVENT, &messages[i])
+			}
+		}
+		if len(ids) > 0 {
+			onExpired(ids)
+		}
+
+		if len(messages) < EXPIRY_BATCH {
+			return nil
+		}
+	}
+}
+
+// MarkListened records that a recipient of an audio message listened to it,
+// the chat is told the first time with a "message:listened" event.
+func (ms MessageService) MarkListened(userId, messageId int) (*models.ListenEvent, utils.HttpError) {
+	msg, httpErr := ms.GetOne(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if msg.Type != "audio" || msg.DeletedAt != "" {
+		err := fmt.Errorf("message %d isn't an audio message", messageId)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if msg.SenderId == userId {
+		err := errors.New("senders don't listen to their own messages")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	listened, err := ms.MessageStorer.MarkListened(userId, messageId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	event := models.ListenEvent{MessageId: msg.Id, ChatId: msg.ChatId, UserId: userId}
+	if listened && ms.Broadcaster != nil {
+		ms.Broadcaster.Broadcast(msg.ChatId, MESSAGE_LISTENED_EVENT, event)
+	}
+	return &event, nil
+}
+
+func (ms MessageService) isAdmin(userId, chatId int) (bool, utils.HttpError) {
+	chatUsers, httpErr := ms.ParticipantService.GetChatUsers(userId, chatId)
+	if httpErr != nil {
+		return false, httpErr
+	}
+	for _, chatUser := range chatUsers {
+		if chatUser.UserId == userId {
+			return chatUser.Role == "admin", nil
+		}
+	}
+	return false, nil
+}
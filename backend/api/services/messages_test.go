@@ -0,0 +1,822 @@
+package services_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateMessageSystemTypeError(t *testing.T) {
+	//Arrange
+	userId := 1
+	fromRequest := models.MessageFromRequest{ChatId: 1, Type: "system", Content: "{}"}
+	expectedError := errors.New("system messages can't be sent by users")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.
+		EXPECT().
+		UserInChat(userId, fromRequest.ChatId).
+		Return(true, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      models_mocks.NewMockIMessageStorer(ctrl),
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	actualMessage, httpErr := messageService.Create(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, actualMessage)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestAnnounceSystemMessage(t *testing.T) {
+	//Arrange
+	userId := 1
+	chatId := 2
+	expectedDTO := models.MessageDTO{
+		SenderId: userId,
+		ChatId:   chatId,
+		Type:     "system",
+		Content:  `{"event":"call_started","data":{"media":"video"}}`,
+	}
+	createdMessage := models.Message{
+		Id:        1,
+		SenderId:  userId,
+		ChatId:    chatId,
+		Type:      expectedDTO.Type,
+		Content:   expectedDTO.Content,
+		CreatedAt: "2023-06-27 12:00:00",
+	}
+	expectedMessage := createdMessage
+	expectedMessage.Mentions = make([]models.Mention, 0)
+	expectedMessage.Reactions = make([]models.ReactionSummary, 0)
+	expectedMessage.Attachments = make([]models.Attachment, 0)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.
+		EXPECT().
+		Create(expectedDTO).
+		Return(&createdMessage, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(chatId, "message", &expectedMessage)
+
+	messageService := services.MessageService{MessageStorer: mockMessageStorer, Broadcaster: mockBroadcaster}
+
+	//Act
+	messageService.Announce(userId, chatId, "call_started", map[string]any{"media": "video"})
+}
+
+func TestParseMentions(t *testing.T) {
+	//Arrange
+	chatUsers := []models.ChatUser{
+		{Participant: models.Participant{UserId: 1}, Tag: "bogpin"},
+		{Participant: models.Participant{UserId: 4}, Tag: "holdennekt"},
+	}
+	content := "привіт @bogpin, ask @holdennekt. mail a@bogpin @nobody @all"
+	expectedMentions := []models.Mention{
+		{UserId: 1, Offset: 7, Length: 7},
+		{UserId: 4, Offset: 20, Length: 11},
+	}
+
+	//Act
+	actualMentions := services.ParseMentions(content, chatUsers, false)
+
+	//Assert
+	assert.Equal(t, expectedMentions, actualMentions)
+}
+
+func TestParseMentionsAll(t *testing.T) {
+	//Arrange
+	content := "😀 @all"
+	expectedMentions := []models.Mention{{All: true, Offset: 3, Length: 4}}
+
+	//Act
+	actualMentions := services.ParseMentions(content, nil, true)
+
+	//Assert
+	assert.Equal(t, expectedMentions, actualMentions)
+}
+
+func TestCreateMessageWithMentionsSuccess(t *testing.T) {
+	//Arrange
+	userId := 1
+	fromRequest := models.MessageFromRequest{ChatId: 2, Type: "text", Content: "hi @bogpin"}
+	chatUsers := []models.ChatUser{
+		{Participant: models.Participant{UserId: userId, ChatId: 2, Role: "member"}, Tag: "holdennekt"},
+		{Participant: models.Participant{UserId: 4, ChatId: 2, Role: "admin"}, Tag: "bogpin"},
+	}
+	expectedDTO := models.MessageDTO{SenderId: userId, ChatId: 2, Type: "text", Content: fromRequest.Content}
+	expectedMentions := []models.Mention{{UserId: 4, Offset: 3, Length: 7}}
+	createdMessage := models.Message{Id: 10, SenderId: userId, ChatId: 2, Type: "text", Content: fromRequest.Content}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+	mockParticipantService.EXPECT().GetChatUsers(userId, fromRequest.ChatId).Return(chatUsers, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().Begin().Return(db.Begin())
+	mockMessageStorer.
+		EXPECT().
+		CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), expectedDTO).
+		Return(&createdMessage, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.
+		EXPECT().
+		CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), createdMessage.Id, expectedMentions).
+		Return(nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		MentionStorer:      mockMentionStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	actualMessage, httpErr := messageService.Create(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, expectedMentions, actualMessage.Mentions)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateReplyToReplyJoinsRootThread(t *testing.T) {
+	//Arrange
+	userId := 1
+	root := models.Message{Id: 3, SenderId: 2, ChatId: 2, Type: "text", Content: "root"}
+	reply := models.Message{Id: 4, SenderId: 2, ChatId: 2, Type: "text", Content: "reply", ParentId: root.Id}
+	fromRequest := models.MessageFromRequest{ChatId: 2, Type: "text", Content: "reply to reply", ParentId: reply.Id}
+	expectedDTO := models.MessageDTO{SenderId: userId, ChatId: 2, Type: "text", Content: fromRequest.Content, ParentId: root.Id}
+	createdMessage := models.Message{Id: 5, SenderId: userId, ChatId: 2, Type: "text", Content: fromRequest.Content, ParentId: root.Id}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetOne(reply.Id).Return(&reply, nil)
+	mockMessageStorer.EXPECT().Begin().Return(db.Begin())
+	mockMessageStorer.
+		EXPECT().
+		CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), expectedDTO).
+		Return(&createdMessage, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.
+		EXPECT().
+		CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), createdMessage.Id, []models.Mention{}).
+		Return(nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		MentionStorer:      mockMentionStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	actualMessage, httpErr := messageService.Create(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, root.Id, actualMessage.ParentId)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateReplyOtherChatError(t *testing.T) {
+	//Arrange
+	userId := 1
+	parent := models.Message{Id: 3, SenderId: 2, ChatId: 7, Type: "text", Content: "elsewhere"}
+	fromRequest := models.MessageFromRequest{ChatId: 2, Type: "text", Content: "reply", ParentId: parent.Id}
+	expectedError := fmt.Errorf("message %d isn't in chat %d", parent.Id, fromRequest.ChatId)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetOne(parent.Id).Return(&parent, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	actualMessage, httpErr := messageService.Create(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, actualMessage)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestUpdateMessageStoresRevision(t *testing.T) {
+	//Arrange
+	userId := 1
+	original := models.Message{Id: 5, SenderId: userId, ChatId: 2, Type: "text", Content: "helo"}
+	edited := models.Message{Id: original.Id, Content: "hello"}
+	updated := original
+	updated.Content = edited.Content
+	updated.EditedAt = "2023-06-27 12:01:00"
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, original.ChatId).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetOne(original.Id).Return(&original, nil)
+	mockMessageStorer.EXPECT().GetThreads(gomock.Any()).Return(map[int]models.Thread{}, nil).Times(3)
+	mockMessageStorer.EXPECT().Begin().Return(db.Begin())
+	mockMessageStorer.
+		EXPECT().
+		UpdateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), edited, 60).
+		Return(&updated, nil)
+
+	mockRevisionStorer := models_mocks.NewMockIRevisionStorer(ctrl)
+	mockRevisionStorer.
+		EXPECT().
+		CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), original.Id, original.Content).
+		Return(&models.Revision{Id: 1, MessageId: original.Id, Content: original.Content}, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Mention{}, nil).Times(3)
+	mockMentionStorer.EXPECT().DeleteInTx(gomock.AssignableToTypeOf(&sql.Tx{}), original.Id).Return(nil)
+	mockMentionStorer.EXPECT().CreateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), original.Id, []models.Mention{}).Return(nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetForMessages(gomock.Any(), gomock.Any()).Return(map[int][]models.ReactionSummary{}, nil).Times(3)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Attachment{}, nil).Times(3)
+
+	mockChatSettingsStorer := models_mocks.NewMockIChatSettingsStorer(ctrl)
+	mockChatSettingsStorer.EXPECT().Get(original.ChatId).Return(&models.ChatSettings{ChatId: original.ChatId, EditWindow: 60}, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(original.ChatId, services.MESSAGE_UPDATED_EVENT, gomock.Any())
+
+	messageService := services.NewMessageService(
+		mockMessageStorer,
+		mockMentionStorer,
+		mockReactionStorer,
+		mockRevisionStorer,
+		mockAttachmentStorer,
+		mockChatSettingsStorer,
+		nil,
+		mockParticipantService,
+		mockBroadcaster,
+		nil,
+		nil,
+		nil,
+	)
+
+	//Act
+	actualMessage, httpErr := messageService.Update(userId, models.Message{Id: original.Id, Content: "hello"})
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, "hello", actualMessage.Content)
+	assert.Equal(t, updated.EditedAt, actualMessage.EditedAt)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateMessageOutsideEditWindowError(t *testing.T) {
+	//Arrange
+	userId := 1
+	original := models.Message{Id: 5, SenderId: userId, ChatId: 2, Type: "text", Content: "helo"}
+	expectedError := fmt.Errorf("message %d can't be edited more than %d seconds after it was sent", original.Id, 60)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusForbidden)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, original.ChatId).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetOne(original.Id).Return(&original, nil)
+	mockMessageStorer.EXPECT().GetThreads(gomock.Any()).Return(map[int]models.Thread{}, nil)
+	mockMessageStorer.EXPECT().Begin().Return(db.Begin())
+	mockMessageStorer.
+		EXPECT().
+		UpdateInTx(gomock.AssignableToTypeOf(&sql.Tx{}), gomock.Any(), 60).
+		Return(nil, sql.ErrNoRows)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Mention{}, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetForMessages(gomock.Any(), gomock.Any()).Return(map[int][]models.ReactionSummary{}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Attachment{}, nil)
+
+	mockChatSettingsStorer := models_mocks.NewMockIChatSettingsStorer(ctrl)
+	mockChatSettingsStorer.EXPECT().Get(original.ChatId).Return(&models.ChatSettings{ChatId: original.ChatId, EditWindow: 60}, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		MentionStorer:      mockMentionStorer,
+		ReactionStorer:     mockReactionStorer,
+		AttachmentStorer:   mockAttachmentStorer,
+		ChatSettingsStorer: mockChatSettingsStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	actualMessage, httpErr := messageService.Update(userId, models.Message{Id: original.Id, Content: "hello"})
+
+	//Assert
+	assert.Nil(t, actualMessage)
+	assert.Equal(t, expectedHTTPError, httpErr)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteMessageForEveryoneByAdmin(t *testing.T) {
+	//Arrange
+	adminId := 1
+	original := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "spam"}
+	tombstone := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", DeletedAt: "2023-06-27 12:00:00", DeletedBy: adminId}
+	chatUsers := []models.ChatUser{
+		{Participant: models.Participant{UserId: adminId, ChatId: 3, Role: "admin"}},
+		{Participant: models.Participant{UserId: 2, ChatId: 3, Role: "member"}},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(adminId, original.ChatId).Return(true, nil)
+	mockParticipantService.EXPECT().GetChatUsers(adminId, original.ChatId).Return(chatUsers, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetOne(original.Id).Return(&original, nil)
+	mockMessageStorer.EXPECT().GetThreads(gomock.Any()).Return(map[int]models.Thread{}, nil)
+	mockMessageStorer.EXPECT().Delete(original.Id, adminId, 0).Return(&tombstone, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Mention{}, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetForMessages(gomock.Any(), adminId).Return(map[int][]models.ReactionSummary{}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Attachment{}, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(original.ChatId, services.MESSAGE_DELETED_EVENT, gomock.Any())
+
+	var deletedAttachmentsOf []int
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		MentionStorer:      mockMentionStorer,
+		ReactionStorer:     mockReactionStorer,
+		AttachmentStorer:   mockAttachmentStorer,
+		ParticipantService: mockParticipantService,
+		Broadcaster:        mockBroadcaster,
+		DeleteAttachments: func(messageIds []int) {
+			deletedAttachmentsOf = messageIds
+		},
+	}
+
+	//Act
+	actualMessage, httpErr := messageService.Delete(adminId, original.Id, true)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, "", actualMessage.Content)
+	assert.Equal(t, adminId, actualMessage.DeletedBy)
+	assert.Equal(t, []int{original.Id}, deletedAttachmentsOf)
+}
+
+func TestDeleteMessageForEveryoneNotSenderError(t *testing.T) {
+	//Arrange
+	userId := 1
+	original := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "hello"}
+	chatUsers := []models.ChatUser{
+		{Participant: models.Participant{UserId: userId, ChatId: 3, Role: "member"}},
+		{Participant: models.Participant{UserId: 2, ChatId: 3, Role: "member"}},
+	}
+	expectedError := fmt.Errorf("user %d doesn't have permission to delete message %d", userId, original.Id)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusForbidden)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, original.ChatId).Return(true, nil)
+	mockParticipantService.EXPECT().GetChatUsers(userId, original.ChatId).Return(chatUsers, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetOne(original.Id).Return(&original, nil)
+	mockMessageStorer.EXPECT().GetThreads(gomock.Any()).Return(map[int]models.Thread{}, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Mention{}, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetForMessages(gomock.Any(), userId).Return(map[int][]models.ReactionSummary{}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Attachment{}, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		MentionStorer:      mockMentionStorer,
+		ReactionStorer:     mockReactionStorer,
+		AttachmentStorer:   mockAttachmentStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	actualMessage, httpErr := messageService.Delete(userId, original.Id, true)
+
+	//Assert
+	assert.Nil(t, actualMessage)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestGetChatMessagesCursorPage(t *testing.T) {
+	//Arrange
+	userId := 1
+	chatId := 2
+	stored := []models.Message{
+		{Id: 9, SenderId: 2, ChatId: chatId, Type: "text", Content: "9"},
+		{Id: 8, SenderId: 2, ChatId: chatId, Type: "text", Content: "8"},
+		{Id: 7, SenderId: 2, ChatId: chatId, Type: "text", Content: "7"},
+	}
+	query := models.HistoryQuery{ChatId: chatId, Cursor: services.EncodeCursor(services.CURSOR_BEFORE, 10), Limit: 2}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, chatId).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetChatMessagesBefore(userId, chatId, 10, 3).Return(stored, nil)
+	mockMessageStorer.EXPECT().GetThreads([]int{9, 8}).Return(map[int]models.Thread{}, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages([]int{9, 8}).Return(map[int][]models.Mention{}, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetForMessages([]int{9, 8}, userId).Return(map[int][]models.ReactionSummary{}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Attachment{}, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		MentionStorer:      mockMentionStorer,
+		ReactionStorer:     mockReactionStorer,
+		AttachmentStorer:   mockAttachmentStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	page, httpErr := messageService.GetChatMessages(userId, query)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Len(t, page.Messages, 2)
+	assert.Equal(t, services.EncodeCursor(services.CURSOR_BEFORE, 8), page.NextCursor)
+	assert.Equal(t, services.EncodeCursor(services.CURSOR_AFTER, 9), page.PrevCursor)
+}
+
+func TestDecodeCursorError(t *testing.T) {
+	//Arrange
+	cursors := []string{"", "not base64!", services.EncodeCursor("sideways", 1), services.EncodeCursor(services.CURSOR_AFTER, 0)}
+
+	//Act & Assert
+	for _, cursor := range cursors {
+		_, _, err := services.DecodeCursor(cursor)
+		assert.Equal(t, services.ErrBadCursor, err, cursor)
+	}
+}
+
+func TestMarkListenedBroadcastsFirstListen(t *testing.T) {
+	//Arrange
+	userId := 2
+	voiceNote := models.Message{Id: 5, SenderId: 1, ChatId: 3, Type: "audio"}
+	expectedEvent := models.ListenEvent{MessageId: voiceNote.Id, ChatId: voiceNote.ChatId, UserId: userId}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, voiceNote.ChatId).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetOne(voiceNote.Id).Return(&voiceNote, nil)
+	mockMessageStorer.EXPECT().GetThreads(gomock.Any()).Return(map[int]models.Thread{}, nil)
+	mockMessageStorer.EXPECT().GetListeners([]int{voiceNote.Id}).Return(map[int][]int{}, nil)
+	mockMessageStorer.EXPECT().MarkListened(userId, voiceNote.Id).Return(true, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Mention{}, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetForMessages(gomock.Any(), userId).Return(map[int][]models.ReactionSummary{}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Attachment{}, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(voiceNote.ChatId, services.MESSAGE_LISTENED_EVENT, expectedEvent)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		MentionStorer:      mockMentionStorer,
+		ReactionStorer:     mockReactionStorer,
+		AttachmentStorer:   mockAttachmentStorer,
+		ParticipantService: mockParticipantService,
+		Broadcaster:        mockBroadcaster,
+	}
+
+	//Act
+	event, httpErr := messageService.MarkListened(userId, voiceNote.Id)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, expectedEvent, *event)
+}
+
+func TestExpireMessagesBroadcastsDeletions(t *testing.T) {
+	//Arrange
+	expired := []models.Message{
+		{Id: 5, SenderId: 1, ChatId: 3, Type: "text", DeletedAt: "2023-01-01 10:00:00"},
+		{Id: 6, SenderId: 2, ChatId: 3, Type: "image", DeletedAt: "2023-01-01 10:00:00"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().DeleteExpired(services.EXPIRY_BATCH).Return(expired, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	for _, msg := range expired {
+		tombstone := msg
+		tombstone.Mentions = make([]models.Mention, 0)
+		tombstone.Reactions = make([]models.ReactionSummary, 0)
+		tombstone.Attachments = make([]models.Attachment, 0)
+		mockBroadcaster.EXPECT().Broadcast(msg.ChatId, services.MESSAGE_DELETED_EVENT, &tombstone)
+	}
+
+	messageService := services.MessageService{
+		MessageStorer: mockMessageStorer,
+		Broadcaster:   mockBroadcaster,
+	}
+	var expiredIds []int
+
+	//Act
+	err := messageService.ExpireMessages(func(messageIds []int) { expiredIds = messageIds })
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []int{5, 6}, expiredIds)
+}
+
+func TestForwardMessagesSuccess(t *testing.T) {
+	//Arrange
+	userId := 1
+	fromRequest := models.ForwardFromRequest{ChatId: 4, MessageIds: []int{6, 5}}
+	originals := []models.Message{
+		{Id: 6, SenderId: 2, ChatId: 3, Type: "image", Content: "look"},
+		{Id: 5, SenderId: 7, ChatId: 3, Type: "text", Content: "hi", Forwarded: &models.Forward{MessageId: 1, SenderId: 8, ChatId: 9}},
+	}
+	image := models.Attachment{Id: 11, MessageId: 6, ChatId: 3, Key: "chats/3/a.png", MimeType: "image/png"}
+	copied := models.Attachment{Id: 12, MessageId: 21, ChatId: fromRequest.ChatId, Key: image.Key, MimeType: image.MimeType}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while begining transaction", err)
+	}
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+	mockParticipantService.EXPECT().UserInChat(userId, 3).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetMany(fromRequest.MessageIds).Return(originals, nil)
+	mockMessageStorer.EXPECT().Begin().Return(tx, nil)
+	mockMessageStorer.
+		EXPECT().
+		CreateInTx(tx, models.MessageDTO{
+			SenderId:  userId,
+			ChatId:    fromRequest.ChatId,
+			Type:      "text",
+			Content:   "hi",
+			Forwarded: &models.Forward{MessageId: 1, SenderId: 8, ChatId: 9},
+		}).
+		Return(&models.Message{Id: 20, SenderId: userId, ChatId: fromRequest.ChatId, Type: "text", Content: "hi"}, nil)
+	mockMessageStorer.
+		EXPECT().
+		CreateInTx(tx, models.MessageDTO{
+			SenderId:  userId,
+			ChatId:    fromRequest.ChatId,
+			Type:      "image",
+			Content:   "look",
+			Forwarded: &models.Forward{MessageId: 6, SenderId: 2, ChatId: 3},
+		}).
+		Return(&models.Message{Id: 21, SenderId: userId, ChatId: fromRequest.ChatId, Type: "image", Content: "look"}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.
+		EXPECT().
+		GetForMessages(fromRequest.MessageIds).
+		Return(map[int][]models.Attachment{6: {image}}, nil)
+	mockAttachmentStorer.EXPECT().CopyInTx(tx, 6, 21, userId, fromRequest.ChatId).Return([]models.Attachment{copied}, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		AttachmentStorer:   mockAttachmentStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	messages, httpErr := messageService.Forward(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, []int{20, 21}, []int{messages[0].Id, messages[1].Id})
+	assert.Equal(t, []models.Attachment{}, messages[0].Attachments)
+	assert.Equal(t, []models.Attachment{copied}, messages[1].Attachments)
+	assert.Nil(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestForwardMessagesTargetChatError(t *testing.T) {
+	//Arrange
+	userId := 1
+	fromRequest := models.ForwardFromRequest{ChatId: 4, MessageIds: []int{5}}
+	expectedError := fmt.Errorf("user %d doesn't participate in chat %d", userId, fromRequest.ChatId)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusForbidden)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(false, nil)
+
+	messageService := services.MessageService{
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	messages, httpErr := messageService.Forward(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, messages)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestForwardMessagesOfOtherChatNotFound(t *testing.T) {
+	//Arrange
+	userId := 1
+	fromRequest := models.ForwardFromRequest{ChatId: 4, MessageIds: []int{5, 6}}
+	originals := []models.Message{
+		{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "hi"},
+		{Id: 6, SenderId: 2, ChatId: 3, Type: "poll", DeletedAt: "2023-06-14 18:53:25"},
+	}
+	expectedError := fmt.Errorf("no message with id %d", 5)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusNotFound)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+	mockParticipantService.EXPECT().UserInChat(userId, 3).Return(false, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetMany(fromRequest.MessageIds).Return(originals, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	messages, httpErr := messageService.Forward(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, messages)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestForwardMessagesFailedImageError(t *testing.T) {
+	//Arrange
+	userId := 1
+	fromRequest := models.ForwardFromRequest{ChatId: 4, MessageIds: []int{6}}
+	original := models.Message{Id: 6, SenderId: 2, ChatId: 3, Type: "image", Content: "look"}
+	image := models.Attachment{Id: 11, MessageId: 6, ChatId: 3, Key: "chats/3/a.png", MimeType: "image/png", ProcessingFailed: true}
+	expectedError := fmt.Errorf("attachments of message %d couldn't be processed, they can't be forwarded", original.Id)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+	mockParticipantService.EXPECT().UserInChat(userId, original.ChatId).Return(true, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().GetMany(fromRequest.MessageIds).Return([]models.Message{original}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.
+		EXPECT().
+		GetForMessages(fromRequest.MessageIds).
+		Return(map[int][]models.Attachment{6: {image}}, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:      mockMessageStorer,
+		AttachmentStorer:   mockAttachmentStorer,
+		ParticipantService: mockParticipantService,
+	}
+
+	//Act
+	messages, httpErr := messageService.Forward(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, messages)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
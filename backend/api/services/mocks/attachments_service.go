@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/attachments.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	io "io"
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMediaProcessor is a mock of MediaProcessor interface.
+type MockMediaProcessor struct {
+	ctrl     *gomock.Controller
+	recorder *MockMediaProcessorMockRecorder
+}
+
+// MockMediaProcessorMockRecorder is the mock recorder for MockMediaProcessor.
+type MockMediaProcessorMockRecorder struct {
+	mock *MockMediaProcessor
+}
+
+// NewMockMediaProcessor creates a new mock instance.
+func NewMockMediaProcessor(ctrl *gomock.Controller) *MockMediaProcessor {
+	mock := &MockMediaProcessor{ctrl: ctrl}
+	mock.recorder = &MockMediaProcessorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMediaProcessor) EXPECT() *MockMediaProcessorMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method.
+func (m *MockMediaProcessor) Enqueue(msg models.Message) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Enqueue", msg)
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockMediaProcessorMockRecorder) Enqueue(msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockMediaProcessor)(nil).Enqueue), msg)
+}
+
+// MockIAttachmentService is a mock of IAttachmentService interface.
+type MockIAttachmentService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAttachmentServiceMockRecorder
+}
+
+// MockIAttachmentServiceMockRecorder is the mock recorder for MockIAttachmentService.
+type MockIAttachmentServiceMockRecorder struct {
+	mock *MockIAttachmentService
+}
+
+// NewMockIAttachmentService creates a new mock instance.
+func NewMockIAttachmentService(ctrl *gomock.Controller) *MockIAttachmentService {
+	mock := &MockIAttachmentService{ctrl: ctrl}
+	mock.recorder = &MockIAttachmentServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAttachmentService) EXPECT() *MockIAttachmentServiceMockRecorder {
+	return m.recorder
+}
+
+// GetURL mocks base method.
+func (m *MockIAttachmentService) GetURL(userId, attachmentId int, variant string) (*models.AttachmentURL, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURL", userId, attachmentId, variant)
+	ret0, _ := ret[0].(*models.AttachmentURL)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetURL indicates an expected call of GetURL.
+func (mr *MockIAttachmentServiceMockRecorder) GetURL(userId, attachmentId, variant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockIAttachmentService)(nil).GetURL), userId, attachmentId, variant)
+}
+
+// Upload mocks base method.
+func (m *MockIAttachmentService) Upload(userId, chatId int, fileName string, r io.Reader) (*models.Attachment, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", userId, chatId, fileName, r)
+	ret0, _ := ret[0].(*models.Attachment)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockIAttachmentServiceMockRecorder) Upload(userId, chatId, fileName, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockIAttachmentService)(nil).Upload), userId, chatId, fileName, r)
+}
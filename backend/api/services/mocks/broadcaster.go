@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/broadcaster.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBroadcaster is a mock of Broadcaster interface.
+type MockBroadcaster struct {
+	ctrl     *gomock.Controller
+	recorder *MockBroadcasterMockRecorder
+}
+
+// MockBroadcasterMockRecorder is the mock recorder for MockBroadcaster.
+type MockBroadcasterMockRecorder struct {
+	mock *MockBroadcaster
+}
+
+// NewMockBroadcaster creates a new mock instance.
+func NewMockBroadcaster(ctrl *gomock.Controller) *MockBroadcaster {
+	mock := &MockBroadcaster{ctrl: ctrl}
+	mock.recorder = &MockBroadcasterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBroadcaster) EXPECT() *MockBroadcasterMockRecorder {
+	return m.recorder
+}
+
+// Broadcast mocks base method.
+func (m *MockBroadcaster) Broadcast(chatId int, event string, data any) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Broadcast", chatId, event, data)
+}
+
+// Broadcast indicates an expected call of Broadcast.
+func (mr *MockBroadcasterMockRecorder) Broadcast(chatId, event, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Broadcast", reflect.TypeOf((*MockBroadcaster)(nil).Broadcast), chatId, event, data)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/chat_settings.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIChatSettingsService is a mock of IChatSettingsService interface.
+type MockIChatSettingsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIChatSettingsServiceMockRecorder
+}
+
+// MockIChatSettingsServiceMockRecorder is the mock recorder for MockIChatSettingsService.
+type MockIChatSettingsServiceMockRecorder struct {
+	mock *MockIChatSettingsService
+}
+
+// NewMockIChatSettingsService creates a new mock instance.
+func NewMockIChatSettingsService(ctrl *gomock.Controller) *MockIChatSettingsService {
+	mock := &MockIChatSettingsService{ctrl: ctrl}
+	mock.recorder = &MockIChatSettingsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIChatSettingsService) EXPECT() *MockIChatSettingsServiceMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockIChatSettingsService) Get(userId, chatId int) (*models.ChatSettings, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", userId, chatId)
+	ret0, _ := ret[0].(*models.ChatSettings)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIChatSettingsServiceMockRecorder) Get(userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIChatSettingsService)(nil).Get), userId, chatId)
+}
+
+// Update mocks base method.
+func (m *MockIChatSettingsService) Update(userId int, settings models.ChatSettings) (*models.ChatSettings, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", userId, settings)
+	ret0, _ := ret[0].(*models.ChatSettings)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockIChatSettingsServiceMockRecorder) Update(userId, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIChatSettingsService)(nil).Update), userId, settings)
+}
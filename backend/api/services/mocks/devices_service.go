@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/devices.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIDeviceService is a mock of IDeviceService interface.
+type MockIDeviceService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDeviceServiceMockRecorder
+}
+
+// MockIDeviceServiceMockRecorder is the mock recorder for MockIDeviceService.
+type MockIDeviceServiceMockRecorder struct {
+	mock *MockIDeviceService
+}
+
+// NewMockIDeviceService creates a new mock instance.
+func NewMockIDeviceService(ctrl *gomock.Controller) *MockIDeviceService {
+	mock := &MockIDeviceService{ctrl: ctrl}
+	mock.recorder = &MockIDeviceServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDeviceService) EXPECT() *MockIDeviceServiceMockRecorder {
+	return m.recorder
+}
+
+// GetUserDevices mocks base method.
+func (m *MockIDeviceService) GetUserDevices(userId int) ([]models.Device, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDevices", userId)
+	ret0, _ := ret[0].([]models.Device)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetUserDevices indicates an expected call of GetUserDevices.
+func (mr *MockIDeviceServiceMockRecorder) GetUserDevices(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDevices", reflect.TypeOf((*MockIDeviceService)(nil).GetUserDevices), userId)
+}
+
+// Register mocks base method.
+func (m *MockIDeviceService) Register(userId int, device models.DeviceFromRequest) (*models.Device, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", userId, device)
+	ret0, _ := ret[0].(*models.Device)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockIDeviceServiceMockRecorder) Register(userId, device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockIDeviceService)(nil).Register), userId, device)
+}
+
+// Unregister mocks base method.
+func (m *MockIDeviceService) Unregister(userId, deviceId int) (*models.Device, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unregister", userId, deviceId)
+	ret0, _ := ret[0].(*models.Device)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Unregister indicates an expected call of Unregister.
+func (mr *MockIDeviceServiceMockRecorder) Unregister(userId, deviceId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unregister", reflect.TypeOf((*MockIDeviceService)(nil).Unregister), userId, deviceId)
+}
@@ -0,0 +1,149 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/exports.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	io "io"
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockChatExporter is a mock of ChatExporter interface.
+type MockChatExporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockChatExporterMockRecorder
+}
+
+// MockChatExporterMockRecorder is the mock recorder for MockChatExporter.
+type MockChatExporterMockRecorder struct {
+	mock *MockChatExporter
+}
+
+// NewMockChatExporter creates a new mock instance.
+func NewMockChatExporter(ctrl *gomock.Controller) *MockChatExporter {
+	mock := &MockChatExporter{ctrl: ctrl}
+	mock.recorder = &MockChatExporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChatExporter) EXPECT() *MockChatExporterMockRecorder {
+	return m.recorder
+}
+
+// Write mocks base method.
+func (m *MockChatExporter) Write(w io.Writer, chatId int, format string, progress func(int)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", w, chatId, format, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockChatExporterMockRecorder) Write(w, chatId, format, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockChatExporter)(nil).Write), w, chatId, format, progress)
+}
+
+// MockIExportService is a mock of IExportService interface.
+type MockIExportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIExportServiceMockRecorder
+}
+
+// MockIExportServiceMockRecorder is the mock recorder for MockIExportService.
+type MockIExportServiceMockRecorder struct {
+	mock *MockIExportService
+}
+
+// NewMockIExportService creates a new mock instance.
+func NewMockIExportService(ctrl *gomock.Controller) *MockIExportService {
+	mock := &MockIExportService{ctrl: ctrl}
+	mock.recorder = &MockIExportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIExportService) EXPECT() *MockIExportServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIExportService) Create(userId, chatId int, format string) (*models.Export, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, chatId, format)
+	ret0, _ := ret[0].(*models.Export)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIExportServiceMockRecorder) Create(userId, chatId, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIExportService)(nil).Create), userId, chatId, format)
+}
+
+// GetAll mocks base method.
+func (m *MockIExportService) GetAll(userId, chatId int) ([]models.Export, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId, chatId)
+	ret0, _ := ret[0].([]models.Export)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockIExportServiceMockRecorder) GetAll(userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockIExportService)(nil).GetAll), userId, chatId)
+}
+
+// GetOne mocks base method.
+func (m *MockIExportService) GetOne(userId, chatId, exportId int) (*models.Export, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", userId, chatId, exportId)
+	ret0, _ := ret[0].(*models.Export)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIExportServiceMockRecorder) GetOne(userId, chatId, exportId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIExportService)(nil).GetOne), userId, chatId, exportId)
+}
+
+// GetURL mocks base method.
+func (m *MockIExportService) GetURL(userId, chatId, exportId int) (*models.AttachmentURL, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURL", userId, chatId, exportId)
+	ret0, _ := ret[0].(*models.AttachmentURL)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetURL indicates an expected call of GetURL.
+func (mr *MockIExportServiceMockRecorder) GetURL(userId, chatId, exportId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockIExportService)(nil).GetURL), userId, chatId, exportId)
+}
+
+// Stream mocks base method.
+func (m *MockIExportService) Stream(userId, chatId int, format string) (func(io.Writer) error, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stream", userId, chatId, format)
+	ret0, _ := ret[0].(func(io.Writer) error)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Stream indicates an expected call of Stream.
+func (mr *MockIExportServiceMockRecorder) Stream(userId, chatId, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stream", reflect.TypeOf((*MockIExportService)(nil).Stream), userId, chatId, format)
+}
@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/imports.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	io "io"
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIImportService is a mock of IImportService interface.
+type MockIImportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIImportServiceMockRecorder
+}
+
+// MockIImportServiceMockRecorder is the mock recorder for MockIImportService.
+type MockIImportServiceMockRecorder struct {
+	mock *MockIImportService
+}
+
+// NewMockIImportService creates a new mock instance.
+func NewMockIImportService(ctrl *gomock.Controller) *MockIImportService {
+	mock := &MockIImportService{ctrl: ctrl}
+	mock.recorder = &MockIImportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIImportService) EXPECT() *MockIImportServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIImportService) Create(userId int, source string, options models.ImportOptions, r io.Reader) (*models.Import, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, source, options, r)
+	ret0, _ := ret[0].(*models.Import)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIImportServiceMockRecorder) Create(userId, source, options, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIImportService)(nil).Create), userId, source, options, r)
+}
+
+// GetAll mocks base method.
+func (m *MockIImportService) GetAll(userId int) ([]models.Import, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId)
+	ret0, _ := ret[0].([]models.Import)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockIImportServiceMockRecorder) GetAll(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockIImportService)(nil).GetAll), userId)
+}
+
+// GetOne mocks base method.
+func (m *MockIImportService) GetOne(userId, importId int) (*models.Import, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", userId, importId)
+	ret0, _ := ret[0].(*models.Import)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIImportServiceMockRecorder) GetOne(userId, importId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIImportService)(nil).GetOne), userId, importId)
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	sql "database/sql"
 	reflect "reflect"
 
 	models "github.com/BogPin/real-time-chat/backend/api/models"
@@ -35,6 +36,18 @@ func (m *MockIMessageService) EXPECT() *MockIMessageServiceMockRecorder {
 	return m.recorder
 }
 
+// Announce mocks base method.
+func (m *MockIMessageService) Announce(userId, chatId int, event string, data any) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Announce", userId, chatId, event, data)
+}
+
+// Announce indicates an expected call of Announce.
+func (mr *MockIMessageServiceMockRecorder) Announce(userId, chatId, event, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Announce", reflect.TypeOf((*MockIMessageService)(nil).Announce), userId, chatId, event, data)
+}
+
 // Create mocks base method.
 func (m *MockIMessageService) Create(userId int, MessageTDO models.MessageFromRequest) (*models.Message, utils.HttpError) {
 	m.ctrl.T.Helper()
@@ -50,34 +63,79 @@ func (mr *MockIMessageServiceMockRecorder) Create(userId, MessageTDO interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIMessageService)(nil).Create), userId, MessageTDO)
 }
 
+// CreateWith mocks base method.
+func (m *MockIMessageService) CreateWith(userId int, fromRequest models.MessageFromRequest, inTx func(*sql.Tx) error) (*models.Message, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWith", userId, fromRequest, inTx)
+	ret0, _ := ret[0].(*models.Message)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// CreateWith indicates an expected call of CreateWith.
+func (mr *MockIMessageServiceMockRecorder) CreateWith(userId, fromRequest, inTx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWith", reflect.TypeOf((*MockIMessageService)(nil).CreateWith), userId, fromRequest, inTx)
+}
+
 // Delete mocks base method.
-func (m *MockIMessageService) Delete(userId int, message models.Message) (*models.Message, utils.HttpError) {
+func (m *MockIMessageService) Delete(userId, messageId int, forEveryone bool) (*models.Message, utils.HttpError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userId, message)
+	ret := m.ctrl.Call(m, "Delete", userId, messageId, forEveryone)
 	ret0, _ := ret[0].(*models.Message)
 	ret1, _ := ret[1].(utils.HttpError)
 	return ret0, ret1
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockIMessageServiceMockRecorder) Delete(userId, message interface{}) *gomock.Call {
+func (mr *MockIMessageServiceMockRecorder) Delete(userId, messageId, forEveryone interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIMessageService)(nil).Delete), userId, message)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIMessageService)(nil).Delete), userId, messageId, forEveryone)
 }
 
-// GetChatMessages mocks base method.
-func (m *MockIMessageService) GetChatMessages(userId, chatId, page int) ([]models.Message, utils.HttpError) {
+// Forward mocks base method.
+func (m *MockIMessageService) Forward(userId int, fromRequest models.ForwardFromRequest) ([]models.Message, utils.HttpError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChatMessages", userId, chatId, page)
+	ret := m.ctrl.Call(m, "Forward", userId, fromRequest)
 	ret0, _ := ret[0].([]models.Message)
 	ret1, _ := ret[1].(utils.HttpError)
 	return ret0, ret1
 }
 
+// Forward indicates an expected call of Forward.
+func (mr *MockIMessageServiceMockRecorder) Forward(userId, fromRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forward", reflect.TypeOf((*MockIMessageService)(nil).Forward), userId, fromRequest)
+}
+
+// GetChatMessages mocks base method.
+func (m *MockIMessageService) GetChatMessages(userId int, query models.HistoryQuery) (*models.MessagePage, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatMessages", userId, query)
+	ret0, _ := ret[0].(*models.MessagePage)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
 // GetChatMessages indicates an expected call of GetChatMessages.
-func (mr *MockIMessageServiceMockRecorder) GetChatMessages(userId, chatId, page interface{}) *gomock.Call {
+func (mr *MockIMessageServiceMockRecorder) GetChatMessages(userId, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMessages", reflect.TypeOf((*MockIMessageService)(nil).GetChatMessages), userId, chatId, page)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMessages", reflect.TypeOf((*MockIMessageService)(nil).GetChatMessages), userId, query)
+}
+
+// GetMany mocks base method.
+func (m *MockIMessageService) GetMany(userId, chatId int, messageIds []int) ([]models.Message, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMany", userId, chatId, messageIds)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetMany indicates an expected call of GetMany.
+func (mr *MockIMessageServiceMockRecorder) GetMany(userId, chatId, messageIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*MockIMessageService)(nil).GetMany), userId, chatId, messageIds)
 }
 
 // GetOne mocks base method.
@@ -95,6 +153,81 @@ func (mr *MockIMessageServiceMockRecorder) GetOne(userId, messageId interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIMessageService)(nil).GetOne), userId, messageId)
 }
 
+// GetRevisions mocks base method.
+func (m *MockIMessageService) GetRevisions(userId, messageId int) ([]models.Revision, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", userId, messageId)
+	ret0, _ := ret[0].([]models.Revision)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockIMessageServiceMockRecorder) GetRevisions(userId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockIMessageService)(nil).GetRevisions), userId, messageId)
+}
+
+// GetThread mocks base method.
+func (m *MockIMessageService) GetThread(userId, messageId, page int) (*models.ThreadView, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThread", userId, messageId, page)
+	ret0, _ := ret[0].(*models.ThreadView)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetThread indicates an expected call of GetThread.
+func (mr *MockIMessageServiceMockRecorder) GetThread(userId, messageId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThread", reflect.TypeOf((*MockIMessageService)(nil).GetThread), userId, messageId, page)
+}
+
+// GetUserMentions mocks base method.
+func (m *MockIMessageService) GetUserMentions(userId, page int) ([]models.Message, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMentions", userId, page)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetUserMentions indicates an expected call of GetUserMentions.
+func (mr *MockIMessageServiceMockRecorder) GetUserMentions(userId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMentions", reflect.TypeOf((*MockIMessageService)(nil).GetUserMentions), userId, page)
+}
+
+// MarkListened mocks base method.
+func (m *MockIMessageService) MarkListened(userId, messageId int) (*models.ListenEvent, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkListened", userId, messageId)
+	ret0, _ := ret[0].(*models.ListenEvent)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// MarkListened indicates an expected call of MarkListened.
+func (mr *MockIMessageServiceMockRecorder) MarkListened(userId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkListened", reflect.TypeOf((*MockIMessageService)(nil).MarkListened), userId, messageId)
+}
+
+// Search mocks base method.
+func (m *MockIMessageService) Search(userId int, query models.SearchQuery) ([]models.SearchResult, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", userId, query)
+	ret0, _ := ret[0].([]models.SearchResult)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockIMessageServiceMockRecorder) Search(userId, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockIMessageService)(nil).Search), userId, query)
+}
+
 // Update mocks base method.
 func (m *MockIMessageService) Update(userId int, message models.Message) (*models.Message, utils.HttpError) {
 	m.ctrl.T.Helper()
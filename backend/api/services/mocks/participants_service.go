@@ -80,6 +80,21 @@ func (mr *MockIParticipantServiceMockRecorder) GetChatUsers(userId, chatId inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatUsers", reflect.TypeOf((*MockIParticipantService)(nil).GetChatUsers), userId, chatId)
 }
 
+// SetMuted mocks base method.
+func (m *MockIParticipantService) SetMuted(userId, chatId int, muted bool) (*models.Participant, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMuted", userId, chatId, muted)
+	ret0, _ := ret[0].(*models.Participant)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// SetMuted indicates an expected call of SetMuted.
+func (mr *MockIParticipantServiceMockRecorder) SetMuted(userId, chatId, muted interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMuted", reflect.TypeOf((*MockIParticipantService)(nil).SetMuted), userId, chatId, muted)
+}
+
 // Update mocks base method.
 func (m *MockIParticipantService) Update(userId int, participant models.Participant) (*models.Participant, utils.HttpError) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/pins.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIPinService is a mock of IPinService interface.
+type MockIPinService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPinServiceMockRecorder
+}
+
+// MockIPinServiceMockRecorder is the mock recorder for MockIPinService.
+type MockIPinServiceMockRecorder struct {
+	mock *MockIPinService
+}
+
+// NewMockIPinService creates a new mock instance.
+func NewMockIPinService(ctrl *gomock.Controller) *MockIPinService {
+	mock := &MockIPinService{ctrl: ctrl}
+	mock.recorder = &MockIPinServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPinService) EXPECT() *MockIPinServiceMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockIPinService) List(userId, chatId int) ([]models.Pin, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", userId, chatId)
+	ret0, _ := ret[0].([]models.Pin)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIPinServiceMockRecorder) List(userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIPinService)(nil).List), userId, chatId)
+}
+
+// Pin mocks base method.
+func (m *MockIPinService) Pin(userId, chatId, messageId int) (*models.Pin, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pin", userId, chatId, messageId)
+	ret0, _ := ret[0].(*models.Pin)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Pin indicates an expected call of Pin.
+func (mr *MockIPinServiceMockRecorder) Pin(userId, chatId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pin", reflect.TypeOf((*MockIPinService)(nil).Pin), userId, chatId, messageId)
+}
+
+// Unpin mocks base method.
+func (m *MockIPinService) Unpin(userId, chatId, messageId int) (*models.Pin, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unpin", userId, chatId, messageId)
+	ret0, _ := ret[0].(*models.Pin)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Unpin indicates an expected call of Unpin.
+func (mr *MockIPinServiceMockRecorder) Unpin(userId, chatId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unpin", reflect.TypeOf((*MockIPinService)(nil).Unpin), userId, chatId, messageId)
+}
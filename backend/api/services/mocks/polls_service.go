@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/polls.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIPollService is a mock of IPollService interface.
+type MockIPollService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPollServiceMockRecorder
+}
+
+// MockIPollServiceMockRecorder is the mock recorder for MockIPollService.
+type MockIPollServiceMockRecorder struct {
+	mock *MockIPollService
+}
+
+// NewMockIPollService creates a new mock instance.
+func NewMockIPollService(ctrl *gomock.Controller) *MockIPollService {
+	mock := &MockIPollService{ctrl: ctrl}
+	mock.recorder = &MockIPollServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPollService) EXPECT() *MockIPollServiceMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockIPollService) Close(userId, messageId int) (*models.PollEvent, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close", userId, messageId)
+	ret0, _ := ret[0].(*models.PollEvent)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockIPollServiceMockRecorder) Close(userId, messageId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockIPollService)(nil).Close), userId, messageId)
+}
+
+// Retract mocks base method.
+func (m *MockIPollService) Retract(userId, messageId int, optionIds []int) (*models.PollEvent, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retract", userId, messageId, optionIds)
+	ret0, _ := ret[0].(*models.PollEvent)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Retract indicates an expected call of Retract.
+func (mr *MockIPollServiceMockRecorder) Retract(userId, messageId, optionIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retract", reflect.TypeOf((*MockIPollService)(nil).Retract), userId, messageId, optionIds)
+}
+
+// Vote mocks base method.
+func (m *MockIPollService) Vote(userId, messageId int, optionIds []int) (*models.PollEvent, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", userId, messageId, optionIds)
+	ret0, _ := ret[0].(*models.PollEvent)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockIPollServiceMockRecorder) Vote(userId, messageId, optionIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockIPollService)(nil).Vote), userId, messageId, optionIds)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/reactions.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIReactionService is a mock of IReactionService interface.
+type MockIReactionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIReactionServiceMockRecorder
+}
+
+// MockIReactionServiceMockRecorder is the mock recorder for MockIReactionService.
+type MockIReactionServiceMockRecorder struct {
+	mock *MockIReactionService
+}
+
+// NewMockIReactionService creates a new mock instance.
+func NewMockIReactionService(ctrl *gomock.Controller) *MockIReactionService {
+	mock := &MockIReactionService{ctrl: ctrl}
+	mock.recorder = &MockIReactionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIReactionService) EXPECT() *MockIReactionServiceMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockIReactionService) Add(userId, messageId int, emoji string) (*models.ReactionEvent, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", userId, messageId, emoji)
+	ret0, _ := ret[0].(*models.ReactionEvent)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockIReactionServiceMockRecorder) Add(userId, messageId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockIReactionService)(nil).Add), userId, messageId, emoji)
+}
+
+// Remove mocks base method.
+func (m *MockIReactionService) Remove(userId, messageId int, emoji string) (*models.ReactionEvent, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", userId, messageId, emoji)
+	ret0, _ := ret[0].(*models.ReactionEvent)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockIReactionServiceMockRecorder) Remove(userId, messageId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockIReactionService)(nil).Remove), userId, messageId, emoji)
+}
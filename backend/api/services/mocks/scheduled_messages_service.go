@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/scheduled_messages.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIScheduledMessageService is a mock of IScheduledMessageService interface.
+type MockIScheduledMessageService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIScheduledMessageServiceMockRecorder
+}
+
+// MockIScheduledMessageServiceMockRecorder is the mock recorder for MockIScheduledMessageService.
+type MockIScheduledMessageServiceMockRecorder struct {
+	mock *MockIScheduledMessageService
+}
+
+// NewMockIScheduledMessageService creates a new mock instance.
+func NewMockIScheduledMessageService(ctrl *gomock.Controller) *MockIScheduledMessageService {
+	mock := &MockIScheduledMessageService{ctrl: ctrl}
+	mock.recorder = &MockIScheduledMessageServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIScheduledMessageService) EXPECT() *MockIScheduledMessageServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIScheduledMessageService) Create(userId int, fromRequest models.ScheduledMessageFromRequest) (*models.ScheduledMessage, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, fromRequest)
+	ret0, _ := ret[0].(*models.ScheduledMessage)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIScheduledMessageServiceMockRecorder) Create(userId, fromRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIScheduledMessageService)(nil).Create), userId, fromRequest)
+}
+
+// Delete mocks base method.
+func (m *MockIScheduledMessageService) Delete(userId, id int) (*models.ScheduledMessage, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userId, id)
+	ret0, _ := ret[0].(*models.ScheduledMessage)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIScheduledMessageServiceMockRecorder) Delete(userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIScheduledMessageService)(nil).Delete), userId, id)
+}
+
+// GetAll mocks base method.
+func (m *MockIScheduledMessageService) GetAll(userId, chatId int) ([]models.ScheduledMessage, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId, chatId)
+	ret0, _ := ret[0].([]models.ScheduledMessage)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockIScheduledMessageServiceMockRecorder) GetAll(userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockIScheduledMessageService)(nil).GetAll), userId, chatId)
+}
+
+// Update mocks base method.
+func (m *MockIScheduledMessageService) Update(userId, id int, fromRequest models.ScheduledMessageFromRequest) (*models.ScheduledMessage, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", userId, id, fromRequest)
+	ret0, _ := ret[0].(*models.ScheduledMessage)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockIScheduledMessageServiceMockRecorder) Update(userId, id, fromRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIScheduledMessageService)(nil).Update), userId, id, fromRequest)
+}
@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/uploads.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	io "io"
+	reflect "reflect"
+
+	models "github.com/BogPin/real-time-chat/backend/api/models"
+	utils "github.com/BogPin/real-time-chat/backend/api/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIUploadService is a mock of IUploadService interface.
+type MockIUploadService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIUploadServiceMockRecorder
+}
+
+// MockIUploadServiceMockRecorder is the mock recorder for MockIUploadService.
+type MockIUploadServiceMockRecorder struct {
+	mock *MockIUploadService
+}
+
+// NewMockIUploadService creates a new mock instance.
+func NewMockIUploadService(ctrl *gomock.Controller) *MockIUploadService {
+	mock := &MockIUploadService{ctrl: ctrl}
+	mock.recorder = &MockIUploadServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIUploadService) EXPECT() *MockIUploadServiceMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockIUploadService) Append(userId int, uploadId string, offset int64, r io.Reader) (*models.Upload, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", userId, uploadId, offset, r)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockIUploadServiceMockRecorder) Append(userId, uploadId, offset, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockIUploadService)(nil).Append), userId, uploadId, offset, r)
+}
+
+// Complete mocks base method.
+func (m *MockIUploadService) Complete(userId int, uploadId string) (*models.Attachment, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", userId, uploadId)
+	ret0, _ := ret[0].(*models.Attachment)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIUploadServiceMockRecorder) Complete(userId, uploadId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIUploadService)(nil).Complete), userId, uploadId)
+}
+
+// Create mocks base method.
+func (m *MockIUploadService) Create(userId, chatId int, fileName string, length int64) (*models.Upload, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, chatId, fileName, length)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIUploadServiceMockRecorder) Create(userId, chatId, fileName, length interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIUploadService)(nil).Create), userId, chatId, fileName, length)
+}
+
+// GetOne mocks base method.
+func (m *MockIUploadService) GetOne(userId int, uploadId string) (*models.Upload, utils.HttpError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", userId, uploadId)
+	ret0, _ := ret[0].(*models.Upload)
+	ret1, _ := ret[1].(utils.HttpError)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockIUploadServiceMockRecorder) GetOne(userId, uploadId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockIUploadService)(nil).GetOne), userId, uploadId)
+}
+
+// Terminate mocks base method.
+func (m *MockIUploadService) Terminate(userId int, uploadId string) utils.HttpError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Terminate", userId, uploadId)
+	ret0, _ := ret[0].(utils.HttpError)
+	return ret0
+}
+
+// Terminate indicates an expected call of Terminate.
+func (mr *MockIUploadServiceMockRecorder) Terminate(userId, uploadId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Terminate", reflect.TypeOf((*MockIUploadService)(nil).Terminate), userId, uploadId)
+}
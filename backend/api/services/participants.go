@@ -15,16 +15,23 @@ type IParticipantService interface {
 	Create(userId int, participant models.Participant) (*models.Participant, utils.HttpError)
 	GetChatUsers(userId, chatId int) ([]models.ChatUser, utils.HttpError)
 	Update(userId int, participant models.Participant) (*models.Participant, utils.HttpError)
+	SetMuted(userId, chatId int, muted bool) (*models.Participant, utils.HttpError)
 	Delete(userId int, participant models.Participant) (*models.Participant, utils.HttpError)
 	UserInChat(userId, chatId int) (bool, error)
 }
 
 type ParticipantService struct {
 	ParticipantStorer models.IParticipantStorer
+	MessageStorer     models.IMessageStorer
+	Broadcaster       Broadcaster
 }
 
-func NewParticipantService(participantStorer models.IParticipantStorer) ParticipantService {
-	return ParticipantService{ParticipantStorer: participantStorer}
+func NewParticipantService(participantStorer models.IParticipantStorer, messageStorer models.IMessageStorer, broadcaster Broadcaster) ParticipantService {
+	return ParticipantService{
+		ParticipantStorer: participantStorer,
+		MessageStorer:     messageStorer,
+		Broadcaster:       broadcaster,
+	}
 }
 
 func (ps ParticipantService) Create(userId int, participant models.Participant) (*models.Participant, utils.HttpError) {
@@ -44,6 +51,8 @@ func (ps ParticipantService) Create(userId int, participant models.Participant)
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
 
+	data := map[string]any{"userId": participant.UserId, "role": participant.Role}
+	announce(ps.MessageStorer, ps.Broadcaster, userId, chatId, "participant_joined", data)
 	return &participant, nil
 }
 
@@ -84,14 +93,40 @@ func (ps ParticipantService) Update(userId int, participant models.Participant)
 		return nil, utils.NewHttpError(err, http.StatusForbidden)
 	}
 
+	oldParticipant, err := ps.ParticipantStorer.GetOne(participant.UserId, chatId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("user %d doesn't participate in chat %d", participant.UserId, chatId)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
 	newParticipant, err := ps.ParticipantStorer.Update(participant)
 	if err != nil {
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
 
+	if newParticipant.Role != oldParticipant.Role {
+		data := map[string]any{"userId": participant.UserId, "role": newParticipant.Role, "oldRole": oldParticipant.Role}
+		announce(ps.MessageStorer, ps.Broadcaster, userId, chatId, "participant_role_changed", data)
+	}
 	return newParticipant, nil
 }
 
+func (ps ParticipantService) SetMuted(userId, chatId int, muted bool) (*models.Participant, utils.HttpError) {
+	participant, err := ps.ParticipantStorer.UpdateMuted(userId, chatId, muted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("user %d doesn't participate in chat %d", userId, chatId)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	return participant, nil
+}
+
+// Delete removes a participant from a chat, admins can remove anyone and everyone can leave.
 func (ps ParticipantService) Delete(userId int, participant models.Participant) (*models.Participant, utils.HttpError) {
 	chatId := participant.ChatId
 	userInChat, err := ps.UserInChat(userId, chatId)
@@ -104,14 +139,17 @@ func (ps ParticipantService) Delete(userId int, participant models.Participant)
 		return nil, utils.NewHttpError(err, http.StatusForbidden)
 	}
 
-	user, err := ps.ParticipantStorer.GetOne(userId, chatId)
-	if err != nil {
-		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
-	}
+	leaving := participant.UserId == userId
+	if !leaving {
+		user, err := ps.ParticipantStorer.GetOne(userId, chatId)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
 
-	if user.Role != "admin" {
-		err := fmt.Errorf("user %d doesn't have permission to delete user %d in chat %d", userId, participant.UserId, chatId)
-		return nil, utils.NewHttpError(err, http.StatusForbidden)
+		if user.Role != "admin" {
+			err := fmt.Errorf("user %d doesn't have permission to delete user %d in chat %d", userId, participant.UserId, chatId)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
 	}
 
 	dltParticipant, err := ps.ParticipantStorer.Delete(participant)
@@ -119,6 +157,11 @@ func (ps ParticipantService) Delete(userId int, participant models.Participant)
 		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
 	}
 
+	event := "participant_removed"
+	if leaving {
+		event = "participant_left"
+	}
+	announce(ps.MessageStorer, ps.Broadcaster, userId, chatId, event, map[string]int{"userId": participant.UserId})
 	return dltParticipant, nil
 }
 
@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteParticipantLeavingSuccess(t *testing.T) {
+	//Arrange
+	userId, chatId := 2, 3
+	participant := models.Participant{UserId: userId, ChatId: chatId, Role: "member"}
+	expectedDTO := models.MessageDTO{
+		SenderId: userId,
+		ChatId:   chatId,
+		Type:     "system",
+		Content:  `{"event":"participant_left","data":{"userId":2}}`,
+	}
+	systemMsg := models.Message{Id: 7, SenderId: userId, ChatId: chatId, Type: "system", Content: expectedDTO.Content}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.EXPECT().GetOne(userId, chatId).Return(&participant, nil)
+	mockParticipantStorer.EXPECT().Delete(participant).Return(&participant, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().Create(expectedDTO).Return(&systemMsg, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(chatId, "message", &systemMsg)
+
+	participantService := services.NewParticipantService(mockParticipantStorer, mockMessageStorer, mockBroadcaster)
+
+	//Act
+	actualParticipant, httpErr := participantService.Delete(userId, participant)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, participant, *actualParticipant)
+}
+
+func TestDeleteParticipantUserNotAdminError(t *testing.T) {
+	//Arrange
+	userId, chatId := 2, 3
+	participant := models.Participant{UserId: 4, ChatId: chatId}
+	expectedError := fmt.Errorf("user %d doesn't have permission to delete user %d in chat %d", userId, participant.UserId, chatId)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusForbidden)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.
+		EXPECT().
+		GetOne(userId, chatId).
+		Return(&models.Participant{UserId: userId, ChatId: chatId, Role: "member"}, nil).
+		Times(2)
+
+	participantService := services.NewParticipantService(mockParticipantStorer, nil, nil)
+
+	//Act
+	actualParticipant, httpErr := participantService.Delete(userId, participant)
+
+	//Assert
+	assert.Nil(t, actualParticipant)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestUpdateParticipantAnnouncesRoleChange(t *testing.T) {
+	//Arrange
+	userId, chatId := 1, 3
+	participant := models.Participant{UserId: 4, ChatId: chatId, Role: "admin"}
+	expectedDTO := models.MessageDTO{
+		SenderId: userId,
+		ChatId:   chatId,
+		Type:     "system",
+		Content:  `{"event":"participant_role_changed","data":{"oldRole":"member","role":"admin","userId":4}}`,
+	}
+	systemMsg := models.Message{Id: 7, SenderId: userId, ChatId: chatId, Type: "system", Content: expectedDTO.Content}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantStorer := models_mocks.NewMockIParticipantStorer(ctrl)
+	mockParticipantStorer.
+		EXPECT().
+		GetOne(userId, chatId).
+		Return(&models.Participant{UserId: userId, ChatId: chatId, Role: "admin"}, nil).
+		Times(2)
+	mockParticipantStorer.
+		EXPECT().
+		GetOne(participant.UserId, chatId).
+		Return(&models.Participant{UserId: participant.UserId, ChatId: chatId, Role: "member"}, nil)
+	mockParticipantStorer.EXPECT().Update(participant).Return(&participant, nil)
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().Create(expectedDTO).Return(&systemMsg, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(chatId, "message", &systemMsg)
+
+	participantService := services.NewParticipantService(mockParticipantStorer, mockMessageStorer, mockBroadcaster)
+
+	//Act
+	actualParticipant, httpErr := participantService.Update(userId, participant)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, participant, *actualParticipant)
+}
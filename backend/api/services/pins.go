@@ -0,0 +1,186 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+)
+
+const (
+	DEFAULT_MAX_PINS  = 50
+	MAX_PINS          = 200
+	PIN_ADDED_EVENT   = "pin:added"
+	PIN_REMOVED_EVENT = "pin:removed"
+)
+
+type IPinService interface {
+	Pin(userId, chatId, messageId int) (*models.Pin, utils.HttpError)
+	Unpin(userId, chatId, messageId int) (*models.Pin, utils.HttpError)
+	List(userId, chatId int) ([]models.Pin, utils.HttpError)
+}
+
+type PinService struct {
+	PinStorer          models.IPinStorer
+	ChatSettingsStorer models.IChatSettingsStorer
+	MessageService     IMessageService
+	ParticipantService IParticipantService
+	Broadcaster        Broadcaster
+}
+
+func NewPinService(
+	pinStorer models.IPinStorer,
+	chatSettingsStorer models.IChatSettingsStorer,
+	messageService IMessageService,
+	participantService IParticipantService,
+	broadcaster Broadcaster,
+) PinService {
+	return PinService{
+		PinStorer:          pinStorer,
+		ChatSettingsStorer: chatSettingsStorer,
+		MessageService:     messageService,
+		ParticipantService: participantService,
+		Broadcaster:        broadcaster,
+	}
+}
+
+// Pin pins a message to the top of its chat. Admins can pin messages,
+// other participants only when the settings of the chat let them.
+func (ps PinService) Pin(userId, chatId, messageId int) (*models.Pin, utils.HttpError) {
+	msg, settings, httpErr := ps.authorize(userId, chatId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if msg.DeletedAt != "" || msg.Type == "system" {
+		err := fmt.Errorf("message %d can't be pinned", messageId)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	pins, err := ps.PinStorer.GetForChat(chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	for _, pin := range pins {
+		if pin.MessageId == messageId {
+			err := fmt.Errorf("message %d is already pinned", messageId)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+	}
+
+	maxPins := settings.MaxPins
+	if maxPins == 0 {
+		maxPins = DEFAULT_MAX_PINS
+	}
+	if len(pins) >= maxPins {
+		err := fmt.Errorf("chat %d can't have more than %d pinned messages", chatId, maxPins)
+		return nil, utils.NewHttpError(err, http.StatusUnprocessableEntity)
+	}
+
+	pin, err := ps.PinStorer.Create(chatId, messageId, userId, maxPins)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("message %d was pinned or the pin limit of chat %d was reached meanwhile", messageId, chatId)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	ps.notify(PIN_ADDED_EVENT, "message_pinned", userId, *pin)
+	return pin, nil
+}
+
+func (ps PinService) Unpin(userId, chatId, messageId int) (*models.Pin, utils.HttpError) {
+	_, _, httpErr := ps.authorize(userId, chatId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	pin, err := ps.PinStorer.Delete(chatId, messageId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("message %d isn't pinned", messageId)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	ps.notify(PIN_REMOVED_EVENT, "message_unpinned", userId, *pin)
+	return pin, nil
+}
+
+// List returns the pins of a chat with their messages, the latest pinned first.
+func (ps PinService) List(userId, chatId int) ([]models.Pin, utils.HttpError) {
+	pins, err := ps.PinStorer.GetForChat(chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	ids := make([]int, 0, len(pins))
+	for _, pin := range pins {
+		ids = append(ids, pin.MessageId)
+	}
+	messages, httpErr := ps.MessageService.GetMany(userId, chatId, ids)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	byId := make(map[int]models.Message, len(messages))
+	for _, msg := range messages {
+		byId[msg.Id] = msg
+	}
+	for i := range pins {
+		if msg, ok := byId[pins[i].MessageId]; ok {
+			pins[i].Message = &msg
+		}
+	}
+	return pins, nil
+}
+
+// authorize checks that userId may pin messages in the chat messageId belongs to.
+func (ps PinService) authorize(userId, chatId, messageId int) (*models.Message, *models.ChatSettings, utils.HttpError) {
+	msg, httpErr := ps.MessageService.GetOne(userId, messageId)
+	if httpErr != nil {
+		return nil, nil, httpErr
+	}
+
+	if msg.ChatId != chatId {
+		err := fmt.Errorf("no message with id %d in chat %d", messageId, chatId)
+		return nil, nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	settings, err := ps.ChatSettingsStorer.Get(chatId)
+	if err != nil {
+		return nil, nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if !settings.MembersCanPin {
+		chatUsers, httpErr := ps.ParticipantService.GetChatUsers(userId, chatId)
+		if httpErr != nil {
+			return nil, nil, httpErr
+		}
+		isAdmin := false
+		for _, chatUser := range chatUsers {
+			if chatUser.UserId == userId {
+				isAdmin = chatUser.Role == "admin"
+			}
+		}
+		if !isAdmin {
+			err := fmt.Errorf("user %d doesn't have permission to pin messages in chat %d", userId, chatId)
+			return nil, nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
+	}
+
+	return msg, settings, nil
+}
+
+// notify tells the chat about the pin and leaves a system message in its history.
+func (ps PinService) notify(event, systemEvent string, userId int, pin models.Pin) {
+	if ps.Broadcaster != nil {
+		ps.Broadcaster.Broadcast(pin.ChatId, event, pin)
+	}
+	ps.MessageService.Announce(userId, pin.ChatId, systemEvent, map[string]int{"messageId": pin.MessageId})
+}
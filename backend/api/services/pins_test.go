@@ -0,0 +1,118 @@
+package services_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinMessageSuccess(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "on-call: @bob"}
+	expectedPin := models.Pin{ChatId: msg.ChatId, MessageId: msg.Id, PinnedBy: userId, PinnedAt: "2023-01-01 10:00:00"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+	mockMessageService.
+		EXPECT().
+		Announce(userId, msg.ChatId, "message_pinned", map[string]int{"messageId": msg.Id})
+
+	mockChatSettingsStorer := models_mocks.NewMockIChatSettingsStorer(ctrl)
+	mockChatSettingsStorer.EXPECT().Get(msg.ChatId).Return(&models.ChatSettings{ChatId: msg.ChatId}, nil)
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.
+		EXPECT().
+		GetChatUsers(userId, msg.ChatId).
+		Return([]models.ChatUser{{Participant: models.Participant{UserId: userId, ChatId: msg.ChatId, Role: "admin"}}}, nil)
+
+	mockPinStorer := models_mocks.NewMockIPinStorer(ctrl)
+	mockPinStorer.EXPECT().GetForChat(msg.ChatId).Return([]models.Pin{{ChatId: msg.ChatId, MessageId: 4}}, nil)
+	mockPinStorer.EXPECT().Create(msg.ChatId, msg.Id, userId, services.DEFAULT_MAX_PINS).Return(&expectedPin, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(msg.ChatId, services.PIN_ADDED_EVENT, expectedPin)
+
+	pinService := services.NewPinService(mockPinStorer, mockChatSettingsStorer, mockMessageService, mockParticipantService, mockBroadcaster)
+
+	//Act
+	actualPin, httpErr := pinService.Pin(userId, msg.ChatId, msg.Id)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, expectedPin, *actualPin)
+}
+
+func TestPinMessageLimitError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "hello"}
+	settings := models.ChatSettings{ChatId: msg.ChatId, MaxPins: 1, MembersCanPin: true}
+	expectedError := fmt.Errorf("chat %d can't have more than %d pinned messages", msg.ChatId, settings.MaxPins)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusUnprocessableEntity)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	mockChatSettingsStorer := models_mocks.NewMockIChatSettingsStorer(ctrl)
+	mockChatSettingsStorer.EXPECT().Get(msg.ChatId).Return(&settings, nil)
+
+	mockPinStorer := models_mocks.NewMockIPinStorer(ctrl)
+	mockPinStorer.EXPECT().GetForChat(msg.ChatId).Return([]models.Pin{{ChatId: msg.ChatId, MessageId: 4}}, nil)
+
+	pinService := services.NewPinService(mockPinStorer, mockChatSettingsStorer, mockMessageService, nil, nil)
+
+	//Act
+	actualPin, httpErr := pinService.Pin(userId, msg.ChatId, msg.Id)
+
+	//Assert
+	assert.Nil(t, actualPin)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestPinMessagePermissionError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "hello"}
+	expectedError := fmt.Errorf("user %d doesn't have permission to pin messages in chat %d", userId, msg.ChatId)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusForbidden)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	mockChatSettingsStorer := models_mocks.NewMockIChatSettingsStorer(ctrl)
+	mockChatSettingsStorer.EXPECT().Get(msg.ChatId).Return(&models.ChatSettings{ChatId: msg.ChatId}, nil)
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.
+		EXPECT().
+		GetChatUsers(userId, msg.ChatId).
+		Return([]models.ChatUser{{Participant: models.Participant{UserId: userId, ChatId: msg.ChatId, Role: "member"}}}, nil)
+
+	pinService := services.NewPinService(nil, mockChatSettingsStorer, mockMessageService, mockParticipantService, nil)
+
+	//Act
+	actualPin, httpErr := pinService.Unpin(userId, msg.ChatId, msg.Id)
+
+	//Assert
+	assert.Nil(t, actualPin)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
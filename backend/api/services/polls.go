@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	MIN_POLL_OPTIONS         = 2
+	MAX_POLL_OPTIONS         = 10
+	MAX_POLL_QUESTION_LENGTH = 300
+	MAX_POLL_OPTION_LENGTH   = 100
+	MAX_POLL_DURATION        = 90 * 24 * time.Hour
+	POLL_UPDATED_EVENT       = "poll:updated"
+	POLL_CLOSED_EVENT        = "poll:closed"
+)
+
+type IPollService interface {
+	Vote(userId, messageId int, optionIds []int) (*models.PollEvent, utils.HttpError)
+	Retract(userId, messageId int, optionIds []int) (*models.PollEvent, utils.HttpError)
+	Close(userId, messageId int) (*models.PollEvent, utils.HttpError)
+}
+
+type PollService struct {
+	PollStorer         models.IPollStorer
+	MessageService     IMessageService
+	ParticipantService IParticipantService
+	Broadcaster        Broadcaster
+}
+
+func NewPollService(
+	pollStorer models.IPollStorer,
+	messageService IMessageService,
+	participantService IParticipantService,
+	broadcaster Broadcaster,
+) PollService {
+	return PollService{
+		PollStorer:         pollStorer,
+		MessageService:     messageService,
+		ParticipantService: participantService,
+		Broadcaster:        broadcaster,
+	}
+}
+
+// Vote casts the votes of userId for optionIds. A vote in a single choice
+// poll replaces the previous one, votes in multiple choice polls add up.
+func (ps PollService) Vote(userId, messageId int, optionIds []int) (*models.PollEvent, utils.HttpError) {
+	msg, httpErr := ps.getOpen(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if len(optionIds) == 0 {
+		err := errors.New("no options to vote for")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if !msg.Poll.Multiple && len(optionIds) > 1 {
+		err := fmt.Errorf("poll %d takes one vote at a time", messageId)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if httpErr := checkOptions(*msg.Poll, optionIds); httpErr != nil {
+		return nil, httpErr
+	}
+
+	voted, err := ps.PollStorer.Vote(messageId, userId, optionIds, !msg.Poll.Multiple)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if !voted {
+		err := fmt.Errorf("poll %d is closed", messageId)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	return ps.notify(POLL_UPDATED_EVENT, userId, *msg)
+}
+
+// Retract takes back the votes of userId for optionIds, all of their votes when there are none.
+func (ps PollService) Retract(userId, messageId int, optionIds []int) (*models.PollEvent, utils.HttpError) {
+	msg, httpErr := ps.getOpen(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if httpErr := checkOptions(*msg.Poll, optionIds); httpErr != nil {
+		return nil, httpErr
+	}
+
+	retracted, err := ps.PollStorer.Retract(messageId, userId, optionIds)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if retracted == 0 {
+		err := fmt.Errorf("user %d has no such votes in poll %d", userId, messageId)
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	return ps.notify(POLL_UPDATED_EVENT, userId, *msg)
+}
+
+// Close stops a poll from taking votes before its time, which
+// only the one who sent it and admins of the chat can do.
+func (ps PollService) Close(userId, messageId int) (*models.PollEvent, utils.HttpError) {
+	msg, httpErr := ps.getOpen(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if msg.SenderId != userId {
+		chatUsers, httpErr := ps.ParticipantService.GetChatUsers(userId, msg.ChatId)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		isAdmin := false
+		for _, chatUser := range chatUsers {
+			if chatUser.UserId == userId {
+				isAdmin = chatUser.Role == "admin"
+			}
+		}
+		if !isAdmin {
+			err := fmt.Errorf("user %d can't close poll %d", userId, messageId)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
+	}
+
+	closed, err := ps.PollStorer.Close(messageId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	if !closed {
+		err := fmt.Errorf("poll %d is closed", messageId)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	return ps.notify(POLL_CLOSED_EVENT, userId, *msg)
+}
+
+// getOpen returns the poll message with messageId as seen by userId if it takes votes.
+func (ps PollService) getOpen(userId, messageId int) (*models.Message, utils.HttpError) {
+	msg, httpErr := ps.MessageService.GetOne(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if msg.Type != "poll" || msg.Poll == nil || msg.DeletedAt != "" {
+		err := fmt.Errorf("message %d isn't a poll", messageId)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if msg.Poll.Closed {
+		err := fmt.Errorf("poll %d is closed", messageId)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	return msg, nil
+}
+
+// notify tells the chat the new tally of the poll in msg, which is the same for
+// every participant, and returns it as seen by userId.
+func (ps PollService) notify(event string, userId int, msg models.Message) (*models.PollEvent, utils.HttpError) {
+	polls, err := ps.PollStorer.GetForMessages([]int{msg.Id}, userId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	poll := polls[msg.Id]
+
+	if ps.Broadcaster != nil {
+		tally := poll
+		tally.MyVotes = make([]int, 0)
+		ps.Broadcaster.Broadcast(msg.ChatId, event, models.PollEvent{MessageId: msg.Id, ChatId: msg.ChatId, Poll: tally})
+	}
+	return &models.PollEvent{MessageId: msg.Id, ChatId: msg.ChatId, Poll: poll}, nil
+}
+
+func checkOptions(poll models.Poll, optionIds []int) utils.HttpError {
+	for i, id := range optionIds {
+		found := slices.ContainsFunc(poll.Options, func(option models.PollOption) bool { return option.Id == id })
+		if !found || slices.Contains(optionIds[:i], id) {
+			err := fmt.Errorf("option %d isn't in the poll or is repeated", id)
+			return utils.NewHttpError(err, http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
+// newPoll checks a poll sent with a message.
+func newPoll(fromRequest models.PollFromRequest) (*models.PollDTO, utils.HttpError) {
+	poll := models.PollDTO{
+		Question:  strings.TrimSpace(fromRequest.Question),
+		Options:   make([]string, 0, len(fromRequest.Options)),
+		Multiple:  fromRequest.Multiple,
+		Anonymous: fromRequest.Anonymous,
+	}
+	if poll.Question == "" || utf8.RuneCountInString(poll.Question) > MAX_POLL_QUESTION_LENGTH {
+		err := fmt.Errorf("poll question must have from 1 to %d characters", MAX_POLL_QUESTION_LENGTH)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if len(fromRequest.Options) < MIN_POLL_OPTIONS || len(fromRequest.Options) > MAX_POLL_OPTIONS {
+		err := fmt.Errorf("poll must have from %d to %d options", MIN_POLL_OPTIONS, MAX_POLL_OPTIONS)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	for _, option := range fromRequest.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > MAX_POLL_OPTION_LENGTH {
+			err := fmt.Errorf("poll options must have from 1 to %d characters", MAX_POLL_OPTION_LENGTH)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		if slices.Contains(poll.Options, option) {
+			err := fmt.Errorf("poll option %q is repeated", option)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		poll.Options = append(poll.Options, option)
+	}
+
+	if fromRequest.ClosesAt != "" {
+		closesAt, err := time.Parse(time.RFC3339, fromRequest.ClosesAt)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		now := time.Now()
+		if !closesAt.After(now) || closesAt.Sub(now) > MAX_POLL_DURATION {
+			err := fmt.Errorf("polls can close from now to %d days ahead", MAX_POLL_DURATION/(24*time.Hour))
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+		poll.ClosesAt = closesAt
+	}
+	return &poll, nil
+}
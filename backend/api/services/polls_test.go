@@ -0,0 +1,199 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func pollMessage(poll models.Poll) models.Message {
+	return models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "poll", Content: poll.Question, Poll: &poll}
+}
+
+func TestVoteSingleChoiceSuccess(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := pollMessage(models.Poll{
+		Question: "Lunch?",
+		Options:  []models.PollOption{{Id: 10, Text: "Pizza"}, {Id: 11, Text: "Sushi"}},
+		MyVotes:  []int{},
+	})
+	tally := models.Poll{
+		Question:   "Lunch?",
+		Options:    []models.PollOption{{Id: 10, Text: "Pizza"}, {Id: 11, Text: "Sushi", Votes: 1, Voters: []int{userId}}},
+		VoterCount: 1,
+		MyVotes:    []int{11},
+	}
+	broadcastTally := tally
+	broadcastTally.MyVotes = []int{}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	mockPollStorer := models_mocks.NewMockIPollStorer(ctrl)
+	mockPollStorer.EXPECT().Vote(msg.Id, userId, []int{11}, true).Return(true, nil)
+	mockPollStorer.EXPECT().GetForMessages([]int{msg.Id}, userId).Return(map[int]models.Poll{msg.Id: tally}, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.
+		EXPECT().
+		Broadcast(msg.ChatId, services.POLL_UPDATED_EVENT, models.PollEvent{MessageId: msg.Id, ChatId: msg.ChatId, Poll: broadcastTally})
+
+	pollService := services.NewPollService(mockPollStorer, mockMessageService, nil, mockBroadcaster)
+
+	//Act
+	actualEvent, httpErr := pollService.Vote(userId, msg.Id, []int{11})
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, models.PollEvent{MessageId: msg.Id, ChatId: msg.ChatId, Poll: tally}, *actualEvent)
+}
+
+func TestVoteSingleChoiceManyOptionsError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := pollMessage(models.Poll{
+		Question: "Lunch?",
+		Options:  []models.PollOption{{Id: 10, Text: "Pizza"}, {Id: 11, Text: "Sushi"}},
+	})
+	expectedError := fmt.Errorf("poll %d takes one vote at a time", msg.Id)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	pollService := services.NewPollService(nil, mockMessageService, nil, nil)
+
+	//Act
+	actualEvent, httpErr := pollService.Vote(userId, msg.Id, []int{10, 11})
+
+	//Assert
+	assert.Nil(t, actualEvent)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestVoteSingleChoiceNoOptionsError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := pollMessage(models.Poll{
+		Question: "Lunch?",
+		Options:  []models.PollOption{{Id: 10, Text: "Pizza"}, {Id: 11, Text: "Sushi"}},
+	})
+	expectedError := errors.New("no options to vote for")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	pollService := services.NewPollService(nil, mockMessageService, nil, nil)
+
+	//Act
+	actualEvent, httpErr := pollService.Vote(userId, msg.Id, []int{})
+
+	//Assert
+	assert.Nil(t, actualEvent)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestVoteClosedPollError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := pollMessage(models.Poll{
+		Question: "Lunch?",
+		Options:  []models.PollOption{{Id: 10, Text: "Pizza"}, {Id: 11, Text: "Sushi"}},
+		Closed:   true,
+	})
+	expectedError := fmt.Errorf("poll %d is closed", msg.Id)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusConflict)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	pollService := services.NewPollService(nil, mockMessageService, nil, nil)
+
+	//Act
+	actualEvent, httpErr := pollService.Vote(userId, msg.Id, []int{10})
+
+	//Assert
+	assert.Nil(t, actualEvent)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestClosePollNotAdminError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := pollMessage(models.Poll{
+		Question: "Lunch?",
+		Options:  []models.PollOption{{Id: 10, Text: "Pizza"}, {Id: 11, Text: "Sushi"}},
+	})
+	expectedError := fmt.Errorf("user %d can't close poll %d", userId, msg.Id)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusForbidden)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.
+		EXPECT().
+		GetChatUsers(userId, msg.ChatId).
+		Return([]models.ChatUser{{Participant: models.Participant{UserId: userId, ChatId: msg.ChatId, Role: "member"}}}, nil)
+
+	pollService := services.NewPollService(nil, mockMessageService, mockParticipantService, nil)
+
+	//Act
+	actualEvent, httpErr := pollService.Close(userId, msg.Id)
+
+	//Assert
+	assert.Nil(t, actualEvent)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestCreatePollRepeatedOptionError(t *testing.T) {
+	//Arrange
+	fromRequest := models.MessageFromRequest{
+		ChatId: 3,
+		Type:   "poll",
+		Poll:   &models.PollFromRequest{Question: "Lunch?", Options: []string{"Pizza", " Pizza "}},
+	}
+	expectedError := fmt.Errorf("poll option %q is repeated", "Pizza")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(1, fromRequest.ChatId).Return(true, nil)
+
+	messageService := services.MessageService{ParticipantService: mockParticipantService}
+
+	//Act
+	actualMessage, httpErr := messageService.Create(1, fromRequest)
+
+	//Assert
+	assert.Nil(t, actualMessage)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
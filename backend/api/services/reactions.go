@@ -0,0 +1,163 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+)
+
+const (
+	MAX_EMOJIS_PER_MESSAGE = 20
+	MAX_REACTIONS_PER_USER = 3
+	MAX_EMOJI_LENGTH       = 32
+	REACTION_ADDED_EVENT   = "reaction:added"
+	REACTION_REMOVED_EVENT = "reaction:removed"
+)
+
+type IReactionService interface {
+	Add(userId, messageId int, emoji string) (*models.ReactionEvent, utils.HttpError)
+	Remove(userId, messageId int, emoji string) (*models.ReactionEvent, utils.HttpError)
+}
+
+type ReactionService struct {
+	ReactionStorer models.IReactionStorer
+	MessageService IMessageService
+	Broadcaster    Broadcaster
+}
+
+func NewReactionService(reactionStorer models.IReactionStorer, messageService IMessageService, broadcaster Broadcaster) ReactionService {
+	return ReactionService{
+		ReactionStorer: reactionStorer,
+		MessageService: messageService,
+		Broadcaster:    broadcaster,
+	}
+}
+
+func (rs ReactionService) Add(userId, messageId int, emoji string) (*models.ReactionEvent, utils.HttpError) {
+	if !IsEmoji(emoji) {
+		err := fmt.Errorf("%q isn't an emoji", emoji)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	msg, httpErr := rs.MessageService.GetOne(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if msg.DeletedAt != "" {
+		err := fmt.Errorf("message %d is deleted", messageId)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	reacted := false
+	for _, reaction := range msg.Reactions {
+		if reaction.Emoji == emoji && reaction.ReactedByMe {
+			reacted = true
+		}
+	}
+	if reacted {
+		err := fmt.Errorf("user %d already reacted with %s to message %d", userId, emoji, messageId)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	emojis, byUser, err := rs.ReactionStorer.CountForMessage(messageId, userId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if byUser >= MAX_REACTIONS_PER_USER {
+		err := fmt.Errorf("user %d can't leave more than %d reactions on a message", userId, MAX_REACTIONS_PER_USER)
+		return nil, utils.NewHttpError(err, http.StatusUnprocessableEntity)
+	}
+
+	newEmoji := true
+	for _, reaction := range msg.Reactions {
+		if reaction.Emoji == emoji {
+			newEmoji = false
+		}
+	}
+	if newEmoji && emojis >= MAX_EMOJIS_PER_MESSAGE {
+		err := fmt.Errorf("message %d can't have more than %d different reactions", messageId, MAX_EMOJIS_PER_MESSAGE)
+		return nil, utils.NewHttpError(err, http.StatusUnprocessableEntity)
+	}
+
+	// the counts above can be outdated by concurrent reactions, the storer checks the limits again
+	_, err = rs.ReactionStorer.Create(messageId, userId, emoji, MAX_EMOJIS_PER_MESSAGE, MAX_REACTIONS_PER_USER)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("user %d already reacted with %s to message %d", userId, emoji, messageId)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		if errors.Is(err, models.ErrReactionLimit) {
+			err := fmt.Errorf("message %d can't take more reactions", messageId)
+			return nil, utils.NewHttpError(err, http.StatusUnprocessableEntity)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return rs.notify(REACTION_ADDED_EVENT, userId, msg.ChatId, messageId, emoji)
+}
+
+func (rs ReactionService) Remove(userId, messageId int, emoji string) (*models.ReactionEvent, utils.HttpError) {
+	msg, httpErr := rs.MessageService.GetOne(userId, messageId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	_, err := rs.ReactionStorer.Delete(messageId, userId, emoji)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("user %d didn't react with %s to message %d", userId, emoji, messageId)
+			return nil, utils.NewHttpError(err, http.StatusNotFound)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return rs.notify(REACTION_REMOVED_EVENT, userId, msg.ChatId, messageId, emoji)
+}
+
+func (rs ReactionService) notify(event string, userId, chatId, messageId int, emoji string) (*models.ReactionEvent, utils.HttpError) {
+	count, err := rs.ReactionStorer.Count(messageId, emoji)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	reaction := models.ReactionEvent{
+		MessageId: messageId,
+		ChatId:    chatId,
+		UserId:    userId,
+		Emoji:     emoji,
+		Count:     count,
+	}
+	if rs.Broadcaster != nil {
+		rs.Broadcaster.Broadcast(chatId, event, reaction)
+	}
+	return &reaction, nil
+}
+
+// IsEmoji reports whether s is a single emoji, possibly built of several code points
+// (skin tone modifiers, ZWJ sequences, flags, keycaps).
+func IsEmoji(s string) bool {
+	if s == "" || len(s) > MAX_EMOJI_LENGTH || !utf8.ValidString(s) {
+		return false
+	}
+	hasSymbol := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.So, r), r == 0x20E3:
+			hasSymbol = true
+		case unicode.Is(unicode.Sk, r), unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r):
+		case r == 0x200D, r == 0xFE0F, r >= 0xE0020 && r <= 0xE007F:
+		case r >= '0' && r <= '9', r == '#', r == '*':
+		default:
+			return false
+		}
+	}
+	return hasSymbol
+}
@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddReactionSuccess(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := models.Message{
+		Id:        5,
+		SenderId:  2,
+		ChatId:    3,
+		Type:      "text",
+		Content:   "hello",
+		Reactions: []models.ReactionSummary{{Emoji: "👍", Count: 1}},
+	}
+	expectedEvent := models.ReactionEvent{MessageId: msg.Id, ChatId: msg.ChatId, UserId: userId, Emoji: "👍", Count: 2}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().CountForMessage(msg.Id, userId).Return(1, 0, nil)
+	mockReactionStorer.
+		EXPECT().
+		Create(msg.Id, userId, "👍", services.MAX_EMOJIS_PER_MESSAGE, services.MAX_REACTIONS_PER_USER).
+		Return(&models.Reaction{MessageId: msg.Id, UserId: userId, Emoji: "👍"}, nil)
+	mockReactionStorer.EXPECT().Count(msg.Id, "👍").Return(2, nil)
+
+	mockBroadcaster := services_mocks.NewMockBroadcaster(ctrl)
+	mockBroadcaster.EXPECT().Broadcast(msg.ChatId, services.REACTION_ADDED_EVENT, expectedEvent)
+
+	reactionService := services.NewReactionService(mockReactionStorer, mockMessageService, mockBroadcaster)
+
+	//Act
+	actualEvent, httpErr := reactionService.Add(userId, msg.Id, "👍")
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, expectedEvent, *actualEvent)
+}
+
+func TestAddReactionUserLimitError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "hello"}
+	expectedError := fmt.Errorf("user %d can't leave more than %d reactions on a message", userId, services.MAX_REACTIONS_PER_USER)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusUnprocessableEntity)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().CountForMessage(msg.Id, userId).Return(3, services.MAX_REACTIONS_PER_USER, nil)
+
+	reactionService := services.NewReactionService(mockReactionStorer, mockMessageService, nil)
+
+	//Act
+	actualEvent, httpErr := reactionService.Add(userId, msg.Id, "🎉")
+
+	//Assert
+	assert.Nil(t, actualEvent)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestAddReactionConcurrentLimitError(t *testing.T) {
+	//Arrange
+	userId := 1
+	msg := models.Message{Id: 5, SenderId: 2, ChatId: 3, Type: "text", Content: "hello"}
+	expectedError := fmt.Errorf("message %d can't take more reactions", msg.Id)
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusUnprocessableEntity)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.EXPECT().GetOne(userId, msg.Id).Return(&msg, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().CountForMessage(msg.Id, userId).Return(services.MAX_EMOJIS_PER_MESSAGE-1, 0, nil)
+	mockReactionStorer.
+		EXPECT().
+		Create(msg.Id, userId, "🎉", services.MAX_EMOJIS_PER_MESSAGE, services.MAX_REACTIONS_PER_USER).
+		Return(nil, models.ErrReactionLimit)
+
+	reactionService := services.NewReactionService(mockReactionStorer, mockMessageService, nil)
+
+	//Act
+	actualEvent, httpErr := reactionService.Add(userId, msg.Id, "🎉")
+
+	//Assert
+	assert.Nil(t, actualEvent)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestIsEmoji(t *testing.T) {
+	//Arrange
+	valid := []string{"👍", "❤️", "👍🏽", "👨‍👩‍👧", "🇺🇦", "1️⃣"}
+	invalid := []string{"", "a", "1", "👍 ", ":+1:"}
+
+	//Act & Assert
+	for _, emoji := range valid {
+		assert.True(t, services.IsEmoji(emoji), emoji)
+	}
+	for _, emoji := range invalid {
+		assert.False(t, services.IsEmoji(emoji), emoji)
+	}
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+)
+
+const (
+	MAX_SCHEDULED_MESSAGES = 100
+	MAX_SCHEDULE_AHEAD     = 366 * 24 * time.Hour
+	SCHEDULE_LEASE         = 5 * time.Minute
+	SCHEDULE_BATCH         = 100
+)
+
+type IScheduledMessageService interface {
+	Create(userId int, fromRequest models.ScheduledMessageFromRequest) (*models.ScheduledMessage, utils.HttpError)
+	GetAll(userId, chatId int) ([]models.ScheduledMessage, utils.HttpError)
+	Update(userId, id int, fromRequest models.ScheduledMessageFromRequest) (*models.ScheduledMessage, utils.HttpError)
+	Delete(userId, id int) (*models.ScheduledMessage, utils.HttpError)
+}
+
+type ScheduledMessageService struct {
+	ScheduledMessageStorer models.IScheduledMessageStorer
+	MessageService         IMessageService
+	ParticipantService     IParticipantService
+}
+
+func NewScheduledMessageService(
+	scheduledMessageStorer models.IScheduledMessageStorer,
+	messageService IMessageService,
+	participantService IParticipantService,
+) ScheduledMessageService {
+	return ScheduledMessageService{
+		ScheduledMessageStorer: scheduledMessageStorer,
+		MessageService:         messageService,
+		ParticipantService:     participantService,
+	}
+}
+
+func (ss ScheduledMessageService) Create(userId int, fromRequest models.ScheduledMessageFromRequest) (*models.ScheduledMessage, utils.HttpError) {
+	userInChat, err := ss.ParticipantService.UserInChat(userId, fromRequest.ChatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if !userInChat {
+		err := fmt.Errorf("user %d doesn't participate in chat %d", userId, fromRequest.ChatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	if fromRequest.Type == "system" || fromRequest.Type == "poll" || fromRequest.Type == "" {
+		err := fmt.Errorf("messages of type %q can't be scheduled", fromRequest.Type)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if fromRequest.ParentId != 0 {
+		parent, httpErr := ss.MessageService.GetOne(userId, fromRequest.ParentId)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		if parent.ChatId != fromRequest.ChatId {
+			err := fmt.Errorf("message %d isn't in chat %d", parent.Id, fromRequest.ChatId)
+			return nil, utils.NewHttpError(err, http.StatusBadRequest)
+		}
+	}
+
+	msg := models.ScheduledMessage{
+		SenderId:      userId,
+		ChatId:        fromRequest.ChatId,
+		Type:          fromRequest.Type,
+		ParentId:      fromRequest.ParentId,
+		AttachmentIds: make([]int, 0),
+	}
+	if httpErr := applySchedule(&msg, fromRequest); httpErr != nil {
+		return nil, httpErr
+	}
+
+	pending, err := ss.ScheduledMessageStorer.CountPending(userId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if pending >= MAX_SCHEDULED_MESSAGES {
+		err := fmt.Errorf("user %d can't have more than %d scheduled messages", userId, MAX_SCHEDULED_MESSAGES)
+		return nil, utils.NewHttpError(err, http.StatusUnprocessableEntity)
+	}
+
+	scheduled, err := ss.ScheduledMessageStorer.Create(msg)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return scheduled, nil
+}
+
+// GetAll returns the messages userId scheduled in chatId, in every chat when
+// chatId is 0. Failed ones are returned with the reason until edited or cancelled.
+func (ss ScheduledMessageService) GetAll(userId, chatId int) ([]models.ScheduledMessage, utils.HttpError) {
+	messages, err := ss.ScheduledMessageStorer.GetForSender(userId, chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return messages, nil
+}
+
+// Update changes the content, format, attachments and send time of a scheduled message.
+func (ss ScheduledMessageService) Update(userId, id int, fromRequest models.ScheduledMessageFromRequest) (*models.ScheduledMessage, utils.HttpError) {
+	msg, httpErr := ss.getOwn(userId, id)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if httpErr := applySchedule(msg, fromRequest); httpErr != nil {
+		return nil, httpErr
+	}
+
+	updMsg, err := ss.ScheduledMessageStorer.Update(*msg)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("scheduled message %d is being sent", id)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return updMsg, nil
+}
+
+// Delete cancels a scheduled message.
+func (ss ScheduledMessageService) Delete(userId, id int) (*models.ScheduledMessage, utils.HttpError) {
+	if _, httpErr := ss.getOwn(userId, id); httpErr != nil {
+		return nil, httpErr
+	}
+
+	msg, err := ss.ScheduledMessageStorer.Delete(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("scheduled message %d is being sent", id)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return msg, nil
+}
+
+func (ss ScheduledMessageService) getOwn(userId, id int) (*models.ScheduledMessage, utils.HttpError) {
+	msg, err := ss.ScheduledMessageStorer.GetOne(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if err != nil || msg.SenderId != userId {
+		err := fmt.Errorf("no scheduled message with id %d", id)
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	return msg, nil
+}
+
+// Start sends the messages that are due every interval, onSent is called with
+// each sent message for it to be delivered to the chat. Messages are leased
+// to one instance at a time, so any number of them can run. A message is
+// forgotten in the transaction it is sent in, so it is never sent twice.
+func (ss ScheduledMessageService) Start(interval time.Duration, onSent func(msg models.Message)) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := ss.SendDue(onSent); err != nil {
+				log.Printf("error while sending scheduled messages: %v\n", err)
+			}
+		}
+	}()
+}
+
+// SendDue sends the messages that are due through MessageService.CreateWith, as if
+// their senders sent them now. Messages that are refused, for example because
+// the sender left the chat, are marked as failed. The others keep their lease and
+// are retried once it runs out, so failing ones aren't claimed again right away.
+func (ss ScheduledMessageService) SendDue(onSent func(msg models.Message)) error {
+	for {
+		due, err := ss.ScheduledMessageStorer.ClaimDue(SCHEDULE_BATCH, SCHEDULE_LEASE)
+		if err != nil {
+			return err
+		}
+
+		for _, scheduled := range due {
+			msg, httpErr := ss.MessageService.CreateWith(scheduled.SenderId, scheduled.MessageFromRequest(), func(tx *sql.Tx) error {
+				return ss.ScheduledMessageStorer.FinishInTx(tx, scheduled.Id)
+			})
+			if httpErr != nil {
+				log.Printf("scheduled message %d wasn't sent: %s\n", scheduled.Id, httpErr.Message())
+				if httpErr.Status() < http.StatusInternalServerError {
+					if err := ss.ScheduledMessageStorer.Fail(scheduled.Id, httpErr.Message()); err != nil {
+						log.Println(err)
+					}
+				}
+				continue
+			}
+
+			onSent(*msg)
+		}
+
+		if len(due) < SCHEDULE_BATCH {
+			return nil
+		}
+	}
+}
+
+// applySchedule sets the content, attachments and send time of fromRequest to msg.
+func applySchedule(msg *models.ScheduledMessage, fromRequest models.ScheduledMessageFromRequest) utils.HttpError {
+	// the markup is kept to be rendered when the message is sent, it is checked now
+	if _, _, httpErr := renderContent(fromRequest.Content, fromRequest.Format); httpErr != nil {
+		return httpErr
+	}
+
+	sendAt, err := ParseSendAt(fromRequest.SendAt, fromRequest.TimeZone)
+	if err != nil {
+		return utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	now := time.Now()
+	if !sendAt.After(now) {
+		err := errors.New("messages can only be scheduled in the future")
+		return utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if sendAt.Sub(now) > MAX_SCHEDULE_AHEAD {
+		err := fmt.Errorf("messages can't be scheduled more than %d days ahead", MAX_SCHEDULE_AHEAD/(24*time.Hour))
+		return utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	msg.Content = fromRequest.Content
+	msg.Format = fromRequest.Format
+	if fromRequest.AttachmentIds != nil {
+		msg.AttachmentIds = fromRequest.AttachmentIds
+	}
+	msg.SendAt = sendAt
+	msg.TimeZone = fromRequest.TimeZone
+	return nil
+}
+
+// ParseSendAt reads an RFC 3339 time or, when timeZone names an IANA time zone,
+// a local time in that zone such as "2023-05-01T09:00:00".
+func ParseSendAt(sendAt, timeZone string) (time.Time, error) {
+	if timeZone == "" {
+		return time.Parse(time.RFC3339, sendAt)
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown time zone %q", timeZone)
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", sendAt, location)
+}
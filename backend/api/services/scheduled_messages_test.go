@@ -0,0 +1,106 @@
+package services_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSendAtInTimeZone(t *testing.T) {
+	//Arrange
+	expected := time.Date(2023, 5, 1, 6, 0, 0, 0, time.UTC)
+
+	//Act
+	actual, err := services.ParseSendAt("2023-05-01T09:00:00", "Europe/Kyiv")
+
+	//Assert
+	assert.Nil(t, err)
+	assert.True(t, expected.Equal(actual))
+}
+
+func TestScheduleMessageInPastError(t *testing.T) {
+	//Arrange
+	userId := 1
+	fromRequest := models.ScheduledMessageFromRequest{
+		ChatId:  2,
+		Type:    "text",
+		Content: "standup",
+		SendAt:  time.Now().Add(-time.Minute).Format(time.RFC3339),
+	}
+	expectedError := errors.New("messages can only be scheduled in the future")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusBadRequest)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, fromRequest.ChatId).Return(true, nil)
+
+	scheduledMessageService := services.NewScheduledMessageService(nil, nil, mockParticipantService)
+
+	//Act
+	actualMsg, httpErr := scheduledMessageService.Create(userId, fromRequest)
+
+	//Assert
+	assert.Nil(t, actualMsg)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestSendDueMessages(t *testing.T) {
+	//Arrange
+	sent := models.ScheduledMessage{Id: 1, SenderId: 1, ChatId: 2, Type: "text", Content: "standup", AttachmentIds: []int{}}
+	refused := models.ScheduledMessage{Id: 2, SenderId: 3, ChatId: 2, Type: "text", Content: "bye", AttachmentIds: []int{}}
+	retried := models.ScheduledMessage{Id: 3, SenderId: 1, ChatId: 2, Type: "text", Content: "later", AttachmentIds: []int{}}
+	expectedMsg := models.Message{Id: 10, SenderId: sent.SenderId, ChatId: sent.ChatId, Type: "text", Content: sent.Content}
+	refusal := fmt.Errorf("user %d doesn't participate in chat %d", refused.SenderId, refused.ChatId)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockScheduledMessageStorer := models_mocks.NewMockIScheduledMessageStorer(ctrl)
+	mockScheduledMessageStorer.
+		EXPECT().
+		ClaimDue(services.SCHEDULE_BATCH, services.SCHEDULE_LEASE).
+		Return([]models.ScheduledMessage{sent, refused, retried}, nil)
+	mockScheduledMessageStorer.EXPECT().FinishInTx(nil, sent.Id).Return(nil)
+	mockScheduledMessageStorer.EXPECT().Fail(refused.Id, refusal.Error()).Return(nil)
+
+	mockMessageService := services_mocks.NewMockIMessageService(ctrl)
+	mockMessageService.
+		EXPECT().
+		CreateWith(sent.SenderId, sent.MessageFromRequest(), gomock.Any()).
+		DoAndReturn(func(userId int, fromRequest models.MessageFromRequest, inTx func(tx *sql.Tx) error) (*models.Message, utils.HttpError) {
+			assert.Nil(t, inTx(nil))
+			return &expectedMsg, nil
+		})
+	mockMessageService.
+		EXPECT().
+		CreateWith(refused.SenderId, refused.MessageFromRequest(), gomock.Any()).
+		Return(nil, utils.NewHttpError(refusal, http.StatusForbidden))
+	// the lease of a message failing on the server is kept, it is retried once it runs out
+	mockMessageService.
+		EXPECT().
+		CreateWith(retried.SenderId, retried.MessageFromRequest(), gomock.Any()).
+		Return(nil, utils.NewHttpError(errors.New("connection refused"), http.StatusInternalServerError))
+
+	scheduledMessageService := services.NewScheduledMessageService(mockScheduledMessageStorer, mockMessageService, nil)
+	delivered := make([]models.Message, 0)
+
+	//Act
+	err := scheduledMessageService.SendDue(func(msg models.Message) { delivered = append(delivered, msg) })
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Message{expectedMsg}, delivered)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"golang.org/x/exp/slices"
+)
+
+// SearchableTypes are the message types search can be filtered by.
+var SearchableTypes = []string{"text", "image", "video", "audio", "poll"}
+
+// Search finds messages matching query in chats userId participates in. Highlights
+// are HTML escaped content fragments with matched terms wrapped in <mark>.
+func (ms MessageService) Search(userId int, query models.SearchQuery) ([]models.SearchResult, utils.HttpError) {
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		err := errors.New("search query can't be empty")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if query.Type != "" && !slices.Contains(SearchableTypes, query.Type) {
+		err := fmt.Errorf("can't search messages of type %q", query.Type)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = models.PAGE_SIZE
+	}
+	if query.Limit < 0 || query.Limit > MAX_HISTORY_LIMIT {
+		err := fmt.Errorf("limit should be between 1 and %d", MAX_HISTORY_LIMIT)
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if query.Page < 0 {
+		err := errors.New("page can't be negative")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+
+	if query.ChatId != 0 {
+		userInChat, err := ms.ParticipantService.UserInChat(userId, query.ChatId)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+
+		if !userInChat {
+			err := fmt.Errorf("user %d doesn't participate in chat %d", userId, query.ChatId)
+			return nil, utils.NewHttpError(err, http.StatusForbidden)
+		}
+	}
+
+	results, err := ms.MessageStorer.Search(userId, query)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	messages := make([]models.Message, len(results))
+	for i, result := range results {
+		messages[i] = result.Message
+	}
+	messages, httpErr := ms.populate(userId, messages)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	for i := range results {
+		results[i].Message = messages[i]
+		results[i].Highlight = renderHighlight(results[i].Highlight)
+	}
+	return results, nil
+}
+
+func renderHighlight(highlight string) string {
+	return strings.NewReplacer(
+		models.HighlightStart, "<mark>",
+		models.HighlightStop, "</mark>",
+	).Replace(html.EscapeString(highlight))
+}
@@ -0,0 +1,57 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchMessagesEscapesHighlight(t *testing.T) {
+	//Arrange
+	userId := 1
+	query := models.SearchQuery{Text: " deploy ", Limit: 10}
+	expectedQuery := models.SearchQuery{Text: "deploy", Limit: 10}
+	msg := models.Message{Id: 4, SenderId: 2, ChatId: 3, Type: "text", Content: "<b>deploy</b> today"}
+	stored := []models.SearchResult{{
+		Message:   msg,
+		Rank:      0.1,
+		Highlight: "<b>" + models.HighlightStart + "deploy" + models.HighlightStop + "</b> today",
+	}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageStorer := models_mocks.NewMockIMessageStorer(ctrl)
+	mockMessageStorer.EXPECT().Search(userId, expectedQuery).Return(stored, nil)
+	mockMessageStorer.EXPECT().GetThreads([]int{msg.Id}).Return(map[int]models.Thread{}, nil)
+
+	mockMentionStorer := models_mocks.NewMockIMentionStorer(ctrl)
+	mockMentionStorer.EXPECT().GetForMessages([]int{msg.Id}).Return(map[int][]models.Mention{}, nil)
+
+	mockReactionStorer := models_mocks.NewMockIReactionStorer(ctrl)
+	mockReactionStorer.EXPECT().GetForMessages([]int{msg.Id}, userId).Return(map[int][]models.ReactionSummary{}, nil)
+
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.EXPECT().GetForMessages(gomock.Any()).Return(map[int][]models.Attachment{}, nil)
+
+	messageService := services.MessageService{
+		MessageStorer:    mockMessageStorer,
+		MentionStorer:    mockMentionStorer,
+		ReactionStorer:   mockReactionStorer,
+		AttachmentStorer: mockAttachmentStorer,
+	}
+
+	//Act
+	results, httpErr := messageService.Search(userId, query)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "&lt;b&gt;<mark>deploy</mark>&lt;/b&gt; today", results[0].Highlight)
+		assert.Equal(t, msg.Content, results[0].Message.Content)
+	}
+}
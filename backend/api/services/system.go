@@ -0,0 +1,52 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+)
+
+// systemMessage is the "system" message describing a chat event caused by userId,
+// its content is the JSON encoded event together with its data.
+func systemMessage(userId, chatId int, event string, data any) (models.MessageDTO, error) {
+	content, err := json.Marshal(models.SystemContent{Event: event, Data: data})
+	if err != nil {
+		return models.MessageDTO{}, err
+	}
+	return models.MessageDTO{
+		SenderId: userId,
+		ChatId:   chatId,
+		Type:     "system",
+		Content:  string(content),
+	}, nil
+}
+
+func createSystem(messageStorer models.IMessageStorer, userId, chatId int, event string, data any) (*models.Message, error) {
+	dto, err := systemMessage(userId, chatId, event, data)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := messageStorer.Create(dto)
+	if err != nil {
+		return nil, err
+	}
+	msg.Mentions = make([]models.Mention, 0)
+	msg.Reactions = make([]models.ReactionSummary, 0)
+	msg.Attachments = make([]models.Attachment, 0)
+	return msg, nil
+}
+
+// announce leaves a system message about a chat event in the chat history and sends
+// it to the chat. The event already happened, so failing to tell about it is only logged.
+func announce(messageStorer models.IMessageStorer, broadcaster Broadcaster, userId, chatId int, event string, data any) {
+	msg, err := createSystem(messageStorer, userId, chatId, event, data)
+	if err != nil {
+		log.Printf("error while announcing %s in chat %d: %v\n", event, chatId, err)
+		return
+	}
+	if broadcaster != nil {
+		broadcaster.Broadcast(chatId, "message", msg)
+	}
+}
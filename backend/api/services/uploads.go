@@ -0,0 +1,408 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+)
+
+const (
+	MAX_UPLOAD_SIZE int64 = 2 << 30
+	UPLOAD_TTL            = 24 * time.Hour
+)
+
+// IUploadService keeps resumable uploads of files too large to send in one request.
+// An upload is created with its length, receives chunks at its offset until it
+// is complete and is then turned into an attachment.
+type IUploadService interface {
+	Create(userId, chatId int, fileName string, length int64) (*models.Upload, utils.HttpError)
+	GetOne(userId int, uploadId string) (*models.Upload, utils.HttpError)
+	Append(userId int, uploadId string, offset int64, r io.Reader) (*models.Upload, utils.HttpError)
+	Terminate(userId int, uploadId string) utils.HttpError
+	Complete(userId int, uploadId string) (*models.Attachment, utils.HttpError)
+}
+
+type UploadService struct {
+	UploadStorer       models.IUploadStorer
+	AttachmentStorer   models.IAttachmentStorer
+	ParticipantService IParticipantService
+	Storage            storage.Storage
+}
+
+func NewUploadService(uploadStorer models.IUploadStorer, attachmentStorer models.IAttachmentStorer, participantService IParticipantService, blobs storage.Storage) UploadService {
+	return UploadService{
+		UploadStorer:       uploadStorer,
+		AttachmentStorer:   attachmentStorer,
+		ParticipantService: participantService,
+		Storage:            blobs,
+	}
+}
+
+func (us UploadService) Create(userId, chatId int, fileName string, length int64) (*models.Upload, utils.HttpError) {
+	userInChat, err := us.ParticipantService.UserInChat(userId, chatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if !userInChat {
+		err := fmt.Errorf("user %d doesn't participate in chat %d", userId, chatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	fileName, httpErr := cleanFileName(fileName)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if length <= 0 {
+		err := errors.New("upload length must be positive")
+		return nil, utils.NewHttpError(err, http.StatusBadRequest)
+	}
+	if length > MAX_UPLOAD_SIZE {
+		err := fmt.Errorf("uploads can't be larger than %d bytes", MAX_UPLOAD_SIZE)
+		return nil, utils.NewHttpError(err, http.StatusRequestEntityTooLarge)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	dto := models.UploadDTO{
+		Id:         hex.EncodeToString(id),
+		UploaderId: userId,
+		ChatId:     chatId,
+		FileName:   fileName,
+		Length:     length,
+	}
+	upload, err := us.UploadStorer.Create(dto, UPLOAD_TTL)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return upload, nil
+}
+
+// GetOne returns an upload of userId, uploads of others and expired ones aren't found.
+func (us UploadService) GetOne(userId int, uploadId string) (*models.Upload, utils.HttpError) {
+	upload, err := us.UploadStorer.GetOne(uploadId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if err != nil || upload.UploaderId != userId {
+		err := fmt.Errorf("no upload with id %s", uploadId)
+		return nil, utils.NewHttpError(err, http.StatusNotFound)
+	}
+
+	return upload, nil
+}
+
+// Append stores the data read from r as the chunk of the upload at offset.
+// When r fails midway the data read so far is kept so the client can resume
+// from the new offset.
+func (us UploadService) Append(userId int, uploadId string, offset int64, r io.Reader) (*models.Upload, utils.HttpError) {
+	upload, httpErr := us.GetOne(userId, uploadId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if upload.Offset != offset {
+		err := fmt.Errorf("upload %s is at offset %d", uploadId, upload.Offset)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	tmp, err := os.CreateTemp("", "chunk-*")
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	remaining := upload.Length - upload.Offset
+	size, readErr := io.Copy(tmp, io.LimitReader(r, remaining+1))
+	if size > remaining {
+		err := fmt.Errorf("upload %s has only %d bytes left", uploadId, remaining)
+		return nil, utils.NewHttpError(err, http.StatusRequestEntityTooLarge)
+	}
+	if size == 0 {
+		if readErr != nil {
+			return nil, utils.NewHttpError(readErr, http.StatusBadRequest)
+		}
+		return upload, nil
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	chunk, err := storage.NewKey("uploads/" + uploadId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	err = us.Storage.Put(chunk, tmp, size, "application/octet-stream")
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	upload, err = us.UploadStorer.Append(uploadId, offset, size, chunk, UPLOAD_TTL)
+	if err != nil {
+		if err := us.Storage.Delete(chunk); err != nil {
+			log.Println(err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("upload %s was changed concurrently", uploadId)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return upload, nil
+}
+
+// Terminate deletes an upload along with the chunks received so far.
+func (us UploadService) Terminate(userId int, uploadId string) utils.HttpError {
+	_, httpErr := us.GetOne(userId, uploadId)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	upload, err := us.UploadStorer.Delete(uploadId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("upload %s is being completed", uploadId)
+			return utils.NewHttpError(err, http.StatusConflict)
+		}
+		return utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	us.deleteChunks(upload.Chunks)
+	return nil
+}
+
+// Complete turns a fully received upload into an attachment of its chat the
+// same way Upload of the attachment service does. Completing it again returns
+// the same attachment.
+func (us UploadService) Complete(userId int, uploadId string) (*models.Attachment, utils.HttpError) {
+	upload, httpErr := us.GetOne(userId, uploadId)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if upload.AttachmentId != 0 {
+		attachment, err := us.AttachmentStorer.GetOne(upload.AttachmentId)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		return attachment, nil
+	}
+
+	if upload.Offset != upload.Length {
+		err := fmt.Errorf("upload %s has %d of %d bytes", uploadId, upload.Offset, upload.Length)
+		return nil, utils.NewHttpError(err, http.StatusConflict)
+	}
+
+	userInChat, err := us.ParticipantService.UserInChat(userId, upload.ChatId)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	if !userInChat {
+		err := fmt.Errorf("user %d doesn't participate in chat %d", userId, upload.ChatId)
+		return nil, utils.NewHttpError(err, http.StatusForbidden)
+	}
+
+	upload, err = us.UploadStorer.Claim(uploadId, UPLOAD_TTL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err := fmt.Errorf("upload %s is being completed", uploadId)
+			return nil, utils.NewHttpError(err, http.StatusConflict)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	attachment, httpErr := us.attach(upload)
+	if httpErr != nil {
+		if err := us.UploadStorer.Release(uploadId); err != nil {
+			log.Println(err)
+		}
+		return nil, httpErr
+	}
+
+	us.deleteChunks(upload.Chunks)
+	return attachment, nil
+}
+
+// attach copies the chunks of a claimed upload into a single object and makes it an attachment.
+func (us UploadService) attach(upload *models.Upload) (*models.Attachment, utils.HttpError) {
+	chunks := &chunkReader{storage: us.Storage, keys: upload.Chunks}
+	defer chunks.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(chunks, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	mimeType, httpErr := sniffMimeType(head[:n])
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	dto := models.AttachmentDTO{
+		UploaderId: upload.UploaderId,
+		ChatId:     upload.ChatId,
+		FileName:   upload.FileName,
+		MimeType:   mimeType,
+		Size:       upload.Length,
+	}
+	var data io.Reader = io.MultiReader(bytes.NewReader(head[:n]), chunks)
+	if mayBeAudio(mimeType) && upload.Length <= MAX_ATTACHMENT_SIZE {
+		buf, err := io.ReadAll(data)
+		if err != nil {
+			return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+		}
+		if httpErr := probeAudio(&dto, buf); httpErr != nil {
+			return nil, httpErr
+		}
+		data = bytes.NewReader(buf)
+	} else if strings.HasPrefix(mimeType, "audio/") {
+		err := fmt.Errorf("audio attachments can't be larger than %d bytes", MAX_ATTACHMENT_SIZE)
+		return nil, utils.NewHttpError(err, http.StatusRequestEntityTooLarge)
+	}
+
+	dto.Key, err = storage.NewKey(fmt.Sprintf("chats/%d", upload.ChatId))
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+	err = us.Storage.Put(dto.Key, data, upload.Length, dto.MimeType)
+	if err != nil {
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	// the attachment and the upload pointing at it are stored together, so a failed
+	// completion leaves neither behind to be retried
+	attachment, err := us.createAttachment(upload.Id, dto)
+	if err != nil {
+		if err := us.Storage.Delete(dto.Key); err != nil {
+			log.Println(err)
+		}
+		return nil, utils.NewHttpError(err, http.StatusInternalServerError)
+	}
+
+	return attachment, nil
+}
+
+func (us UploadService) createAttachment(uploadId string, dto models.AttachmentDTO) (attachment *models.Attachment, err error) {
+	tx, err := us.UploadStorer.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
+	attachment, err = us.AttachmentStorer.CreateInTx(tx, dto)
+	if err != nil {
+		return nil, err
+	}
+	if err = us.UploadStorer.FinishInTx(tx, uploadId, attachment.Id); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// ExpireAbandoned deletes the uploads that weren't appended to within UPLOAD_TTL.
+func (us UploadService) ExpireAbandoned() error {
+	uploads, err := us.UploadStorer.DeleteExpired()
+	if err != nil {
+		return err
+	}
+	for _, upload := range uploads {
+		us.deleteChunks(upload.Chunks)
+	}
+	return nil
+}
+
+// StartExpiring runs ExpireAbandoned every interval. Expired uploads are deleted
+// by one query so any number of instances can run it.
+func (us UploadService) StartExpiring(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := us.ExpireAbandoned(); err != nil {
+				log.Printf("error while expiring uploads: %v\n", err)
+			}
+		}
+	}()
+}
+
+func (us UploadService) deleteChunks(chunks []string) {
+	for _, chunk := range chunks {
+		if err := us.Storage.Delete(chunk); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Println(err)
+		}
+	}
+}
+
+// chunkReader reads the chunks of an upload one after another, opening each
+// only when the previous one is read to the end.
+type chunkReader struct {
+	storage storage.Storage
+	keys    []string
+	current io.ReadCloser
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if cr.current == nil {
+			if len(cr.keys) == 0 {
+				return 0, io.EOF
+			}
+			current, err := cr.storage.Get(cr.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			cr.current = current
+			cr.keys = cr.keys[1:]
+		}
+
+		n, err := cr.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			cr.current.Close()
+			cr.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (cr *chunkReader) Close() error {
+	if cr.current == nil {
+		return nil
+	}
+	return cr.current.Close()
+}
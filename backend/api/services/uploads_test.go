@@ -0,0 +1,242 @@
+package services_test
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	models_mocks "github.com/BogPin/real-time-chat/backend/api/models/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	services_mocks "github.com/BogPin/real-time-chat/backend/api/services/mocks"
+	"github.com/BogPin/real-time-chat/backend/api/storage"
+	"github.com/BogPin/real-time-chat/backend/api/utils"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendUploadOffsetError(t *testing.T) {
+	//Arrange
+	userId := 1
+	upload := models.Upload{Id: "abc", UploaderId: userId, ChatId: 2, Length: 100, Offset: 40}
+	expectedError := errors.New("upload abc is at offset 40")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusConflict)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUploadStorer := models_mocks.NewMockIUploadStorer(ctrl)
+	mockUploadStorer.EXPECT().GetOne(upload.Id).Return(&upload, nil)
+
+	uploadService := services.NewUploadService(mockUploadStorer, nil, nil, nil)
+
+	//Act
+	actual, httpErr := uploadService.Append(userId, upload.Id, 0, bytes.NewReader(make([]byte, 10)))
+
+	//Assert
+	assert.Nil(t, actual)
+	assert.Equal(t, expectedHTTPError, httpErr)
+}
+
+func TestAppendUploadKeepsPartialChunk(t *testing.T) {
+	//Arrange
+	userId := 1
+	upload := models.Upload{Id: "abc", UploaderId: userId, ChatId: 2, Length: 100}
+	body := io.MultiReader(bytes.NewReader(make([]byte, 30)), &failingReader{})
+
+	blobs, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUploadStorer := models_mocks.NewMockIUploadStorer(ctrl)
+	mockUploadStorer.EXPECT().GetOne(upload.Id).Return(&upload, nil)
+	mockUploadStorer.
+		EXPECT().
+		Append(upload.Id, int64(0), int64(30), gomock.Any(), services.UPLOAD_TTL).
+		DoAndReturn(func(id string, offset, size int64, chunk string, ttl any) (*models.Upload, error) {
+			appended := upload
+			appended.Offset = size
+			appended.Chunks = []string{chunk}
+			return &appended, nil
+		})
+
+	uploadService := services.NewUploadService(mockUploadStorer, nil, nil, blobs)
+
+	//Act
+	actual, httpErr := uploadService.Append(userId, upload.Id, 0, body)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, int64(30), actual.Offset)
+}
+
+func TestCompleteUploadJoinsChunks(t *testing.T) {
+	//Arrange
+	userId := 1
+	chatId := 2
+	content := append(append([]byte{}, pngHeader...), make([]byte, 1000)...)
+
+	blobs, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := []string{"uploads/abc/1", "uploads/abc/2", "uploads/abc/3"}
+	for i, part := range [][]byte{content[:5], content[5:600], content[600:]} {
+		if err := blobs.Put(chunks[i], bytes.NewReader(part), int64(len(part)), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	upload := models.Upload{
+		Id:         "abc",
+		UploaderId: userId,
+		ChatId:     chatId,
+		FileName:   "recording.png",
+		Length:     int64(len(content)),
+		Offset:     int64(len(content)),
+		Chunks:     chunks,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while begining transaction", err)
+	}
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, chatId).Return(true, nil)
+
+	mockUploadStorer := models_mocks.NewMockIUploadStorer(ctrl)
+	mockUploadStorer.EXPECT().GetOne(upload.Id).Return(&upload, nil)
+	mockUploadStorer.EXPECT().Claim(upload.Id, services.UPLOAD_TTL).Return(&upload, nil)
+	mockUploadStorer.EXPECT().Begin().Return(tx, nil)
+	mockUploadStorer.EXPECT().FinishInTx(tx, upload.Id, 7).Return(nil)
+
+	var stored models.AttachmentDTO
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.
+		EXPECT().
+		CreateInTx(tx, gomock.Any()).
+		DoAndReturn(func(tx *sql.Tx, dto models.AttachmentDTO) (*models.Attachment, error) {
+			stored = dto
+			return &models.Attachment{Id: 7, UploaderId: dto.UploaderId, ChatId: dto.ChatId, Key: dto.Key}, nil
+		})
+
+	uploadService := services.NewUploadService(mockUploadStorer, mockAttachmentStorer, mockParticipantService, blobs)
+
+	//Act
+	attachment, httpErr := uploadService.Complete(userId, upload.Id)
+
+	//Assert
+	assert.Nil(t, httpErr)
+	assert.Equal(t, 7, attachment.Id)
+	assert.Equal(t, "image/png", stored.MimeType)
+	blob, err := blobs.Get(stored.Key)
+	if assert.Nil(t, err) {
+		defer blob.Close()
+		actual, _ := io.ReadAll(blob)
+		assert.Equal(t, content, actual)
+	}
+	_, err = blobs.Get(chunks[0])
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Nil(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCompleteUploadFinishError(t *testing.T) {
+	//Arrange
+	userId := 1
+	chatId := 2
+	content := append(append([]byte{}, pngHeader...), make([]byte, 100)...)
+	expectedError := errors.New("connection refused")
+	expectedHTTPError := utils.NewHttpError(expectedError, http.StatusInternalServerError)
+
+	blobs, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := []string{"uploads/abc/1"}
+	if err := blobs.Put(chunks[0], bytes.NewReader(content), int64(len(content)), ""); err != nil {
+		t.Fatal(err)
+	}
+	upload := models.Upload{
+		Id:         "abc",
+		UploaderId: userId,
+		ChatId:     chatId,
+		FileName:   "photo.png",
+		Length:     int64(len(content)),
+		Offset:     int64(len(content)),
+		Chunks:     chunks,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while begining transaction", err)
+	}
+
+	mockParticipantService := services_mocks.NewMockIParticipantService(ctrl)
+	mockParticipantService.EXPECT().UserInChat(userId, chatId).Return(true, nil)
+
+	mockUploadStorer := models_mocks.NewMockIUploadStorer(ctrl)
+	mockUploadStorer.EXPECT().GetOne(upload.Id).Return(&upload, nil)
+	mockUploadStorer.EXPECT().Claim(upload.Id, services.UPLOAD_TTL).Return(&upload, nil)
+	mockUploadStorer.EXPECT().Begin().Return(tx, nil)
+	mockUploadStorer.EXPECT().FinishInTx(tx, upload.Id, 7).Return(expectedError)
+	mockUploadStorer.EXPECT().Release(upload.Id).Return(nil)
+
+	var stored models.AttachmentDTO
+	mockAttachmentStorer := models_mocks.NewMockIAttachmentStorer(ctrl)
+	mockAttachmentStorer.
+		EXPECT().
+		CreateInTx(tx, gomock.Any()).
+		DoAndReturn(func(tx *sql.Tx, dto models.AttachmentDTO) (*models.Attachment, error) {
+			stored = dto
+			return &models.Attachment{Id: 7, UploaderId: dto.UploaderId, ChatId: dto.ChatId, Key: dto.Key}, nil
+		})
+
+	uploadService := services.NewUploadService(mockUploadStorer, mockAttachmentStorer, mockParticipantService, blobs)
+
+	//Act
+	attachment, httpErr := uploadService.Complete(userId, upload.Id)
+
+	//Assert
+	assert.Nil(t, attachment)
+	assert.Equal(t, expectedHTTPError, httpErr)
+	_, err = blobs.Get(stored.Key)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	chunk, err := blobs.Get(chunks[0])
+	assert.Nil(t, err)
+	chunk.Close()
+	assert.Nil(t, sqlMock.ExpectationsWereMet())
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
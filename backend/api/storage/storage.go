@@ -0,0 +1,31 @@
+// Package storage keeps uploaded files behind a Storage interface with
+// local filesystem and S3-compatible implementations.
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("storage: no object with such key")
+
+type Storage interface {
+	// Put stores size bytes read from r under key.
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// SignedURL returns a URL anyone can download the object with until expires passes.
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// NewKey makes a random object key under prefix.
+func NewKey(prefix string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + "/" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,24 @@
+package wshandlers
+
+import (
+	"github.com/BogPin/real-time-chat/backend/api/wss"
+)
+
+// Broadcaster sends events to chat rooms of a server, it lets services
+// tell chats about changes made outside of socket handlers.
+type Broadcaster struct {
+	server *wss.WsServer
+}
+
+func NewBroadcaster(server *wss.WsServer) *Broadcaster {
+	return &Broadcaster{server: server}
+}
+
+// Broadcast sends the event to everyone in the chat room, nobody being online is not an error.
+func (b *Broadcaster) Broadcast(chatId int, event string, data any) {
+	chatRoom, err := b.server.Rooms.Get(chatId)
+	if err != nil {
+		return
+	}
+	chatRoom.Send(0, wss.NewMessage(event, data))
+}
@@ -0,0 +1,410 @@
+package wshandlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/wss"
+	"github.com/mitchellh/mapstructure"
+	"golang.org/x/exp/slices"
+)
+
+type Call struct {
+	ChatId       int       `json:"chatId"`
+	InitiatorId  int       `json:"initiatorId"`
+	Media        string    `json:"media"`
+	State        string    `json:"state"`
+	Participants []int     `json:"participants"`
+	Declined     []int     `json:"declined"`
+	StartedAt    time.Time `json:"startedAt"`
+}
+
+type CallFromRequest struct {
+	ChatId int    `json:"chatId"`
+	Media  string `json:"media"`
+}
+
+type SignalFromRequest struct {
+	ChatId    int `json:"chatId"`
+	ToUserId  int `json:"toUserId"`
+	Sdp       any `json:"sdp"`
+	Candidate any `json:"candidate"`
+}
+
+type Signal struct {
+	ChatId     int `json:"chatId"`
+	FromUserId int `json:"fromUserId"`
+	Sdp        any `json:"sdp,omitempty"`
+	Candidate  any `json:"candidate,omitempty"`
+}
+
+type CallMember struct {
+	ChatId int `json:"chatId"`
+	UserId int `json:"userId"`
+}
+
+type safeCalls struct {
+	mu    sync.Mutex
+	calls map[int]*Call
+}
+
+func (sc *safeCalls) Get(chatId int) (Call, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	c, ok := sc.calls[chatId]
+	if !ok {
+		return Call{}, fmt.Errorf("no call in chat %d", chatId)
+	}
+	return c.copy(), nil
+}
+
+func (sc *safeCalls) Start(chatId, userId int, media string) (Call, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, ok := sc.calls[chatId]; ok {
+		return Call{}, fmt.Errorf("call in chat %d is already in progress", chatId)
+	}
+	c := &Call{
+		ChatId:       chatId,
+		InitiatorId:  userId,
+		Media:        media,
+		State:        "ringing",
+		Participants: []int{userId},
+		Declined:     []int{},
+	}
+	sc.calls[chatId] = c
+	return c.copy(), nil
+}
+
+// Join adds userId to the call in chatId. started reports whether
+// this join turned a ringing call into an active one.
+func (sc *safeCalls) Join(chatId, userId int) (c Call, started bool, err error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	call, ok := sc.calls[chatId]
+	if !ok {
+		return Call{}, false, fmt.Errorf("no call in chat %d", chatId)
+	}
+	if slices.Contains(call.Participants, userId) {
+		return Call{}, false, fmt.Errorf("user %d already joined call in chat %d", userId, chatId)
+	}
+	call.Participants = append(call.Participants, userId)
+	// users may still accept a call they declined on another device
+	if i := slices.Index(call.Declined, userId); i != -1 {
+		call.Declined = slices.Delete(call.Declined, i, i+1)
+	}
+	if call.State == "ringing" {
+		call.State = "active"
+		call.StartedAt = time.Now()
+		started = true
+	}
+	return call.copy(), started, nil
+}
+
+// Leave removes userId from the call in chatId. ended reports whether
+// the call is over: a ringing call ends when its initiator hangs up,
+// an active one when less than two participants remain.
+func (sc *safeCalls) Leave(chatId, userId int) (c Call, ended bool, err error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	call, ok := sc.calls[chatId]
+	if !ok {
+		return Call{}, false, fmt.Errorf("no call in chat %d", chatId)
+	}
+	i := slices.Index(call.Participants, userId)
+	if i == -1 {
+		return Call{}, false, fmt.Errorf("user %d isn't in call in chat %d", userId, chatId)
+	}
+	call.Participants = slices.Delete(call.Participants, i, i+1)
+	ended = call.State == "ringing" && userId == call.InitiatorId ||
+		call.State == "active" && len(call.Participants) < 2
+	if ended {
+		call.State = "ended"
+		delete(sc.calls, chatId)
+	}
+	return call.copy(), ended, nil
+}
+
+// Decline records that userId won't join the call in chatId. ended reports
+// whether the call is over: a ringing call ends once all invitees, the
+// participants of the chat other than its initiator, declined it.
+func (sc *safeCalls) Decline(chatId, userId, invitees int) (c Call, ended bool, err error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	call, ok := sc.calls[chatId]
+	if !ok {
+		return Call{}, false, fmt.Errorf("no call in chat %d", chatId)
+	}
+	if slices.Contains(call.Participants, userId) {
+		return Call{}, false, fmt.Errorf("user %d already joined call in chat %d", userId, chatId)
+	}
+	if slices.Contains(call.Declined, userId) {
+		return Call{}, false, fmt.Errorf("user %d already declined call in chat %d", userId, chatId)
+	}
+	call.Declined = append(call.Declined, userId)
+	ended = call.State == "ringing" && len(call.Declined) >= invitees
+	if ended {
+		call.State = "ended"
+		delete(sc.calls, chatId)
+	}
+	return call.copy(), ended, nil
+}
+
+func (sc *safeCalls) GetAllForUser(userId int) []int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	chatIds := make([]int, 0)
+	for chatId, call := range sc.calls {
+		if slices.Contains(call.Participants, userId) {
+			chatIds = append(chatIds, chatId)
+		}
+	}
+	return chatIds
+}
+
+func (c *Call) copy() Call {
+	cp := *c
+	cp.Participants = slices.Clone(c.Participants)
+	cp.Declined = slices.Clone(c.Declined)
+	return cp
+}
+
+// CallHandler relays WebRTC signaling between participants of a chat.
+// Media never goes through the server, it only tracks call state per chat
+// and forwards offers, answers and ICE candidates to the addressed peer.
+type CallHandler struct {
+	server             *wss.WsServer
+	participantService services.IParticipantService
+	messageService     services.IMessageService
+	calls              safeCalls
+}
+
+func NewCallHandler(server *wss.WsServer, participantService services.IParticipantService, messageService services.IMessageService) *CallHandler {
+	return &CallHandler{
+		server:             server,
+		participantService: participantService,
+		messageService:     messageService,
+		calls: safeCalls{
+			calls: make(map[int]*Call),
+		},
+	}
+}
+
+func (ch *CallHandler) Register(socket *wss.Socket) {
+	socket.On("call:invite", func(data any) { ch.invite(socket, data) })
+	socket.On("call:accept", func(data any) { ch.accept(socket, data) })
+	socket.On("call:decline", func(data any) { ch.decline(socket, data) })
+	socket.On("call:leave", func(data any) { ch.leave(socket, data) })
+	socket.On("call:offer", func(data any) { ch.relay(socket, "call:offer", data) })
+	socket.On("call:answer", func(data any) { ch.relay(socket, "call:answer", data) })
+	socket.On("call:ice", func(data any) { ch.relay(socket, "call:ice", data) })
+	socket.On("disconnect", func(data any) {
+		// the user is still in their calls on their newer connection
+		if socket.Superseded() {
+			return
+		}
+		for _, chatId := range ch.calls.GetAllForUser(socket.UserId) {
+			if _, _, err := ch.hangUp(socket.UserId, chatId); err != nil {
+				log.Println(err)
+			}
+		}
+	})
+}
+
+func (ch *CallHandler) invite(socket *wss.Socket, data any) {
+	req := CallFromRequest{}
+	if err := mapstructure.Decode(data, &req); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(req))
+		return
+	}
+	if req.Media != "audio" && req.Media != "video" {
+		sendError(socket, errors.New("call media must be either audio or video"))
+		return
+	}
+
+	chatRoom, err := ch.authorize(socket.UserId, req.ChatId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	call, err := ch.calls.Start(req.ChatId, socket.UserId, req.Media)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	chatRoom.Send(socket.UserId, wss.NewMessage("call:invite", call))
+	sendMessage(socket, wss.NewMessage("call:state", call))
+}
+
+func (ch *CallHandler) accept(socket *wss.Socket, data any) {
+	req := CallFromRequest{}
+	if err := mapstructure.Decode(data, &req); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(req))
+		return
+	}
+
+	chatRoom, err := ch.authorize(socket.UserId, req.ChatId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	call, started, err := ch.calls.Join(req.ChatId, socket.UserId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	member := CallMember{ChatId: call.ChatId, UserId: socket.UserId}
+	chatRoom.Send(socket.UserId, wss.NewMessage("call:accept", member))
+	sendMessage(socket, wss.NewMessage("call:state", call))
+
+	if started {
+		data := map[string]any{"media": call.Media}
+		ch.messageService.Announce(call.InitiatorId, call.ChatId, "call_started", data)
+	}
+}
+
+func (ch *CallHandler) decline(socket *wss.Socket, data any) {
+	req := CallFromRequest{}
+	if err := mapstructure.Decode(data, &req); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(req))
+		return
+	}
+
+	chatRoom, err := ch.authorize(socket.UserId, req.ChatId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	chatUsers, httpErr := ch.participantService.GetChatUsers(socket.UserId, req.ChatId)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+		return
+	}
+
+	call, ended, err := ch.calls.Decline(req.ChatId, socket.UserId, len(chatUsers)-1)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	member := CallMember{ChatId: call.ChatId, UserId: socket.UserId}
+	chatRoom.Send(socket.UserId, wss.NewMessage("call:decline", member))
+	if ended {
+		chatRoom.Send(socket.UserId, wss.NewMessage("call:end", call))
+		sendMessage(socket, wss.NewMessage("call:end", call))
+	}
+}
+
+func (ch *CallHandler) leave(socket *wss.Socket, data any) {
+	req := CallFromRequest{}
+	if err := mapstructure.Decode(data, &req); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(req))
+		return
+	}
+	call, ended, err := ch.hangUp(socket.UserId, req.ChatId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+	if ended {
+		sendMessage(socket, wss.NewMessage("call:end", call))
+	}
+}
+
+// hangUp takes userId out of the call in chatId and tells the rest of the chat.
+// Nothing is sent to userId, whose socket may be closed already.
+func (ch *CallHandler) hangUp(userId, chatId int) (c Call, ended bool, err error) {
+	call, ended, err := ch.calls.Leave(chatId, userId)
+	if err != nil {
+		return Call{}, false, err
+	}
+
+	chatRoom, err := ch.server.Rooms.Get(chatId)
+	if err != nil {
+		log.Println(err)
+		return call, ended, nil
+	}
+
+	member := CallMember{ChatId: chatId, UserId: userId}
+	chatRoom.Send(userId, wss.NewMessage("call:leave", member))
+	if !ended {
+		return call, ended, nil
+	}
+
+	chatRoom.Send(userId, wss.NewMessage("call:end", call))
+	if !call.StartedAt.IsZero() {
+		data := map[string]any{
+			"media":    call.Media,
+			"duration": int(time.Since(call.StartedAt).Seconds()),
+		}
+		ch.messageService.Announce(userId, chatId, "call_ended", data)
+	}
+	return call, ended, nil
+}
+
+func (ch *CallHandler) relay(socket *wss.Socket, event string, data any) {
+	req := SignalFromRequest{}
+	if err := mapstructure.Decode(data, &req); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(req))
+		return
+	}
+
+	call, err := ch.calls.Get(req.ChatId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+	if !slices.Contains(call.Participants, socket.UserId) || !slices.Contains(call.Participants, req.ToUserId) {
+		sendError(socket, fmt.Errorf("users %d and %d aren't both in call in chat %d", socket.UserId, req.ToUserId, req.ChatId))
+		return
+	}
+
+	chatRoom, err := ch.server.Rooms.Get(req.ChatId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	signal := Signal{
+		ChatId:     req.ChatId,
+		FromUserId: socket.UserId,
+		Sdp:        req.Sdp,
+		Candidate:  req.Candidate,
+	}
+	if err := chatRoom.SendTo(req.ToUserId, wss.NewMessage(event, signal)); err != nil {
+		sendError(socket, err)
+	}
+}
+
+// authorize checks that userId participates in chatId and returns the chat's room.
+// Users are only added to a call after passing it, so signaling between call
+// participants doesn't hit the database again.
+func (ch *CallHandler) authorize(userId, chatId int) (*wss.Room, error) {
+	userInChat, err := ch.participantService.UserInChat(userId, chatId)
+	if err != nil {
+		return nil, err
+	}
+	if !userInChat {
+		return nil, fmt.Errorf("user %d doesn't participate in chat %d", userId, chatId)
+	}
+	return ch.server.Rooms.Get(chatId)
+}
+
+func sendMessage(socket *wss.Socket, msg wss.Message) {
+	if err := socket.Message(msg); err != nil {
+		log.Println(err)
+	}
+}
+
+func sendError(socket *wss.Socket, err error) {
+	sendMessage(socket, wss.NewErrorMessage(err.Error()))
+}
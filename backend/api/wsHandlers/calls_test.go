@@ -0,0 +1,247 @@
+package wshandlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/controllers"
+	"github.com/BogPin/real-time-chat/backend/api/wss"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCalls() *safeCalls {
+	return &safeCalls{calls: make(map[int]*Call)}
+}
+
+// dialTestServer serves wsServer for user 1 and returns a client connection to it.
+func dialTestServer(t *testing.T, wsServer *wss.WsServer) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := controllers.TokenPayload{UserId: 1}
+		ctx := context.WithValue(r.Context(), controllers.TokenPayloadKey, payload)
+		wsServer.HttpHandler(w, r.WithContext(ctx))
+	}))
+	t.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("an error '%s' occured while dialing test server", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCallsStartAlreadyInProgressError(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+
+	//Act
+	_, err = calls.Start(3, 2, "video")
+
+	//Assert
+	assert.EqualError(t, err, "call in chat 3 is already in progress")
+	call, err := calls.Get(3)
+	assert.Nil(t, err)
+	assert.Equal(t, "ringing", call.State)
+	assert.Equal(t, []int{1}, call.Participants)
+}
+
+func TestCallsJoinStartsRingingCall(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+
+	//Act
+	call, started, err := calls.Join(3, 2)
+	_, startedAgain, _ := calls.Join(3, 4)
+	_, _, joinedErr := calls.Join(3, 2)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.True(t, started)
+	assert.False(t, startedAgain)
+	assert.Equal(t, "active", call.State)
+	assert.Equal(t, []int{1, 2}, call.Participants)
+	assert.False(t, call.StartedAt.IsZero())
+	assert.EqualError(t, joinedErr, "user 2 already joined call in chat 3")
+}
+
+func TestCallsJoinAfterDecline(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+	_, _, err = calls.Decline(3, 2, 2)
+	assert.Nil(t, err)
+
+	//Act
+	call, started, err := calls.Join(3, 2)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.True(t, started)
+	assert.Equal(t, []int{1, 2}, call.Participants)
+	assert.Empty(t, call.Declined)
+}
+
+func TestCallsLeaveRingingEndsWithInitiator(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+
+	//Act
+	_, _, notInErr := calls.Leave(3, 2)
+	call, ended, err := calls.Leave(3, 1)
+
+	//Assert
+	assert.EqualError(t, notInErr, "user 2 isn't in call in chat 3")
+	assert.Nil(t, err)
+	assert.True(t, ended)
+	assert.Equal(t, "ended", call.State)
+	_, err = calls.Get(3)
+	assert.EqualError(t, err, "no call in chat 3")
+}
+
+func TestCallsLeaveActiveEndsWithLastTwo(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "video")
+	assert.Nil(t, err)
+	_, _, err = calls.Join(3, 2)
+	assert.Nil(t, err)
+	_, _, err = calls.Join(3, 4)
+	assert.Nil(t, err)
+
+	//Act
+	_, initiatorEnded, err := calls.Leave(3, 1)
+	assert.Nil(t, err)
+	call, ended, err := calls.Leave(3, 4)
+
+	//Assert
+	assert.False(t, initiatorEnded)
+	assert.Nil(t, err)
+	assert.True(t, ended)
+	assert.Equal(t, []int{2}, call.Participants)
+	assert.Empty(t, calls.GetAllForUser(2))
+}
+
+func TestCallsDeclineEndsWhenAllInviteesDeclined(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+
+	//Act
+	_, _, initiatorErr := calls.Decline(3, 1, 2)
+	_, firstEnded, err := calls.Decline(3, 2, 2)
+	assert.Nil(t, err)
+	_, _, againErr := calls.Decline(3, 2, 2)
+	call, ended, err := calls.Decline(3, 4, 2)
+
+	//Assert
+	assert.EqualError(t, initiatorErr, "user 1 already joined call in chat 3")
+	assert.False(t, firstEnded)
+	assert.EqualError(t, againErr, "user 2 already declined call in chat 3")
+	assert.Nil(t, err)
+	assert.True(t, ended)
+	assert.Equal(t, "ended", call.State)
+	assert.Equal(t, []int{2, 4}, call.Declined)
+	_, err = calls.Get(3)
+	assert.EqualError(t, err, "no call in chat 3")
+}
+
+func TestCallsDeclineKeepsActiveCall(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+	_, _, err = calls.Join(3, 2)
+	assert.Nil(t, err)
+
+	//Act
+	call, ended, err := calls.Decline(3, 4, 2)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.False(t, ended)
+	assert.Equal(t, "active", call.State)
+}
+
+func TestCallsGetAllForUser(t *testing.T) {
+	//Arrange
+	calls := newTestCalls()
+	_, err := calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+	_, err = calls.Start(5, 2, "audio")
+	assert.Nil(t, err)
+	_, _, err = calls.Join(5, 1)
+	assert.Nil(t, err)
+
+	//Act
+	chatIds := calls.GetAllForUser(1)
+
+	//Assert
+	assert.ElementsMatch(t, []int{3, 5}, chatIds)
+	assert.Equal(t, []int{5}, calls.GetAllForUser(2))
+}
+
+func TestCallHandlerHangsUpOnDisconnect(t *testing.T) {
+	//Arrange
+	wsServer := wss.NewWsServer()
+	callHandler := NewCallHandler(wsServer, nil, nil)
+	wsServer.HandleConnection(callHandler.Register)
+	_, err := callHandler.calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+	client := dialTestServer(t, wsServer)
+
+	//Act
+	client.Close()
+
+	//Assert
+	assert.Eventually(t, func() bool {
+		_, err := callHandler.calls.Get(3)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCallHandlerKeepsCallOnReconnect(t *testing.T) {
+	//Arrange
+	wsServer := wss.NewWsServer()
+	callHandler := NewCallHandler(wsServer, nil, nil)
+	connected := make(chan struct{}, 2)
+	disconnected := make(chan struct{}, 2)
+	wsServer.HandleConnection(func(socket *wss.Socket) {
+		callHandler.Register(socket)
+		socket.On("disconnect", func(data any) { disconnected <- struct{}{} })
+		connected <- struct{}{}
+	})
+	_, err := callHandler.calls.Start(3, 1, "audio")
+	assert.Nil(t, err)
+	first := dialTestServer(t, wsServer)
+	<-connected
+	dialTestServer(t, wsServer)
+	<-connected
+
+	//Act
+	first.Close()
+
+	//Assert
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("first socket wasn't disconnected")
+	}
+	call, err := callHandler.calls.Get(3)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, call.Participants)
+	assert.True(t, wsServer.Conns.Has(1))
+}
@@ -1 +1,165 @@
 package wshandlers
+
+import (
+	"log"
+
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/notifications"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/wss"
+	"github.com/mitchellh/mapstructure"
+)
+
+type MessageHandler struct {
+	server         *wss.WsServer
+	messageService services.IMessageService
+	dispatcher     *notifications.Dispatcher
+}
+
+func NewMessageHandler(server *wss.WsServer, messageService services.IMessageService, dispatcher *notifications.Dispatcher) *MessageHandler {
+	return &MessageHandler{
+		server:         server,
+		messageService: messageService,
+		dispatcher:     dispatcher,
+	}
+}
+
+func (mh *MessageHandler) Register(socket *wss.Socket) {
+	socket.On("message", func(data any) { mh.create(socket, data) })
+	socket.On("message:edit", func(data any) { mh.edit(socket, data) })
+	socket.On("message:delete", func(data any) { mh.delete(socket, data) })
+	socket.On("message:listened", func(data any) { mh.listened(socket, data) })
+	socket.On("message:forward", func(data any) { mh.forward(socket, data) })
+}
+
+// edit changes a message, the service tells the chat with a "message:updated" event.
+func (mh *MessageHandler) edit(socket *wss.Socket, data any) {
+	msg := models.Message{}
+	if err := mapstructure.Decode(data, &msg); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(msg))
+		return
+	}
+
+	_, httpErr := mh.messageService.Update(socket.UserId, msg)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
+
+// delete removes a message for the user or, with forEveryone, for the whole chat,
+// in which case the service tells the chat with a "message:deleted" event.
+func (mh *MessageHandler) delete(socket *wss.Socket, data any) {
+	input := models.MessageDeleteFromRequest{}
+	if err := mapstructure.Decode(data, &input); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(input))
+		return
+	}
+
+	msg, httpErr := mh.messageService.Delete(socket.UserId, input.Id, input.ForEveryone)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+		return
+	}
+	if !input.ForEveryone {
+		sendMessage(socket, wss.NewMessage("message:hidden", msg))
+	}
+}
+
+// listened marks an audio message as listened to, the service tells the chat
+// with a "message:listened" event.
+func (mh *MessageHandler) listened(socket *wss.Socket, data any) {
+	input := models.ListenEvent{}
+	if err := mapstructure.Decode(data, &input); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(input))
+		return
+	}
+
+	_, httpErr := mh.messageService.MarkListened(socket.UserId, input.MessageId)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
+
+// forward sends copies of messages to another chat, they are delivered like new messages.
+func (mh *MessageHandler) forward(socket *wss.Socket, data any) {
+	input := models.ForwardFromRequest{}
+	if err := mapstructure.Decode(data, &input); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(input))
+		return
+	}
+
+	messages, httpErr := mh.messageService.Forward(socket.UserId, input)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+		return
+	}
+	for _, msg := range messages {
+		mh.Deliver(msg)
+	}
+}
+
+func (mh *MessageHandler) create(socket *wss.Socket, data any) {
+	msg := models.MessageFromRequest{}
+	if err := mapstructure.Decode(data, &msg); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(msg))
+		return
+	}
+
+	chatRoom, err := mh.server.Rooms.Get(msg.ChatId)
+	if err != nil {
+		sendError(socket, err)
+		return
+	}
+
+	fullMessage, httpErr := mh.messageService.Create(socket.UserId, msg)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+		return
+	}
+
+	event := messageEvent(*fullMessage)
+	chatRoom.Send(socket.UserId, wss.NewMessage(event, fullMessage))
+	sendMessage(socket, wss.NewMessage(event, fullMessage))
+	mh.sendMentions(socket.UserId, chatRoom, *fullMessage)
+	mh.dispatcher.Notify(*fullMessage)
+}
+
+// Deliver sends a message created outside of a socket, like a scheduled one,
+// to everyone in its chat including the sender.
+func (mh *MessageHandler) Deliver(msg models.Message) {
+	if chatRoom, err := mh.server.Rooms.Get(msg.ChatId); err == nil {
+		chatRoom.Send(0, wss.NewMessage(messageEvent(msg), msg))
+		mh.sendMentions(msg.SenderId, chatRoom, msg)
+	}
+	mh.dispatcher.Notify(msg)
+}
+
+func messageEvent(msg models.Message) string {
+	if msg.ParentId != 0 {
+		return "thread:reply"
+	}
+	return "message"
+}
+
+// sendMentions emits a "mention" event to every mentioned user that is online,
+// regardless of whether they muted the chat.
+func (mh *MessageHandler) sendMentions(fromUser int, chatRoom *wss.Room, msg models.Message) {
+	mention := wss.NewMessage("mention", msg)
+	for _, m := range msg.Mentions {
+		if m.All {
+			chatRoom.Send(fromUser, mention)
+			return
+		}
+	}
+
+	notified := make(map[int]bool)
+	for _, m := range msg.Mentions {
+		if m.UserId == fromUser || notified[m.UserId] || !chatRoom.Has(m.UserId) {
+			continue
+		}
+		notified[m.UserId] = true
+		if err := chatRoom.SendTo(m.UserId, mention); err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package wshandlers
+
+import (
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/wss"
+	"github.com/mitchellh/mapstructure"
+)
+
+// PinHandler handles "pin:add" and "pin:remove" events,
+// the service broadcasts resulting changes to the chat.
+type PinHandler struct {
+	pinService services.IPinService
+}
+
+func NewPinHandler(pinService services.IPinService) *PinHandler {
+	return &PinHandler{pinService: pinService}
+}
+
+func (ph *PinHandler) Register(socket *wss.Socket) {
+	socket.On("pin:add", func(data any) { ph.add(socket, data) })
+	socket.On("pin:remove", func(data any) { ph.remove(socket, data) })
+}
+
+func (ph *PinHandler) add(socket *wss.Socket, data any) {
+	pin := models.PinFromRequest{}
+	if err := mapstructure.Decode(data, &pin); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(pin))
+		return
+	}
+
+	_, httpErr := ph.pinService.Pin(socket.UserId, pin.ChatId, pin.MessageId)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
+
+func (ph *PinHandler) remove(socket *wss.Socket, data any) {
+	pin := models.PinFromRequest{}
+	if err := mapstructure.Decode(data, &pin); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(pin))
+		return
+	}
+
+	_, httpErr := ph.pinService.Unpin(socket.UserId, pin.ChatId, pin.MessageId)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
@@ -0,0 +1,63 @@
+package wshandlers
+
+import (
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/wss"
+	"github.com/mitchellh/mapstructure"
+)
+
+// PollHandler handles "poll:vote", "poll:retract" and "poll:close" events,
+// the service broadcasts new tallies to the chat.
+type PollHandler struct {
+	pollService services.IPollService
+}
+
+func NewPollHandler(pollService services.IPollService) *PollHandler {
+	return &PollHandler{pollService: pollService}
+}
+
+func (ph *PollHandler) Register(socket *wss.Socket) {
+	socket.On("poll:vote", func(data any) { ph.vote(socket, data) })
+	socket.On("poll:retract", func(data any) { ph.retract(socket, data) })
+	socket.On("poll:close", func(data any) { ph.close(socket, data) })
+}
+
+func (ph *PollHandler) vote(socket *wss.Socket, data any) {
+	vote := models.VoteFromRequest{}
+	if err := mapstructure.Decode(data, &vote); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(vote))
+		return
+	}
+
+	_, httpErr := ph.pollService.Vote(socket.UserId, vote.MessageId, vote.OptionIds)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
+
+func (ph *PollHandler) retract(socket *wss.Socket, data any) {
+	vote := models.VoteFromRequest{}
+	if err := mapstructure.Decode(data, &vote); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(vote))
+		return
+	}
+
+	_, httpErr := ph.pollService.Retract(socket.UserId, vote.MessageId, vote.OptionIds)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
+
+func (ph *PollHandler) close(socket *wss.Socket, data any) {
+	vote := models.VoteFromRequest{}
+	if err := mapstructure.Decode(data, &vote); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(vote))
+		return
+	}
+
+	_, httpErr := ph.pollService.Close(socket.UserId, vote.MessageId)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
@@ -0,0 +1,49 @@
+package wshandlers
+
+import (
+	"github.com/BogPin/real-time-chat/backend/api/models"
+	"github.com/BogPin/real-time-chat/backend/api/services"
+	"github.com/BogPin/real-time-chat/backend/api/wss"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ReactionHandler handles "reaction:add" and "reaction:remove" events,
+// the service broadcasts resulting changes to the chat.
+type ReactionHandler struct {
+	reactionService services.IReactionService
+}
+
+func NewReactionHandler(reactionService services.IReactionService) *ReactionHandler {
+	return &ReactionHandler{reactionService: reactionService}
+}
+
+func (rh *ReactionHandler) Register(socket *wss.Socket) {
+	socket.On("reaction:add", func(data any) { rh.add(socket, data) })
+	socket.On("reaction:remove", func(data any) { rh.remove(socket, data) })
+}
+
+func (rh *ReactionHandler) add(socket *wss.Socket, data any) {
+	reaction := models.ReactionFromRequest{}
+	if err := mapstructure.Decode(data, &reaction); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(reaction))
+		return
+	}
+
+	_, httpErr := rh.reactionService.Add(socket.UserId, reaction.MessageId, reaction.Emoji)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
+
+func (rh *ReactionHandler) remove(socket *wss.Socket, data any) {
+	reaction := models.ReactionFromRequest{}
+	if err := mapstructure.Decode(data, &reaction); err != nil {
+		sendMessage(socket, wss.NewErrorInvalidDataFormatMessage(reaction))
+		return
+	}
+
+	_, httpErr := rh.reactionService.Remove(socket.UserId, reaction.MessageId, reaction.Emoji)
+	if httpErr != nil {
+		sendMessage(socket, wss.NewErrorMessage(httpErr.Message()))
+	}
+}
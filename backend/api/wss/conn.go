@@ -0,0 +1,39 @@
+package wss
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conn is a websocket connection that can be written to from any goroutine.
+// gorilla/websocket allows one writer at a time, so writes take turns. Reads
+// are left to the one goroutine listening to the connection.
+type Conn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func NewConn(ws *websocket.Conn) *Conn {
+	return &Conn{ws: ws}
+}
+
+func (c *Conn) WriteJSON(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteJSON(v)
+}
+
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(messageType, data)
+}
+
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	return c.ws.ReadMessage()
+}
+
+func (c *Conn) Close() error {
+	return c.ws.Close()
+}
@@ -3,30 +3,36 @@ package wss
 import (
 	"fmt"
 	"sync"
-
-	"github.com/gorilla/websocket"
-	"golang.org/x/exp/slices"
 )
 
-type room struct {
+type Room struct {
 	mu    sync.RWMutex
 	Id    int
-	conns map[int]*websocket.Conn
+	conns map[int]*Conn
 }
 
-func (r *room) Add(userId int, conn *websocket.Conn) {
+func (r *Room) add(userId int, conn *Conn) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.conns[userId] = conn
 }
 
-func (r *room) Remove(userId int) {
+// remove deletes userId from the room and reports whether the room is empty afterwards.
+func (r *Room) remove(userId int) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.conns, userId)
+	return len(r.conns) == 0
+}
+
+func (r *Room) Has(userId int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.conns[userId]
+	return ok
 }
 
-func (r *room) Send(fromUser int, msg Message) {
+func (r *Room) Send(fromUser int, msg Message) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	for userId, conn := range r.conns {
@@ -38,51 +44,118 @@ func (r *room) Send(fromUser int, msg Message) {
 	}
 }
 
+func (r *Room) SendTo(toUser int, msg Message) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conn, ok := r.conns[toUser]
+	if !ok {
+		return fmt.Errorf("no user %d in room %d", toUser, r.Id)
+	}
+	return conn.WriteJSON(msg)
+}
+
+// safeRooms keeps rooms by id together with a reverse index of the rooms
+// every user is in. Rooms are created on first join and dropped once the
+// last user leaves them. Lock order is safeRooms.mu, then Room.mu.
 type safeRooms struct {
-	mu    sync.RWMutex
-	rooms []*room
+	mu        sync.RWMutex
+	rooms     map[int]*Room
+	userRooms map[int]map[int]*Room
+}
+
+func newSafeRooms() safeRooms {
+	return safeRooms{
+		rooms:     make(map[int]*Room),
+		userRooms: make(map[int]map[int]*Room),
+	}
 }
 
-func (sr *safeRooms) Get(roomId int) (*room, error) {
+func (sr *safeRooms) Get(roomId int) (*Room, error) {
 	sr.mu.RLock()
 	defer sr.mu.RUnlock()
-	i := slices.IndexFunc(sr.rooms, func(r *room) bool { return r.Id == roomId })
-	if i != -1 {
-		return sr.rooms[i], nil
+	if room, ok := sr.rooms[roomId]; ok {
+		return room, nil
 	}
 	return nil, fmt.Errorf("no room with id %d", roomId)
 }
 
-func (sr *safeRooms) Add(roomId int) *room {
+func (sr *safeRooms) Join(roomId, userId int, conn *Conn) *Room {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
-	room := &room{
-		Id:    roomId,
-		conns: make(map[int]*websocket.Conn),
+	room, ok := sr.rooms[roomId]
+	if !ok {
+		room = &Room{
+			Id:    roomId,
+			conns: make(map[int]*Conn),
+		}
+		sr.rooms[roomId] = room
 	}
-	sr.rooms = append(sr.rooms, room)
+	room.add(userId, conn)
+	if _, ok := sr.userRooms[userId]; !ok {
+		sr.userRooms[userId] = make(map[int]*Room)
+	}
+	sr.userRooms[userId][roomId] = room
 	return room
 }
 
+func (sr *safeRooms) Leave(roomId, userId int) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	room, ok := sr.rooms[roomId]
+	if !ok {
+		return fmt.Errorf("no room with id %d", roomId)
+	}
+	sr.leave(room, userId)
+	return nil
+}
+
+func (sr *safeRooms) LeaveAll(userId int) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	for _, room := range sr.userRooms[userId] {
+		sr.leave(room, userId)
+	}
+}
+
+// leave must be called with sr.mu held.
+func (sr *safeRooms) leave(room *Room, userId int) {
+	if room.remove(userId) {
+		delete(sr.rooms, room.Id)
+	}
+	if userRooms, ok := sr.userRooms[userId]; ok {
+		delete(userRooms, room.Id)
+		if len(userRooms) == 0 {
+			delete(sr.userRooms, userId)
+		}
+	}
+}
+
 func (sr *safeRooms) Remove(roomId int) error {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
-	i := slices.IndexFunc(sr.rooms, func(r *room) bool { return r.Id == roomId })
-	if i != -1 {
-		sr.rooms = slices.Delete(sr.rooms, i, i+1)
-		return nil
+	room, ok := sr.rooms[roomId]
+	if !ok {
+		return fmt.Errorf("no room with id %d", roomId)
+	}
+	room.mu.RLock()
+	userIds := make([]int, 0, len(room.conns))
+	for userId := range room.conns {
+		userIds = append(userIds, userId)
 	}
-	return fmt.Errorf("no room with id %d", roomId)
+	room.mu.RUnlock()
+	for _, userId := range userIds {
+		sr.leave(room, userId)
+	}
+	delete(sr.rooms, roomId)
+	return nil
 }
 
-func (sr *safeRooms) GetAllForUser(userId int) []*room {
+func (sr *safeRooms) GetAllForUser(userId int) []*Room {
 	sr.mu.RLock()
 	defer sr.mu.RUnlock()
-	rooms := make([]*room, 0)
-	for _, room := range sr.rooms {
-		if _, ok := room.conns[userId]; ok {
-			rooms = append(rooms, room)
-		}
+	rooms := make([]*Room, 0, len(sr.userRooms[userId]))
+	for _, room := range sr.userRooms[userId] {
+		rooms = append(rooms, room)
 	}
 	return rooms
 }
@@ -0,0 +1,205 @@
+package wss
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConn returns the server side of a websocket connection
+// whose client side discards everything it receives.
+func newTestConn(tb testing.TB) *Conn {
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			tb.Errorf("an error '%s' occured while upgrading connection", err)
+			return
+		}
+		conns <- conn
+	}))
+	tb.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		tb.Fatalf("an error '%s' occured while dialing test server", err)
+	}
+	tb.Cleanup(func() { client.Close() })
+	go func() {
+		for {
+			if _, _, err := client.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	return NewConn(<-conns)
+}
+
+func TestRoomSendConcurrently(t *testing.T) {
+	//Arrange
+	rooms := newSafeRooms()
+	conn := newTestConn(t)
+	rooms.Join(1, 10, conn)
+	rooms.Join(2, 10, conn)
+	msg := NewMessage("message", "hello")
+
+	//Act
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(roomId int) {
+			defer wg.Done()
+			room, err := rooms.Get(roomId)
+			assert.Nil(t, err)
+			room.Send(-1, msg)
+			assert.Nil(t, room.SendTo(10, msg))
+		}(i%2 + 1)
+	}
+
+	//Assert
+	wg.Wait()
+}
+
+func TestRoomsJoinIndexesUser(t *testing.T) {
+	//Arrange
+	rooms := newSafeRooms()
+
+	//Act
+	rooms.Join(1, 10, nil)
+	rooms.Join(2, 10, nil)
+	rooms.Join(2, 20, nil)
+
+	//Assert
+	assert.Len(t, rooms.GetAllForUser(10), 2)
+	assert.Len(t, rooms.GetAllForUser(20), 1)
+	assert.Len(t, rooms.GetAllForUser(30), 0)
+}
+
+func TestRoomsLeaveRemovesEmptyRoom(t *testing.T) {
+	//Arrange
+	rooms := newSafeRooms()
+	rooms.Join(1, 10, nil)
+	rooms.Join(1, 20, nil)
+
+	//Act
+	err1 := rooms.Leave(1, 10)
+	_, getErr1 := rooms.Get(1)
+	err2 := rooms.Leave(1, 20)
+	_, getErr2 := rooms.Get(1)
+
+	//Assert
+	assert.Nil(t, err1)
+	assert.Nil(t, getErr1)
+	assert.Nil(t, err2)
+	assert.Equal(t, fmt.Errorf("no room with id %d", 1), getErr2)
+	assert.Len(t, rooms.rooms, 0)
+	assert.Len(t, rooms.userRooms, 0)
+}
+
+func TestRoomsLeaveAll(t *testing.T) {
+	//Arrange
+	rooms := newSafeRooms()
+	rooms.Join(1, 10, nil)
+	rooms.Join(2, 10, nil)
+	rooms.Join(2, 20, nil)
+
+	//Act
+	rooms.LeaveAll(10)
+
+	//Assert
+	_, err := rooms.Get(1)
+	assert.NotNil(t, err)
+	room, err := rooms.Get(2)
+	assert.Nil(t, err)
+	assert.False(t, room.Has(10))
+	assert.True(t, room.Has(20))
+	assert.Len(t, rooms.GetAllForUser(10), 0)
+}
+
+func TestRoomsRemove(t *testing.T) {
+	//Arrange
+	rooms := newSafeRooms()
+	rooms.Join(1, 10, nil)
+	rooms.Join(1, 20, nil)
+	rooms.Join(2, 20, nil)
+
+	//Act
+	err := rooms.Remove(1)
+
+	//Assert
+	assert.Nil(t, err)
+	assert.Len(t, rooms.GetAllForUser(10), 0)
+	assert.Len(t, rooms.GetAllForUser(20), 1)
+}
+
+const (
+	benchRooms       = 10000
+	benchRoomsByUser = 50
+	benchRoomSize    = 20
+)
+
+// populatedRooms returns benchRooms rooms, each one shared by benchRoomSize users
+// that are in benchRoomsByUser rooms.
+func populatedRooms(conn *Conn) *safeRooms {
+	rooms := newSafeRooms()
+	users := benchRooms * benchRoomSize / benchRoomsByUser
+	for userId := 0; userId < users; userId++ {
+		for i := 0; i < benchRoomsByUser; i++ {
+			rooms.Join((userId*benchRoomsByUser+i)%benchRooms, userId, conn)
+		}
+	}
+	return &rooms
+}
+
+func BenchmarkRoomsJoin(b *testing.B) {
+	rooms := populatedRooms(nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rooms.Join(i%benchRooms, -1-i%benchRoomsByUser, nil)
+	}
+}
+
+func BenchmarkRoomsGet(b *testing.B) {
+	rooms := populatedRooms(nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = rooms.Get(i % benchRooms)
+	}
+}
+
+func BenchmarkRoomBroadcast(b *testing.B) {
+	rooms := populatedRooms(newTestConn(b))
+	msg := NewMessage("message", "hello")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		room, err := rooms.Get(i % benchRooms)
+		if err != nil {
+			b.Fatal(err)
+		}
+		room.Send(-1, msg)
+	}
+}
+
+func BenchmarkRoomsDisconnect(b *testing.B) {
+	rooms := populatedRooms(nil)
+	users := benchRooms * benchRoomSize / benchRoomsByUser
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		userId := i % users
+		rooms.LeaveAll(userId)
+		b.StopTimer()
+		for j := 0; j < benchRoomsByUser; j++ {
+			rooms.Join((userId*benchRoomsByUser+j)%benchRooms, userId, nil)
+		}
+		b.StartTimer()
+	}
+}
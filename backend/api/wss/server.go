@@ -37,10 +37,10 @@ func NewErrorInvalidDataFormatMessage(val any) Message {
 
 type safeConns struct {
 	mu    sync.RWMutex
-	conns map[int]*websocket.Conn
+	conns map[int]*Conn
 }
 
-func (sc *safeConns) Get(userId int) (*websocket.Conn, error) {
+func (sc *safeConns) Get(userId int) (*Conn, error) {
 	sc.mu.RLock()
 	defer sc.mu.RUnlock()
 	if conn, ok := sc.conns[userId]; ok {
@@ -49,6 +49,13 @@ func (sc *safeConns) Get(userId int) (*websocket.Conn, error) {
 	return nil, fmt.Errorf("no socket with user id %d", userId)
 }
 
+func (sc *safeConns) Has(userId int) bool {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	_, ok := sc.conns[userId]
+	return ok
+}
+
 func (sc *safeConns) SendAll(fromUser int, msg Message) {
 	sc.mu.RLock()
 	defer sc.mu.RUnlock()
@@ -61,16 +68,22 @@ func (sc *safeConns) SendAll(fromUser int, msg Message) {
 	}
 }
 
-func (sc *safeConns) Add(userId int, conn *websocket.Conn) {
+func (sc *safeConns) Add(userId int, conn *Conn) {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
 	sc.conns[userId] = conn
 }
 
-func (sc *safeConns) Remove(userId int) {
+// Remove removes the connection of userId if it is still conn, it reports
+// false when the user connected again since.
+func (sc *safeConns) Remove(userId int, conn *Conn) bool {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
+	if sc.conns[userId] != conn {
+		return false
+	}
 	delete(sc.conns, userId)
+	return true
 }
 
 type WsServer struct {
@@ -83,11 +96,9 @@ type WsServer struct {
 func NewWsServer() *WsServer {
 	return &WsServer{
 		Conns: safeConns{
-			conns: make(map[int]*websocket.Conn),
-		},
-		Rooms: safeRooms{
-			rooms: make([]*room, 0),
+			conns: make(map[int]*Conn),
 		},
+		Rooms: newSafeRooms(),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -101,12 +112,15 @@ func (wss *WsServer) HandleConnection(handler func(socket *Socket)) {
 }
 
 func (wss *WsServer) listenMessages(socket *Socket) {
+	go socket.processEvents()
+	defer close(socket.events)
 	for {
 		messageType, msg, err := socket.conn.ReadMessage()
 		if err != nil {
 			fmt.Println("read message error:", err)
-			socket.emit("disconnect", nil)
+			// disconnect listeners run once nothing is sent to the socket anymore
 			socket.PostDisconnect()
+			socket.events <- NewMessage("disconnect", nil)
 			return
 		}
 		if messageType != websocket.TextMessage {
@@ -123,9 +137,10 @@ func (wss *WsServer) listenMessages(socket *Socket) {
 			if err != nil {
 				log.Println(err)
 			}
+			continue
 		}
 
-		go socket.emit(message.Event, message.Data)
+		socket.events <- message
 	}
 }
 
@@ -136,7 +151,13 @@ func (wss *WsServer) HttpHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	conn, _ := wss.upgrader.Upgrade(w, r, nil)
+	ws, err := wss.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has answered the request already
+		log.Println(err)
+		return
+	}
+	conn := NewConn(ws)
 	socket := NewSocket(payload.UserId, conn, wss)
 	wss.Conns.Add(socket.UserId, conn)
 	wss.socketHandler(socket)
@@ -0,0 +1,122 @@
+package wss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BogPin/real-time-chat/backend/api/controllers"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// dialTestServer serves wsServer for user 1 and returns a client connection to it.
+func dialTestServer(t *testing.T, wsServer *WsServer) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := controllers.TokenPayload{UserId: 1}
+		ctx := context.WithValue(r.Context(), controllers.TokenPayloadKey, payload)
+		wsServer.HttpHandler(w, r.WithContext(ctx))
+	}))
+	t.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("an error '%s' occured while dialing test server", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestListenMessagesKeepsOrder(t *testing.T) {
+	//Arrange
+	count := 100
+	received := make(chan int, count)
+	wsServer := NewWsServer()
+	wsServer.HandleConnection(func(socket *Socket) {
+		socket.On("message", func(data any) {
+			n := int(data.(float64))
+			time.Sleep(time.Duration(n%3) * time.Millisecond)
+			received <- n
+		})
+	})
+	client := dialTestServer(t, wsServer)
+
+	//Act
+	for i := 0; i < count; i++ {
+		if err := client.WriteJSON(NewMessage("message", i)); err != nil {
+			t.Fatalf("an error '%s' occured while writing message", err)
+		}
+	}
+
+	//Assert
+	for i := 0; i < count; i++ {
+		select {
+		case n := <-received:
+			assert.Equal(t, i, n)
+		case <-time.After(time.Second):
+			t.Fatalf("message %d wasn't received", i)
+		}
+	}
+}
+
+func TestListenMessagesMalformedFrame(t *testing.T) {
+	//Arrange
+	emitted := make(chan string, 2)
+	wsServer := NewWsServer()
+	wsServer.HandleConnection(func(socket *Socket) {
+		socket.On("", func(data any) { emitted <- "" })
+		socket.On("ping", func(data any) { emitted <- "ping" })
+	})
+	client := dialTestServer(t, wsServer)
+
+	//Act
+	err := client.WriteMessage(websocket.TextMessage, []byte("not json"))
+	if err != nil {
+		t.Fatalf("an error '%s' occured while writing message", err)
+	}
+	_, resp, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("an error '%s' occured while reading message", err)
+	}
+	err = client.WriteJSON(NewMessage("ping", nil))
+	if err != nil {
+		t.Fatalf("an error '%s' occured while writing message", err)
+	}
+
+	//Assert
+	assert.Equal(t, MessageFormatErr, string(resp))
+	select {
+	case event := <-emitted:
+		assert.Equal(t, "ping", event)
+	case <-time.After(time.Second):
+		t.Fatal("ping wasn't received")
+	}
+}
+
+func TestPostDisconnectKeepsNewerConn(t *testing.T) {
+	//Arrange
+	wsServer := NewWsServer()
+	old := NewSocket(1, &Conn{}, wsServer)
+	newer := NewSocket(1, &Conn{}, wsServer)
+	wsServer.Conns.Add(1, old.conn)
+	old.Join(3)
+	wsServer.Conns.Add(1, newer.conn)
+	newer.Join(3)
+
+	//Act
+	old.PostDisconnect()
+
+	//Assert
+	assert.True(t, old.Superseded())
+	assert.False(t, newer.Superseded())
+	conn, err := wsServer.Conns.Get(1)
+	assert.Nil(t, err)
+	assert.Same(t, newer.conn, conn)
+	room, err := wsServer.Rooms.Get(3)
+	assert.Nil(t, err)
+	assert.True(t, room.Has(1))
+}
@@ -2,34 +2,48 @@ package wss
 
 import (
 	"log"
+	"sync"
 
 	"github.com/gorilla/websocket"
 )
 
+// EventQueueSize is how many received events may wait for their listeners
+// before the socket stops reading new frames from the connection.
+const EventQueueSize = 64
+
 type Socket struct {
 	UserId    int
-	conn      *websocket.Conn
+	conn      *Conn
+	mu        sync.RWMutex
 	listeners map[string][]func(data any)
+	events    chan Message
 	server    *WsServer
 }
 
-func NewSocket(userId int, conn *websocket.Conn, server *WsServer) *Socket {
+func NewSocket(userId int, conn *Conn, server *WsServer) *Socket {
 	return &Socket{
 		UserId:    userId,
 		conn:      conn,
 		listeners: make(map[string][]func(data any)),
+		events:    make(chan Message, EventQueueSize),
 		server:    server,
 	}
 }
 
+// PostDisconnect takes the socket out of the server. Nothing is removed when
+// the user connected again since, their newer socket owns their rooms then.
 func (s *Socket) PostDisconnect() {
-	s.server.Conns.Remove(s.UserId)
-	chats := s.server.Rooms.GetAllForUser(s.UserId)
-	for _, chat := range chats {
-		chat.Remove(s.UserId)
+	if s.server.Conns.Remove(s.UserId, s.conn) {
+		s.server.Rooms.LeaveAll(s.UserId)
 	}
 }
 
+// Superseded reports whether the user connected again after this socket.
+func (s *Socket) Superseded() bool {
+	conn, err := s.server.Conns.Get(s.UserId)
+	return err == nil && conn != s.conn
+}
+
 func (s *Socket) Disconnect(code int, reason string) {
 	cm := websocket.FormatCloseMessage(code, reason)
 	err := s.conn.WriteMessage(websocket.CloseMessage, cm)
@@ -41,34 +55,38 @@ func (s *Socket) Disconnect(code int, reason string) {
 }
 
 func (s *Socket) On(event string, listener func(data any)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.listeners[event] = append(s.listeners[event], listener)
 }
 
 func (s *Socket) emit(event string, data any) {
-	if listeners, ok := s.listeners[event]; ok {
-		for _, listener := range listeners {
-			listener(data)
-		}
+	s.mu.RLock()
+	listeners := s.listeners[event]
+	s.mu.RUnlock()
+	for _, listener := range listeners {
+		listener(data)
 	}
 }
 
+// processEvents runs listeners for queued events one at a time, so events
+// sent by a client are handled in the order they were received.
+// It returns once the queue is closed and drained.
+func (s *Socket) processEvents() {
+	for event := range s.events {
+		s.emit(event.Event, event.Data)
+	}
+	s.PostDisconnect()
+}
+
 func (s *Socket) Message(msg Message) error {
 	return s.conn.WriteJSON(msg)
 }
 
 func (s *Socket) Join(roomId int) {
-	room, err := s.server.Rooms.Get(roomId)
-	if err != nil {
-		room = s.server.Rooms.Add(roomId)
-	}
-	room.Add(s.UserId, s.conn)
+	s.server.Rooms.Join(roomId, s.UserId, s.conn)
 }
 
 func (s *Socket) Leave(roomId int) error {
-	room, err := s.server.Rooms.Get(roomId)
-	if err != nil {
-		return err
-	}
-	room.Remove(s.UserId)
-	return nil
+	return s.server.Rooms.Leave(roomId, s.UserId)
 }
@@ -0,0 +1,15 @@
+DELETE FROM public.messages WHERE type = 'system';
+
+ALTER TYPE public.message_type RENAME TO message_type_old;
+
+CREATE TYPE public.message_type AS ENUM (
+    'text',
+    'image',
+    'video'
+);
+
+ALTER TABLE public.messages ALTER COLUMN type DROP DEFAULT;
+ALTER TABLE public.messages ALTER COLUMN type TYPE public.message_type USING type::text::public.message_type;
+ALTER TABLE public.messages ALTER COLUMN type SET DEFAULT 'text'::public.message_type;
+
+DROP TYPE public.message_type_old;
@@ -0,0 +1 @@
+ALTER TYPE public.message_type ADD VALUE 'system';
@@ -0,0 +1,5 @@
+ALTER TABLE public.participants DROP COLUMN muted;
+
+DROP TABLE public.devices;
+
+DROP TYPE public.device_platform;
@@ -0,0 +1,18 @@
+CREATE TYPE public.device_platform AS ENUM (
+    'webpush',
+    'apns',
+    'fcm'
+);
+
+CREATE TABLE public.devices (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    platform public.device_platform NOT NULL,
+    token text NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    UNIQUE (platform, token)
+);
+
+CREATE INDEX devices_user_id_idx ON public.devices (user_id);
+
+ALTER TABLE public.participants ADD COLUMN muted boolean DEFAULT false NOT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE public.mentions (
+    message_id integer NOT NULL REFERENCES public.messages(id) ON DELETE CASCADE,
+    user_id integer REFERENCES public.users(id) ON DELETE CASCADE,
+    "position" integer NOT NULL,
+    length integer NOT NULL,
+    PRIMARY KEY (message_id, "position")
+);
+
+COMMENT ON COLUMN public.mentions.user_id IS 'NULL for @all mentions';
+
+CREATE INDEX mentions_user_id_idx ON public.mentions (user_id);
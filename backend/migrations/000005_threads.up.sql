@@ -0,0 +1,3 @@
+ALTER TABLE public.messages ADD COLUMN parent_id integer REFERENCES public.messages(id) ON DELETE CASCADE;
+
+CREATE INDEX messages_parent_id_idx ON public.messages (parent_id, created_at);
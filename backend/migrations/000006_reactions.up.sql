@@ -0,0 +1,7 @@
+CREATE TABLE public.reactions (
+    message_id integer NOT NULL REFERENCES public.messages(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    emoji text NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY (message_id, user_id, emoji)
+);
@@ -0,0 +1,3 @@
+DROP TABLE public.chat_settings;
+DROP TABLE public.message_revisions;
+ALTER TABLE public.messages DROP COLUMN edited_at;
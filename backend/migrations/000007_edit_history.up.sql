@@ -0,0 +1,19 @@
+ALTER TABLE public.messages ADD COLUMN edited_at timestamp without time zone;
+
+CREATE TABLE public.message_revisions (
+    id serial PRIMARY KEY,
+    message_id integer NOT NULL REFERENCES public.messages(id) ON DELETE CASCADE,
+    content character varying(1024) NOT NULL,
+    edited_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON COLUMN public.message_revisions.content IS 'content the message had before the edit';
+
+CREATE INDEX message_revisions_message_id_idx ON public.message_revisions (message_id, edited_at);
+
+CREATE TABLE public.chat_settings (
+    chat_id integer PRIMARY KEY REFERENCES public.chats(id) ON DELETE CASCADE,
+    edit_window integer DEFAULT 0 NOT NULL CHECK (edit_window >= 0)
+);
+
+COMMENT ON COLUMN public.chat_settings.edit_window IS 'seconds messages stay editable after being sent, 0 for no limit';
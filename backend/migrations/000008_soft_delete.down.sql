@@ -0,0 +1,3 @@
+ALTER TABLE public.chat_settings DROP COLUMN delete_window;
+DROP TABLE public.hidden_messages;
+ALTER TABLE public.messages DROP COLUMN deleted_at, DROP COLUMN deleted_by;
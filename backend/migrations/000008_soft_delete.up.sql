@@ -0,0 +1,13 @@
+ALTER TABLE public.messages
+    ADD COLUMN deleted_at timestamp without time zone,
+    ADD COLUMN deleted_by integer REFERENCES public.users(id) ON DELETE SET NULL;
+
+CREATE TABLE public.hidden_messages (
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    message_id integer NOT NULL REFERENCES public.messages(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, message_id)
+);
+
+ALTER TABLE public.chat_settings ADD COLUMN delete_window integer DEFAULT 0 NOT NULL CHECK (delete_window >= 0);
+
+COMMENT ON COLUMN public.chat_settings.delete_window IS 'seconds senders can delete messages for everyone after sending, 0 for no limit';
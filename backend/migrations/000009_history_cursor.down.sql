@@ -0,0 +1 @@
+DROP INDEX public.messages_chat_id_created_at_idx;
@@ -0,0 +1 @@
+CREATE INDEX messages_chat_id_created_at_idx ON public.messages (chat_id, created_at, id) WHERE parent_id IS NULL;
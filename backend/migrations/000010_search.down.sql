@@ -0,0 +1 @@
+ALTER TABLE public.messages DROP COLUMN search;
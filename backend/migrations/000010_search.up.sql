@@ -0,0 +1,3 @@
+ALTER TABLE public.messages ADD COLUMN search tsvector GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+
+CREATE INDEX messages_search_idx ON public.messages USING gin (search);
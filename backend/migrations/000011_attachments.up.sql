@@ -0,0 +1,15 @@
+CREATE TABLE public.attachments (
+    id serial PRIMARY KEY,
+    uploader_id integer REFERENCES public.users(id) ON DELETE SET NULL,
+    chat_id integer NOT NULL REFERENCES public.chats(id) ON DELETE CASCADE,
+    message_id integer REFERENCES public.messages(id) ON DELETE CASCADE,
+    key text NOT NULL UNIQUE,
+    file_name character varying(255) NOT NULL,
+    mime_type character varying(127) NOT NULL,
+    size bigint NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON COLUMN public.attachments.message_id IS 'NULL until the attachment is sent with a message';
+
+CREATE INDEX attachments_message_id_idx ON public.attachments (message_id);
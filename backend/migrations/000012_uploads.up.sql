@@ -0,0 +1,18 @@
+CREATE TABLE public.uploads (
+    id character varying(32) PRIMARY KEY,
+    uploader_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    chat_id integer NOT NULL REFERENCES public.chats(id) ON DELETE CASCADE,
+    file_name character varying(255) NOT NULL,
+    upload_length bigint NOT NULL,
+    upload_offset bigint DEFAULT 0 NOT NULL,
+    chunks text[] DEFAULT '{}' NOT NULL,
+    claimed_at timestamp with time zone,
+    attachment_id integer REFERENCES public.attachments(id) ON DELETE SET NULL,
+    expires_at timestamp with time zone NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON COLUMN public.uploads.chunks IS 'storage keys of the received chunks in upload order';
+COMMENT ON COLUMN public.uploads.claimed_at IS 'set while the upload is being turned into an attachment';
+
+CREATE INDEX uploads_expires_at_idx ON public.uploads (expires_at);
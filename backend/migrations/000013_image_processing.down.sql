@@ -0,0 +1,10 @@
+DROP INDEX public.attachments_unprocessed_idx;
+
+ALTER TABLE public.attachments
+    DROP COLUMN width,
+    DROP COLUMN height,
+    DROP COLUMN blurhash,
+    DROP COLUMN variants,
+    DROP COLUMN processed_at,
+    DROP COLUMN processing_failed,
+    DROP COLUMN processing_until;
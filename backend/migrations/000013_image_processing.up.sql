@@ -0,0 +1,14 @@
+ALTER TABLE public.attachments
+    ADD COLUMN width integer,
+    ADD COLUMN height integer,
+    ADD COLUMN blurhash character varying(64),
+    ADD COLUMN variants jsonb DEFAULT '[]' NOT NULL,
+    ADD COLUMN processed_at timestamp without time zone,
+    ADD COLUMN processing_failed boolean DEFAULT false NOT NULL,
+    ADD COLUMN processing_until timestamp with time zone;
+
+COMMENT ON COLUMN public.attachments.processed_at IS 'NULL until an image is stripped of metadata and has its thumbnails';
+COMMENT ON COLUMN public.attachments.processing_until IS 'lease of the worker processing the image';
+
+CREATE INDEX attachments_unprocessed_idx ON public.attachments (id)
+    WHERE processed_at IS NULL AND message_id IS NOT NULL AND mime_type LIKE 'image/%';
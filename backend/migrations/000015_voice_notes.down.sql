@@ -0,0 +1,5 @@
+DROP TABLE public.message_listens;
+
+ALTER TABLE public.attachments
+    DROP COLUMN duration,
+    DROP COLUMN waveform;
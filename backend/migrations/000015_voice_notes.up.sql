@@ -0,0 +1,12 @@
+ALTER TABLE public.attachments
+    ADD COLUMN duration integer,
+    ADD COLUMN waveform smallint[];
+
+COMMENT ON COLUMN public.attachments.duration IS 'milliseconds of audio';
+
+CREATE TABLE public.message_listens (
+    message_id integer NOT NULL REFERENCES public.messages(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    listened_at timestamp without time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY (message_id, user_id)
+);
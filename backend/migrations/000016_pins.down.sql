@@ -0,0 +1,4 @@
+ALTER TABLE public.chat_settings
+    DROP COLUMN max_pins,
+    DROP COLUMN members_can_pin;
+DROP TABLE public.pinned_messages;
@@ -0,0 +1,15 @@
+CREATE TABLE public.pinned_messages (
+    chat_id integer NOT NULL REFERENCES public.chats(id) ON DELETE CASCADE,
+    message_id integer NOT NULL REFERENCES public.messages(id) ON DELETE CASCADE,
+    pinned_by integer REFERENCES public.users(id) ON DELETE SET NULL,
+    pinned_at timestamp without time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE INDEX pinned_messages_chat_id_idx ON public.pinned_messages (chat_id, pinned_at);
+
+ALTER TABLE public.chat_settings
+    ADD COLUMN max_pins integer DEFAULT 0 NOT NULL CHECK (max_pins >= 0),
+    ADD COLUMN members_can_pin boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN public.chat_settings.max_pins IS 'how many messages can be pinned at once, 0 for the default';
@@ -0,0 +1,21 @@
+CREATE TABLE public.scheduled_messages (
+    id serial PRIMARY KEY,
+    sender_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    chat_id integer NOT NULL REFERENCES public.chats(id) ON DELETE CASCADE,
+    type character varying(16) NOT NULL,
+    content character varying(1024) NOT NULL,
+    parent_id integer REFERENCES public.messages(id) ON DELETE CASCADE,
+    attachment_ids integer[] DEFAULT '{}' NOT NULL,
+    send_at timestamp with time zone NOT NULL,
+    time_zone character varying(64) DEFAULT '' NOT NULL,
+    claimed_until timestamp with time zone,
+    failed_at timestamp with time zone,
+    error text DEFAULT '' NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON COLUMN public.scheduled_messages.time_zone IS 'IANA time zone send_at was given in, empty when it had an offset';
+COMMENT ON COLUMN public.scheduled_messages.claimed_until IS 'set while a worker sends the message, others pick it up once it passes';
+
+CREATE INDEX scheduled_messages_send_at_idx ON public.scheduled_messages (send_at) WHERE failed_at IS NULL;
+CREATE INDEX scheduled_messages_sender_id_idx ON public.scheduled_messages (sender_id, send_at);
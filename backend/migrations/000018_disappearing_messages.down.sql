@@ -0,0 +1,2 @@
+ALTER TABLE public.chat_settings DROP COLUMN message_ttl;
+ALTER TABLE public.messages DROP COLUMN expires_at;
@@ -0,0 +1,7 @@
+ALTER TABLE public.messages ADD COLUMN expires_at timestamp with time zone;
+
+CREATE INDEX messages_expires_at_idx ON public.messages (expires_at) WHERE expires_at IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TABLE public.chat_settings ADD COLUMN message_ttl integer DEFAULT 0 NOT NULL CHECK (message_ttl >= 0);
+
+COMMENT ON COLUMN public.chat_settings.message_ttl IS 'seconds new messages live before being deleted, 0 to keep them';
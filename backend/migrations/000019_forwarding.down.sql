@@ -0,0 +1,8 @@
+DELETE FROM public.attachments a USING public.attachments b WHERE a.key = b.key AND a.id > b.id;
+DROP INDEX public.attachments_key_idx;
+ALTER TABLE public.attachments ADD CONSTRAINT attachments_key_key UNIQUE (key);
+
+ALTER TABLE public.messages
+    DROP COLUMN forwarded_from_id,
+    DROP COLUMN forwarded_sender_id,
+    DROP COLUMN forwarded_chat_id;
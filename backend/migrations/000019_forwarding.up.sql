@@ -0,0 +1,10 @@
+ALTER TABLE public.messages
+    ADD COLUMN forwarded_from_id integer REFERENCES public.messages(id) ON DELETE SET NULL,
+    ADD COLUMN forwarded_sender_id integer REFERENCES public.users(id) ON DELETE SET NULL,
+    ADD COLUMN forwarded_chat_id integer REFERENCES public.chats(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN public.messages.forwarded_from_id IS 'original message of a forwarded one, forwarding a forward keeps the original';
+
+-- forwarded attachments share the blob of the original
+ALTER TABLE public.attachments DROP CONSTRAINT attachments_key_key;
+CREATE INDEX attachments_key_idx ON public.attachments (key);
@@ -0,0 +1,5 @@
+ALTER TABLE public.scheduled_messages
+    DROP COLUMN format,
+    ALTER COLUMN content TYPE character varying(1024) USING left(content, 1024);
+
+ALTER TABLE public.messages DROP COLUMN entities;
@@ -0,0 +1,8 @@
+ALTER TABLE public.messages ADD COLUMN entities jsonb DEFAULT '[]'::jsonb NOT NULL;
+
+COMMENT ON COLUMN public.messages.entities IS 'formatting of content, offsets and lengths are in UTF-16 code units';
+
+-- scheduled messages keep the markdown they are sent with, which is longer than the text it renders to
+ALTER TABLE public.scheduled_messages
+    ADD COLUMN format character varying(16) DEFAULT '' NOT NULL,
+    ALTER COLUMN content TYPE character varying(4096);
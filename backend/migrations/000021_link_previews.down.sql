@@ -0,0 +1,2 @@
+DROP TABLE public.link_previews;
+ALTER TABLE public.messages DROP COLUMN preview;
@@ -0,0 +1,13 @@
+ALTER TABLE public.messages ADD COLUMN preview jsonb;
+
+COMMENT ON COLUMN public.messages.preview IS 'preview of the first link in content, filled in the background';
+
+CREATE TABLE public.link_previews (
+    url text NOT NULL PRIMARY KEY,
+    preview jsonb,
+    fetched_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON COLUMN public.link_previews.preview IS 'NULL when the link could not be previewed';
+
+CREATE INDEX link_previews_fetched_at_idx ON public.link_previews (fetched_at);
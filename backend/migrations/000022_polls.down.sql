@@ -0,0 +1,21 @@
+DROP TABLE public.poll_votes;
+DROP TABLE public.poll_options;
+DROP TABLE public.polls;
+
+DELETE FROM public.messages WHERE type = 'poll';
+
+ALTER TYPE public.message_type RENAME TO message_type_old;
+
+CREATE TYPE public.message_type AS ENUM (
+    'text',
+    'image',
+    'video',
+    'system',
+    'audio'
+);
+
+ALTER TABLE public.messages ALTER COLUMN type DROP DEFAULT;
+ALTER TABLE public.messages ALTER COLUMN type TYPE public.message_type USING type::text::public.message_type;
+ALTER TABLE public.messages ALTER COLUMN type SET DEFAULT 'text'::public.message_type;
+
+DROP TYPE public.message_type_old;
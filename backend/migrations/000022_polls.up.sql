@@ -0,0 +1,31 @@
+ALTER TYPE public.message_type ADD VALUE 'poll';
+
+CREATE TABLE public.polls (
+    message_id integer NOT NULL PRIMARY KEY REFERENCES public.messages(id) ON DELETE CASCADE,
+    question character varying(300) NOT NULL,
+    multiple boolean DEFAULT false NOT NULL,
+    anonymous boolean DEFAULT false NOT NULL,
+    closes_at timestamp with time zone,
+    closed_at timestamp with time zone
+);
+
+COMMENT ON COLUMN public.polls.closes_at IS 'when the poll stops taking votes by itself';
+COMMENT ON COLUMN public.polls.closed_at IS 'when the poll was closed by hand';
+
+CREATE TABLE public.poll_options (
+    id serial PRIMARY KEY,
+    message_id integer NOT NULL REFERENCES public.polls(message_id) ON DELETE CASCADE,
+    "position" integer NOT NULL,
+    text character varying(100) NOT NULL,
+    UNIQUE (message_id, "position")
+);
+
+CREATE TABLE public.poll_votes (
+    option_id integer NOT NULL REFERENCES public.poll_options(id) ON DELETE CASCADE,
+    message_id integer NOT NULL REFERENCES public.polls(message_id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    voted_at timestamp with time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY (option_id, user_id)
+);
+
+CREATE INDEX poll_votes_message_id_idx ON public.poll_votes (message_id, user_id);
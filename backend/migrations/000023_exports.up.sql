@@ -0,0 +1,22 @@
+CREATE TABLE public.chat_exports (
+    id serial PRIMARY KEY,
+    chat_id integer NOT NULL REFERENCES public.chats(id) ON DELETE CASCADE,
+    requested_by integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    format character varying(16) NOT NULL,
+    status character varying(16) DEFAULT 'pending' NOT NULL,
+    key character varying(255),
+    size bigint DEFAULT 0 NOT NULL,
+    messages integer DEFAULT 0 NOT NULL,
+    error text DEFAULT '' NOT NULL,
+    claimed_until timestamp with time zone,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    finished_at timestamp with time zone,
+    expires_at timestamp with time zone
+);
+
+COMMENT ON COLUMN public.chat_exports.status IS 'pending, running, ready or failed';
+COMMENT ON COLUMN public.chat_exports.messages IS 'how many messages were written so far';
+COMMENT ON COLUMN public.chat_exports.claimed_until IS 'set while a worker writes the export, others pick it up once it passes';
+
+CREATE INDEX chat_exports_chat_id_idx ON public.chat_exports (chat_id, created_at);
+CREATE INDEX chat_exports_pending_idx ON public.chat_exports (created_at) WHERE status IN ('pending', 'running');
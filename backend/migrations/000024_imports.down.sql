@@ -0,0 +1,2 @@
+DROP TABLE public.imported_users;
+DROP TABLE public.imports;
@@ -0,0 +1,35 @@
+CREATE TABLE public.imports (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    source character varying(16) NOT NULL,
+    options jsonb DEFAULT '{}' NOT NULL,
+    key character varying(255) NOT NULL,
+    status character varying(16) DEFAULT 'pending' NOT NULL,
+    chat_ids integer[] DEFAULT '{}' NOT NULL,
+    users_created integer DEFAULT 0 NOT NULL,
+    messages_total integer DEFAULT 0 NOT NULL,
+    messages_imported integer DEFAULT 0 NOT NULL,
+    errors jsonb DEFAULT '[]' NOT NULL,
+    claimed_until timestamp with time zone,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    finished_at timestamp with time zone
+);
+
+COMMENT ON COLUMN public.imports.key IS 'the uploaded export in blob storage, deleted once the import is done';
+COMMENT ON COLUMN public.imports.errors IS 'what couldn''t be imported, the import goes on past them';
+
+CREATE INDEX imports_user_id_idx ON public.imports (user_id, created_at);
+CREATE INDEX imports_pending_idx ON public.imports (created_at) WHERE status IN ('pending', 'running');
+
+CREATE TABLE public.imported_users (
+    importer_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    source character varying(16) NOT NULL,
+    source_key character varying(255) NOT NULL,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    placeholder boolean DEFAULT false NOT NULL,
+    PRIMARY KEY (importer_id, source, source_key)
+);
+
+COMMENT ON TABLE public.imported_users IS 'local users that source users were mapped to, so later imports map them the same way';
+COMMENT ON COLUMN public.imported_users.importer_id IS 'the user whose imports map source users this way';
+COMMENT ON COLUMN public.imported_users.placeholder IS 'the user was made by the import, nobody can log in as them';